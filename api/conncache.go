@@ -0,0 +1,60 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// defaultConnCacheTTL is how long a profile's connection list is reused unless
+// SetConnectionCacheTTL changes it
+const defaultConnCacheTTL = 30 * time.Second
+
+// cachedConnections is a connection list and when it was read.
+type cachedConnections struct {
+	connections []*models.Connection
+	loaded      time.Time
+}
+
+// connCache holds the last connection list of each profile, so clicking around the
+// export page does not scan the connection table every time. Imports and deletes
+// through the server invalidate the profile they touched.
+type connCache struct {
+	mu    sync.Mutex
+	lists map[string]cachedConnections
+}
+
+// get returns the cached list of a profile if it is younger than ttl.
+func (c *connCache) get(id string, ttl time.Duration, now time.Time) (cachedConnections, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.lists[id]
+	if !ok || now.Sub(cached.loaded) > ttl {
+		return cachedConnections{}, false
+	}
+	return cached, true
+}
+
+func (c *connCache) set(id string, connections []*models.Connection, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lists == nil {
+		c.lists = make(map[string]cachedConnections)
+	}
+	c.lists[id] = cachedConnections{connections: connections, loaded: now}
+}
+
+// invalidate drops the cached list of a profile.
+func (c *connCache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.lists, id)
+}
+
+// clear drops every cached list.
+func (c *connCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lists = nil
+}
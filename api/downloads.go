@@ -0,0 +1,84 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// downloadTTL is how long a kept export file can be downloaded again
+const downloadTTL = 15 * time.Minute
+
+// keptDownload is an export file kept on disk until it expires.
+type keptDownload struct {
+	path     string
+	filename string
+	expires  time.Time
+}
+
+// downloadStore maps random tokens to kept export files. Files are removed when
+// their token expires, so a kept export never outlives downloadTTL.
+type downloadStore struct {
+	mu        sync.Mutex
+	downloads map[string]keptDownload
+}
+
+// keep registers the file at path for downloadTTL and returns its token.
+func (d *downloadStore) keep(path, filename string) (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate download token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+
+	d.mu.Lock()
+	if d.downloads == nil {
+		d.downloads = make(map[string]keptDownload)
+	}
+	d.downloads[token] = keptDownload{path: path, filename: filename, expires: time.Now().Add(downloadTTL)}
+	d.mu.Unlock()
+
+	time.AfterFunc(downloadTTL, func() { d.expire(token) })
+	return token, nil
+}
+
+// get returns the file of a token that has not expired.
+func (d *downloadStore) get(token string, now time.Time) (keptDownload, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept, ok := d.downloads[token]
+	if !ok || now.After(kept.expires) {
+		return keptDownload{}, false
+	}
+	return kept, true
+}
+
+// expire forgets a token and removes its file.
+func (d *downloadStore) expire(token string) {
+	d.mu.Lock()
+	kept, ok := d.downloads[token]
+	delete(d.downloads, token)
+	d.mu.Unlock()
+
+	if ok {
+		os.Remove(kept.path)
+	}
+}
+
+// handleKeptDownload serves a kept export file as often as needed until its token expires.
+func (s *Server) handleKeptDownload(w http.ResponseWriter, r *http.Request) {
+	kept, ok := s.downloads.get(r.PathValue("token"), time.Now())
+	if !ok {
+		http.Error(w, "Download link expired, run the export again", http.StatusGone)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", kept.filename))
+	w.Header().Set("Content-Type", "text/csv")
+	http.ServeFile(w, r, kept.path)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+const (
+	// exportHistoryKey is the secret holding the recent web exports, newest first
+	exportHistoryKey = "web:export_history"
+
+	// maxExportHistory is how many exports the Recent Exports page remembers
+	maxExportHistory = 50
+)
+
+// exportHistoryEntry records a web export. File keys are not recorded, and only kept
+// files can be downloaded again, until their token expires.
+type exportHistoryEntry struct {
+	Filename    string    `json:"filename"`
+	ProfileID   string    `json:"profile_id"`
+	ProfileName string    `json:"profile_name"`
+	ExportedAt  time.Time `json:"exported_at"`
+	Count       int       `json:"count"`
+	Token       string    `json:"token,omitempty"` // Token of the kept file, see downloadStore
+	Expires     time.Time `json:"expires,omitzero"`
+}
+
+// recentExport is an export history entry as listed on the page.
+type recentExport struct {
+	exportHistoryEntry
+	DownloadURL string // Empty once the file is gone
+}
+
+// recordExport adds an export to the history, dropping the oldest beyond maxExportHistory.
+// Failures are logged only, as the export itself succeeded.
+func (s *Server) recordExport(entry exportHistoryEntry) {
+	err := s.secrets.Transaction(func(txn *secrets.Txn) error {
+		history := readExportHistory(txn.Get)
+		history = append([]exportHistoryEntry{entry}, history...)
+		if len(history) > maxExportHistory {
+			history = history[:maxExportHistory]
+		}
+		data, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+		txn.Set(exportHistoryKey, string(data))
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to record export %s: %v", entry.Filename, err)
+	}
+}
+
+// readExportHistory returns the stored history, or nil when there is none or it cannot be parsed.
+func readExportHistory(get func(key string) (string, error)) []exportHistoryEntry {
+	data, err := get(exportHistoryKey)
+	if err != nil {
+		return nil
+	}
+	var history []exportHistoryEntry
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		return nil
+	}
+	return history
+}
+
+// handleExportsPage lists the recent exports, linking the files that can still be downloaded.
+func (s *Server) handleExportsPage(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	var exports []recentExport
+	for _, entry := range readExportHistory(s.secrets.Get) {
+		export := recentExport{exportHistoryEntry: entry}
+		if entry.Token != "" {
+			if _, ok := s.downloads.get(entry.Token, now); ok {
+				export.DownloadURL = "/downloads/" + entry.Token
+			}
+		}
+		exports = append(exports, export)
+	}
+
+	s.renderPage(w, "exports", map[string]any{
+		"Page":    "exports",
+		"Title":   "Recent Exports",
+		"Exports": exports,
+	})
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+// maxLockCheckInterval bounds how late the background check locks an idle store
+const maxLockCheckInterval = 30 * time.Second
+
+// idleClock remembers when the server last answered a request.
+type idleClock struct {
+	mu   sync.Mutex
+	last time.Time
+}
+
+func (c *idleClock) touch(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = now
+}
+
+// idleFor returns how long no request came in, counting from the first one.
+func (c *idleClock) idleFor(now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last.IsZero() {
+		c.last = now
+	}
+	return now.Sub(c.last)
+}
+
+// SetLockAfter locks the secrets store after this long without requests. Requests
+// then answer 401 until the master password is posted to /unlock (web) or /api/unlock
+// (JSON). Zero or less never locks.
+func (s *Server) SetLockAfter(d time.Duration) {
+	s.lockAfter = max(d, 0)
+}
+
+// lockIdle locks the secrets store once lockAfter has passed without requests, and
+// drops the cached connection lists and import plans that hold decrypted passwords.
+func (s *Server) lockIdle(now time.Time) {
+	if s.lockAfter <= 0 || s.secrets.Locked() || s.idle.idleFor(now) < s.lockAfter {
+		return
+	}
+	s.secrets.Lock()
+	s.connections.clear()
+	s.plans.clear()
+	log.Printf("secrets store locked after %s without requests", s.lockAfter)
+}
+
+// watchIdle locks the store in the background, so it does not wait for the next request.
+func (s *Server) watchIdle() {
+	if s.lockAfter <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(min(s.lockAfter, maxLockCheckInterval))
+		defer ticker.Stop()
+		for now := range ticker.C {
+			s.lockIdle(now)
+		}
+	}()
+}
+
+// guardLock wraps the routes so they answer 401 while the store is locked, except the
+// health check and the unlock routes. Other requests count as activity.
+func (s *Server) guardLock(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.lockAfter <= 0 || r.URL.Path == "/health" || r.URL.Path == "/unlock" || r.URL.Path == "/api/unlock" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		s.lockIdle(now)
+		if !s.secrets.Locked() {
+			s.idle.touch(now)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		unlock := "/unlock?next=" + url.QueryEscape(r.URL.RequestURI())
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/"):
+			httpError(w, "secrets store is locked, POST the master password to /api/unlock", http.StatusUnauthorized)
+		case r.Header.Get("HX-Request") == "true":
+			// Fragments cannot show the form, send the whole page there
+			w.Header().Set("HX-Redirect", "/unlock")
+			http.Error(w, "Secrets store is locked", http.StatusUnauthorized)
+		default:
+			http.Redirect(w, r, unlock, http.StatusSeeOther)
+		}
+	})
+}
+
+// unlock unlocks the secrets store with password and restarts the idle clock.
+func (s *Server) unlock(password string) error {
+	if err := s.secrets.Unlock(password); err != nil {
+		return err
+	}
+	s.idle.touch(time.Now())
+	return nil
+}
+
+// Unlock page
+func (s *Server) handleUnlockPage(w http.ResponseWriter, r *http.Request) {
+	if !s.secrets.Locked() {
+		http.Redirect(w, r, safeNext(r.URL.Query().Get("next")), http.StatusSeeOther)
+		return
+	}
+	s.renderPage(w, "unlock", map[string]any{"Page": "unlock", "Title": "Locked", "Next": r.URL.Query().Get("next")})
+}
+
+func (s *Server) handleUnlockForm(w http.ResponseWriter, r *http.Request) {
+	next := r.FormValue("next")
+	err := s.unlock(r.FormValue("password"))
+	if errors.Is(err, secrets.ErrInvalidPassword) {
+		w.WriteHeader(http.StatusUnauthorized)
+		s.renderPage(w, "unlock", map[string]any{"Page": "unlock", "Title": "Locked", "Next": next, "Error": "Wrong master password"})
+		return
+	}
+	if err != nil {
+		log.Printf("unlock: %v", err)
+	}
+	http.Redirect(w, r, safeNext(next), http.StatusSeeOther)
+}
+
+// Unlock the secrets store
+func (s *Server) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err := s.unlock(req.Password)
+	if errors.Is(err, secrets.ErrInvalidPassword) {
+		httpError(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// safeNext returns next when it is a path on this server, or the home page.
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
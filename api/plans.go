@@ -0,0 +1,136 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// planTTL is how long an import plan can be applied after it was made
+const planTTL = 10 * time.Minute
+
+// keptPlan is an import request with the connections read when it was planned.
+type keptPlan struct {
+	req     models.ImportRequest
+	expires time.Time
+}
+
+// planStore maps random plan IDs to planned imports. A plan is applied at most once.
+type planStore struct {
+	mu    sync.Mutex
+	plans map[string]keptPlan
+}
+
+// keep registers req for planTTL and returns its plan ID and expiry.
+func (p *planStore) keep(req models.ImportRequest, now time.Time) (string, time.Time, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate plan ID: %w", err)
+	}
+	id := hex.EncodeToString(b)
+	expires := now.Add(planTTL)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.plans == nil {
+		p.plans = make(map[string]keptPlan)
+	}
+	// Expired plans hold decrypted connections, drop them rather than wait for a lookup
+	for planID, kept := range p.plans {
+		if now.After(kept.expires) {
+			delete(p.plans, planID)
+		}
+	}
+	p.plans[id] = keptPlan{req: req, expires: expires}
+	return id, expires, nil
+}
+
+// get returns the plan of an ID that has not expired.
+func (p *planStore) get(id string, now time.Time) (keptPlan, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	kept, ok := p.plans[id]
+	if !ok || now.After(kept.expires) {
+		return keptPlan{}, false
+	}
+	return kept, true
+}
+
+// take forgets a plan and reports whether it was still there, so only one apply runs it.
+func (p *planStore) take(id string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.plans[id]
+	delete(p.plans, id)
+	return ok
+}
+
+// clear forgets every plan.
+func (p *planStore) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.plans = nil
+}
+
+// planImport answers POST /api/connections/import?plan=true: it reads the file and
+// returns what the import would do, kept under a plan ID for handleApplyImportPlan.
+func (s *Server) planImport(w http.ResponseWriter, r *http.Request, req models.ImportRequest) {
+	plan, err := s.migrator.PlanImport(r.Context(), req)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if plan.Success {
+		// The file is read, applying needs neither it nor its key
+		req.Records = plan.Records
+		req.FileDecryptionKey, req.FilePassphrase = "", ""
+		plan.PlanID, plan.ExpiresAt, err = s.plans.keep(req, time.Now())
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(plan)
+}
+
+// Apply an import plan
+func (s *Server) handleApplyImportPlan(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		PlanID  string `json:"plan_id"`
+		Confirm bool   `json:"confirm"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		httpError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	kept, ok := s.plans.get(body.PlanID, time.Now())
+	if !ok {
+		httpError(w, "plan not found or expired, plan the import again", http.StatusNotFound)
+		return
+	}
+	if kept.req.PurgeBeforeImport && !body.Confirm {
+		httpError(w, "this plan purges connections, set confirm to true to apply it", http.StatusBadRequest)
+		return
+	}
+	if !s.plans.take(body.PlanID) {
+		httpError(w, "plan not found or expired, plan the import again", http.StatusNotFound)
+		return
+	}
+
+	result, err := s.migrator.Import(r.Context(), kept.req)
+	s.connections.invalidate(kept.req.TargetProfile.ID)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// fernetRateBurst is how many Fernet requests a client may make at once
+	fernetRateBurst = 10
+
+	// fernetRatePerSecond is how fast a client's allowance refills
+	fernetRatePerSecond = 1.0
+
+	// unlockRateBurst is how many unlock attempts a client may make at once
+	unlockRateBurst = 5
+
+	// unlockRatePerSecond refills one unlock attempt every 10 seconds
+	unlockRatePerSecond = 0.1
+
+	// rateLimiterIdle is how long an unused client bucket is kept
+	rateLimiterIdle = 10 * time.Minute
+)
+
+// bucket is a token bucket for one client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token-bucket limiter keyed by client IP.
+type rateLimiter struct {
+	burst     float64
+	perSecond float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// allow takes a token from the client's bucket, reporting false when it is empty.
+func (l *rateLimiter) allow(client string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = make(map[string]*bucket)
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		l.prune(now)
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.perSecond
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune drops buckets idle long enough to be full again.
+func (l *rateLimiter) prune(now time.Time) {
+	for client, b := range l.buckets {
+		if now.Sub(b.last) > rateLimiterIdle {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// clientIP returns the IP of the request's remote address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limited wraps a handler so clients exceeding the Fernet rate limit get 429.
+func (s *Server) limited(h http.HandlerFunc) http.HandlerFunc {
+	return limit(&s.fernetLimiter, h)
+}
+
+// unlockLimited wraps an unlock handler in the stricter unlock rate limit, so
+// guessing the master password is not paced by the Fernet budget.
+func (s *Server) unlockLimited(h http.HandlerFunc) http.HandlerFunc {
+	return limit(&s.unlockLimiter, h)
+}
+
+// limit wraps a handler so clients l does not allow get 429.
+func limit(l *rateLimiter, h http.HandlerFunc) http.HandlerFunc {
+	// Retry once the next token is due
+	retryAfter := strconv.Itoa(int(math.Ceil(1 / l.perSecond)))
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !l.allow(clientIP(r), time.Now()) {
+			w.Header().Set("Retry-After", retryAfter)
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				httpError(w, "too many requests", http.StatusTooManyRequests)
+			} else {
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			}
+			return
+		}
+		h(w, r)
+	}
+}
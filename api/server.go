@@ -1,20 +1,62 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/flevanti/airflow-migrator/internal/core"
 	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/core/services"
 	"github.com/flevanti/airflow-migrator/internal/secrets"
 )
 
+// defaultMaxUploadSize is the web import upload limit unless SetMaxUploadSize changes it
+const defaultMaxUploadSize = 10 << 20
+
 // Server is the HTTP API server.
 type Server struct {
 	migrator  *core.Migrator
 	secrets   *secrets.Store
 	mux       *http.ServeMux
 	configDir string
+	stats     statsCache
+	readOnly  bool
+
+	// connections caches connection lists for connCacheTTL; zero disables it
+	connections  connCache
+	connCacheTTL time.Duration
+
+	// maxUploadSize caps the size of files uploaded to the web import
+	maxUploadSize int64
+
+	// confirmDeleteByName requires the profile name to delete a profile
+	confirmDeleteByName bool
+
+	fernetLimiter rateLimiter
+
+	// unlockLimiter paces master password attempts, apart from fernetLimiter
+	unlockLimiter rateLimiter
+
+	// downloads holds the export files kept for repeated download
+	downloads downloadStore
+
+	// plans holds the import plans waiting to be applied
+	plans planStore
+
+	// filenameTemplate names web export files, see models.ExportRequest.FilenameTemplate
+	filenameTemplate string
+
+	// lockAfter locks the secrets store after this long without requests; zero never locks
+	lockAfter time.Duration
+	idle      idleClock
 }
 
 // NewServer creates a new HTTP server.
@@ -24,6 +66,12 @@ func NewServer(migrator *core.Migrator, secrets *secrets.Store, configDir string
 		secrets:   secrets,
 		mux:       http.NewServeMux(),
 		configDir: configDir,
+
+		maxUploadSize: defaultMaxUploadSize,
+		connCacheTTL:  defaultConnCacheTTL,
+
+		fernetLimiter: rateLimiter{burst: fernetRateBurst, perSecond: fernetRatePerSecond},
+		unlockLimiter: rateLimiter{burst: unlockRateBurst, perSecond: unlockRatePerSecond},
 	}
 	s.setupRoutes()
 	return s
@@ -38,23 +86,129 @@ func (s *Server) setupRoutes() {
 
 	// JSON API - Connections
 	s.mux.HandleFunc("POST /api/connections/list", s.handleListConnections)
-	s.mux.HandleFunc("POST /api/connections/export", s.handleExport)
-	s.mux.HandleFunc("POST /api/connections/import", s.handleImport)
+	s.mux.HandleFunc("POST /api/connections/export", s.writable(s.handleExport))
+	s.mux.HandleFunc("POST /api/connections/import", s.writable(s.handleImport))
+	s.mux.HandleFunc("POST /api/connections/import/apply", s.writable(s.handleApplyImportPlan))
 	s.mux.HandleFunc("POST /api/connections/test", s.handleTestConnection)
+	s.mux.HandleFunc("DELETE /api/connections", s.writable(s.handleDeleteConnections))
+	s.mux.HandleFunc("POST /api/files/reencrypt", s.writable(s.handleReencrypt))
 
 	// Fernet
-	s.mux.HandleFunc("GET /api/fernet/generate", s.handleGenerateFernetKey)
-	s.mux.HandleFunc("POST /api/fernet/validate", s.handleValidateFernetKey)
+	s.mux.HandleFunc("GET /api/fernet/generate", s.limited(s.handleGenerateFernetKey))
+	s.mux.HandleFunc("POST /api/fernet/validate", s.limited(s.handleValidateFernetKey))
 
 	// Profiles
 	s.mux.HandleFunc("GET /api/profiles", s.handleListProfiles)
-	s.mux.HandleFunc("POST /api/profiles", s.handleSaveProfile)
-	s.mux.HandleFunc("DELETE /api/profiles/{id}", s.handleDeleteProfile)
+	s.mux.HandleFunc("GET /api/profiles/{id}", s.handleGetProfile)
+	s.mux.HandleFunc("POST /api/profiles", s.writable(s.handleSaveProfile))
+	s.mux.HandleFunc("DELETE /api/profiles/{id}", s.writable(s.handleDeleteProfile))
+	s.mux.HandleFunc("POST /api/profiles/{id}/clone", s.writable(s.handleCloneProfile))
+	s.mux.HandleFunc("GET /api/secrets/orphans", s.handleListOrphans)
+	s.mux.HandleFunc("DELETE /api/secrets/orphans", s.writable(s.handleCleanOrphans))
+
+	// Scheduled exports
+	s.mux.HandleFunc("GET /api/schedules", s.handleListSchedules)
+
+	// Idle lock, see SetLockAfter
+	s.mux.HandleFunc("POST /api/unlock", s.unlockLimited(s.handleUnlock))
+}
+
+// SetReadOnly disables every route that writes files, Airflow databases or secrets.
+func (s *Server) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// SetMaxUploadSize sets the largest file, in bytes, the web import accepts.
+// Values of zero or less keep the default of 10MB.
+func (s *Server) SetMaxUploadSize(size int64) {
+	if size <= 0 {
+		size = defaultMaxUploadSize
+	}
+	s.maxUploadSize = size
+}
+
+// SetConnectionCacheTTL sets how long the web UI reuses a profile's connection list.
+// Zero or less turns the cache off.
+func (s *Server) SetConnectionCacheTTL(ttl time.Duration) {
+	s.connCacheTTL = max(ttl, 0)
+}
+
+// SetFilenameTemplate names web export files with a text/template of .Profile,
+// .Timestamp and .Count. Empty keeps airflow_<profile>_<timestamp>.csv.
+func (s *Server) SetFilenameTemplate(tmpl string) {
+	s.filenameTemplate = tmpl
+}
+
+// SetConfirmDeleteByName requires the profile name to be typed (web) or passed as
+// ?confirm=<name> (API) to delete a profile.
+func (s *Server) SetConfirmDeleteByName(confirm bool) {
+	s.confirmDeleteByName = confirm
+}
+
+// deleteConfirmed reports whether a profile delete may go ahead given the typed name.
+func (s *Server) deleteConfirmed(id, typed string) bool {
+	if !s.confirmDeleteByName {
+		return true
+	}
+	profile := s.loadProfile(id)
+	return profile != nil && typed == profile.Name
+}
+
+// writable wraps a mutating handler so it answers 403 in read-only mode.
+func (s *Server) writable(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.readOnly {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				httpError(w, "server is in read-only mode", http.StatusForbidden)
+			} else {
+				http.Error(w, "Server is in read-only mode", http.StatusForbidden)
+			}
+			return
+		}
+		h(w, r)
+	}
 }
 
 // Start starts the HTTP server.
+// Scheduled exports from the config directory run for as long as the server does.
 func (s *Server) Start(addr string) error {
-	return http.ListenAndServe(addr, s.mux)
+	s.startSchedules(context.Background())
+	s.watchIdle()
+	return http.ListenAndServe(addr, s.guardLock(s.mux))
+}
+
+// startSchedules starts the exports listed in the schedule file. Invalid entries
+// are logged and skipped so they do not keep the server from starting.
+func (s *Server) startSchedules(ctx context.Context) {
+	schedules, err := core.LoadSchedules(s.configDir)
+	if err != nil {
+		log.Printf("scheduled exports disabled: %v", err)
+		return
+	}
+
+	for _, sched := range schedules {
+		load := s.scheduledProfile(sched.ProfileID)
+		if err := s.migrator.ScheduleExport(ctx, load, sched.Interval, sched.Dir, sched.Rotation, sched.Webhook); err != nil {
+			log.Printf("scheduled export of %s skipped: %v", sched.ProfileID, err)
+			continue
+		}
+		log.Printf("scheduled export of %s every %s into %s", sched.ProfileID, sched.Interval, sched.Dir)
+	}
+}
+
+// scheduledProfile loads a profile from the store before each scheduled run. While
+// the store is locked the run is skipped rather than use a copy decrypted earlier.
+func (s *Server) scheduledProfile(id string) core.ProfileLoader {
+	return func() (*models.Profile, error) {
+		if s.secrets.Locked() {
+			return nil, secrets.ErrLocked
+		}
+		profile := s.loadProfile(id)
+		if profile == nil {
+			return nil, fmt.Errorf("profile %s not found", id)
+		}
+		return profile, nil
+	}
 }
 
 // Health check
@@ -76,16 +230,26 @@ func (s *Server) handleListConnections(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	connections, err := s.migrator.ListConnections(r.Context(), req.Profile)
+	connections, err := s.migrator.ListConnectionsFiltered(r.Context(), req.Profile, req.Filter())
 	if err != nil {
 		httpError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	var expanded []string
+	if req.ExpandHostURIs {
+		for _, conn := range connections {
+			if services.ExpandHostURI(conn) {
+				expanded = append(expanded, conn.ID)
+			}
+		}
+	}
+
 	json.NewEncoder(w).Encode(models.ListConnectionsResult{
 		Success:     true,
 		Connections: connections,
 		Count:       len(connections),
+		ExpandedIDs: expanded,
 	})
 }
 
@@ -101,6 +265,10 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 		httpError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := req.Validate(); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	result, err := s.migrator.Export(r.Context(), req)
 	if err != nil {
@@ -123,8 +291,72 @@ func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
 		httpError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := req.Validate(); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Get("plan") == "true" {
+		if req.Resume {
+			httpError(w, "resume cannot be combined with a plan", http.StatusBadRequest)
+			return
+		}
+		s.planImport(w, r, req)
+		return
+	}
+	if req.PurgeBeforeImport && !req.Confirm {
+		httpError(w, "this import purges connections, set confirm to true to run it", http.StatusBadRequest)
+		return
+	}
 
 	result, err := s.migrator.Import(r.Context(), req)
+	s.connections.invalidate(req.TargetProfile.ID)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// Delete connections by prefix (DELETE /api/connections?prefix=test_)
+func (s *Server) handleDeleteConnections(w http.ResponseWriter, r *http.Request) {
+	var req models.DeleteConnectionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if prefix := r.URL.Query().Get("prefix"); prefix != "" {
+		req.Prefix = prefix
+	}
+	if req.Profile == nil {
+		httpError(w, "profile is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.loadProfileSecrets(req.Profile); err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.migrator.DeleteConnectionsByPrefix(r.Context(), req.Profile, req.Prefix, req.Confirm)
+	s.connections.invalidate(req.Profile.ID)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(result)
+}
+
+// Re-encrypt an export file under another key
+func (s *Server) handleReencrypt(w http.ResponseWriter, r *http.Request) {
+	var req models.ReencryptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.migrator.Reencrypt(r.Context(), req)
 	if err != nil {
 		httpError(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -179,18 +411,32 @@ func (s *Server) handleValidateFernetKey(w http.ResponseWriter, r *http.Request)
 	}
 
 	valid := s.migrator.ValidateFernetKey(req.Key)
-
-	result := models.ValidateFernetKeyResult{Valid: valid}
-	if valid {
-		result.Message = "Valid Fernet key"
-	} else {
+	info, err := services.InspectKey(req.Key)
+
+	result := models.ValidateFernetKeyResult{
+		Valid:             valid,
+		Encoding:          info.Encoding,
+		Length:            info.Length,
+		URLSafe:           info.URLSafe,
+		AirflowCompatible: info.AirflowCompatible,
+		Warning:           info.Warning,
+	}
+	switch {
+	case !valid && err != nil:
+		result.Message = err.Error()
+	case !valid:
 		result.Message = "Invalid Fernet key"
+	case info.Warning != "":
+		result.Message = "Valid Fernet key, but not accepted by Airflow"
+	default:
+		result.Message = "Valid Fernet key"
 	}
 
 	json.NewEncoder(w).Encode(result)
 }
 
 // List saved profiles
+// With ?stats=true each profile also reports a live connection count
 func (s *Server) handleListProfiles(w http.ResponseWriter, r *http.Request) {
 	// Get all profile keys from secrets
 	keys := s.secrets.List()
@@ -218,9 +464,25 @@ func (s *Server) handleListProfiles(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if r.URL.Query().Get("stats") == "true" {
+		json.NewEncoder(w).Encode(s.collectProfileStats(r.Context(), profiles))
+		return
+	}
+
 	json.NewEncoder(w).Encode(profiles)
 }
 
+// Get one profile, without its password and Fernet key
+func (s *Server) handleGetProfile(w http.ResponseWriter, r *http.Request) {
+	profile := s.loadProfile(r.PathValue("id"))
+	if profile == nil {
+		httpError(w, "profile not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(profile.Details())
+}
+
 // Save profile
 func (s *Server) handleSaveProfile(w http.ResponseWriter, r *http.Request) {
 	var profile models.Profile
@@ -229,37 +491,99 @@ func (s *Server) handleSaveProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// New profiles get their ID from NewProfile
+	if profile.ID == "" {
+		fresh := models.NewProfile(profile.Name)
+		profile.ID = fresh.ID
+		profile.CreatedAt = fresh.CreatedAt
+	}
+
 	keys := profile.GetSecretKeys()
+	values := make(map[string]string)
 
 	// Store password
 	if profile.DBPassword != "" {
-		if err := s.secrets.Set(keys.Password, profile.DBPassword); err != nil {
-			httpError(w, "failed to save password", http.StatusInternalServerError)
-			return
-		}
+		values[keys.Password] = profile.DBPassword
 	}
 
 	// Store Fernet key
 	if profile.FernetKey != "" {
-		if err := s.secrets.Set(keys.FernetKey, profile.FernetKey); err != nil {
-			httpError(w, "failed to save fernet key", http.StatusInternalServerError)
-			return
-		}
+		values[keys.FernetKey] = profile.FernetKey
 	}
 
 	// Store metadata (non-sensitive)
 	profile.Touch()
-	metaKey := "profile:" + profile.ID + ":meta"
 	metaJSON, _ := json.Marshal(profile.Summary())
-	if err := s.secrets.Set(metaKey, string(metaJSON)); err != nil {
-		httpError(w, "failed to save profile metadata", http.StatusInternalServerError)
+	values["profile:"+profile.ID+":meta"] = string(metaJSON)
+
+	if err := s.secrets.SetBatch(values); err != nil {
+		httpError(w, "failed to save profile", http.StatusInternalServerError)
 		return
 	}
+	s.connections.invalidate(profile.ID)
+	s.stats.invalidate(profile.ID)
 
 	json.NewEncoder(w).Encode(map[string]string{"status": "saved", "id": profile.ID})
 }
 
-// Delete profile
+// Clone a profile with another Fernet key, e.g. to try a rotation candidate
+// against the same database. An empty key generates a new one.
+func (s *Server) handleCloneProfile(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string `json:"name"`
+		FernetKey string `json:"fernet_key"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httpError(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	source := s.loadProfile(r.PathValue("id"))
+	if source == nil {
+		httpError(w, "profile not found", http.StatusNotFound)
+		return
+	}
+
+	if req.FernetKey == "" {
+		key, err := s.migrator.GenerateFernetKey()
+		if err != nil {
+			httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		req.FernetKey = key
+	} else if !s.migrator.ValidateFernetKey(req.FernetKey) {
+		httpError(w, "invalid fernet key", http.StatusBadRequest)
+		return
+	}
+	if req.Name == "" {
+		req.Name = source.Name + " (new key)"
+	}
+
+	clone := source.Clone()
+	fresh := models.NewProfile(req.Name)
+	clone.ID = fresh.ID
+	clone.Name = req.Name
+	clone.CreatedAt = fresh.CreatedAt
+	clone.FernetKey = req.FernetKey
+
+	keys := clone.GetSecretKeys()
+	err := s.secrets.SetBatch(map[string]string{
+		keys.Password:                   clone.DBPassword,
+		keys.FernetKey:                  clone.FernetKey,
+		"profile:" + clone.ID + ":meta": profileToJSON(clone),
+	})
+	if err != nil {
+		httpError(w, "failed to save profile", http.StatusInternalServerError)
+		return
+	}
+	s.stats.invalidate(clone.ID)
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "cloned", "id": clone.ID, "fernet_key": clone.FernetKey})
+}
+
+// Delete profile (DELETE /api/profiles/{id}?confirm=<name> or ?force=true)
 func (s *Server) handleDeleteProfile(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -267,20 +591,71 @@ func (s *Server) handleDeleteProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete all profile keys
-	keys := []string{
-		"profile:" + id + ":password",
-		"profile:" + id + ":fernet",
-		"profile:" + id + ":meta",
+	// The options come from an optional JSON body, overridden by the query
+	var req models.DeleteProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		httpError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	if query.Has("confirm") {
+		req.Confirm = query.Get("confirm")
+	}
+	if query.Has("force") {
+		req.Force, _ = strconv.ParseBool(query.Get("force"))
+	}
+
+	if !req.Force && !s.deleteConfirmed(id, req.Confirm) {
+		httpError(w, "pass the profile name as ?confirm=<name>, or ?force=true, to delete it", http.StatusBadRequest)
+		return
 	}
 
-	for _, key := range keys {
-		s.secrets.Delete(key) // Ignore errors for non-existent keys
+	if err := s.deleteProfileSecrets(id); err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, secrets.ErrLocked) {
+			code = http.StatusLocked
+		}
+		httpError(w, "failed to delete profile: "+err.Error(), code)
+		return
 	}
 
 	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
 }
 
+// deleteProfileSecrets removes all keys of a profile in a single save
+func (s *Server) deleteProfileSecrets(id string) error {
+	s.connections.invalidate(id)
+	s.stats.invalidate(id)
+	keys := (&models.Profile{ID: id}).GetSecretKeys()
+	return s.secrets.Transaction(func(txn *secrets.Txn) error {
+		txn.Delete(keys.Password) // Ignore errors for non-existent keys
+		txn.Delete(keys.FernetKey)
+		txn.Delete("profile:" + id + ":meta")
+		txn.Delete(keys.LastExportSelection)
+		return nil
+	})
+}
+
+// List scheduled exports and their last run
+func (s *Server) handleListSchedules(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string][]models.ScheduleStatus{"schedules": s.migrator.Schedules()})
+}
+
+// List profile secrets whose profile metadata is missing
+func (s *Server) handleListOrphans(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string][]string{"orphans": s.migrator.FindOrphanedSecrets(s.secrets)})
+}
+
+// Delete profile secrets whose profile metadata is missing
+func (s *Server) handleCleanOrphans(w http.ResponseWriter, r *http.Request) {
+	deleted, err := s.migrator.CleanOrphanedSecrets(s.secrets)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string][]string{"deleted": deleted})
+}
+
 // Helper: load secrets into profile
 func (s *Server) loadProfileSecrets(profile *models.Profile) error {
 	if profile == nil {
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+const (
+	// statsTimeout bounds how long a single profile may take to report its stats
+	statsTimeout = 5 * time.Second
+
+	// statsCacheTTL is how long live stats are reused before reconnecting
+	statsCacheTTL = 60 * time.Second
+)
+
+// statsCache holds the last live stats of each profile.
+type statsCache struct {
+	mu    sync.Mutex
+	stats map[string]models.ProfileStats
+}
+
+func (c *statsCache) get(id string) (models.ProfileStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats, ok := c.stats[id]
+	if !ok || time.Since(stats.LastTested) > statsCacheTTL {
+		return models.ProfileStats{}, false
+	}
+	return stats, true
+}
+
+func (c *statsCache) set(stats models.ProfileStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats == nil {
+		c.stats = make(map[string]models.ProfileStats)
+	}
+	c.stats[stats.ID] = stats
+}
+
+// invalidate drops the stats of a profile, e.g. after it was saved or deleted.
+func (c *statsCache) invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.stats, id)
+}
+
+// collectProfileStats gathers live stats for all profiles concurrently.
+func (s *Server) collectProfileStats(ctx context.Context, summaries []models.ProfileSummary) []models.ProfileStats {
+	stats := make([]models.ProfileStats, len(summaries))
+
+	var wg sync.WaitGroup
+	for i, summary := range summaries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats[i] = s.profileStats(ctx, summary)
+		}()
+	}
+	wg.Wait()
+
+	return stats
+}
+
+// profileStats returns cached stats or connects to the profile's database to count connections.
+// A profile that does not answer within statsTimeout is reported as unreachable.
+func (s *Server) profileStats(ctx context.Context, summary models.ProfileSummary) models.ProfileStats {
+	if cached, ok := s.stats.get(summary.ID); ok {
+		cached.ProfileSummary = summary
+		return cached
+	}
+
+	stats := models.ProfileStats{ProfileSummary: summary}
+
+	profile := s.loadProfile(summary.ID)
+	if profile == nil {
+		stats.Error = "profile not found"
+		return stats
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, statsTimeout)
+	defer cancel()
+
+	count, err := s.migrator.CountConnections(ctx, profile)
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		stats.Error = fmt.Sprintf("timed out after %s", statsTimeout)
+	case err != nil:
+		stats.Error = err.Error()
+	default:
+		stats.Reachable = true
+		stats.ConnectionCount = count
+	}
+
+	stats.LastTested = time.Now().UTC()
+	s.stats.set(stats)
+	return stats
+}
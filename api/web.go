@@ -2,10 +2,12 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
@@ -32,21 +34,27 @@ func (s *Server) SetupWebRoutes() {
 	s.mux.HandleFunc("GET /profiles", s.handleProfilesPage)
 	s.mux.HandleFunc("GET /export", s.handleExportPage)
 	s.mux.HandleFunc("GET /import", s.handleImportPage)
+	s.mux.HandleFunc("GET /exports", s.handleExportsPage)
 	s.mux.HandleFunc("GET /about", s.handleAboutPage)
+	s.mux.HandleFunc("GET /unlock", s.handleUnlockPage)
+	s.mux.HandleFunc("POST /unlock", s.unlockLimited(s.handleUnlockForm))
 
 	// HTMX endpoints (return HTML fragments)
-	s.mux.HandleFunc("GET /htmx/fernet/generate", s.htmxGenerateFernetKey)
-	s.mux.HandleFunc("POST /htmx/fernet/validate", s.htmxValidateFernet)
+	s.mux.HandleFunc("GET /htmx/fernet/generate", s.limited(s.htmxGenerateFernetKey))
+	s.mux.HandleFunc("POST /htmx/fernet/validate", s.limited(s.htmxValidateFernet))
 	s.mux.HandleFunc("GET /htmx/profiles/list", s.htmxListProfiles)
-	s.mux.HandleFunc("POST /htmx/profiles/save", s.htmxSaveProfile)
+	s.mux.HandleFunc("POST /htmx/profiles/save", s.writable(s.htmxSaveProfile))
+	s.mux.HandleFunc("GET /htmx/profiles/fernet/generate", s.limited(s.htmxGenerateProfileFernetKey))
 	s.mux.HandleFunc("GET /htmx/profiles/{id}", s.htmxGetProfile)
 	s.mux.HandleFunc("POST /htmx/profiles/test", s.htmxTestProfile)
-	s.mux.HandleFunc("DELETE /htmx/profiles/{id}", s.htmxDeleteProfile)
+	s.mux.HandleFunc("DELETE /htmx/profiles/{id}", s.writable(s.htmxDeleteProfile))
 	s.mux.HandleFunc("GET /htmx/connections/list", s.htmxListConnections)
-	s.mux.HandleFunc("POST /htmx/export", s.htmxExport)
-	s.mux.HandleFunc("POST /htmx/import", s.htmxImport)
+	s.mux.HandleFunc("POST /htmx/export", s.writable(s.htmxExport))
+	s.mux.HandleFunc("POST /htmx/import", s.writable(s.htmxImport))
 	s.mux.HandleFunc("POST /htmx/import/preview", s.htmxImportPreview)
+	s.mux.HandleFunc("POST /htmx/import/check-key", s.htmxImportCheckKey)
 	s.mux.HandleFunc("GET /download/{filename}", s.handleDownload)
+	s.mux.HandleFunc("GET /downloads/{token}", s.handleKeptDownload)
 }
 
 func (s *Server) renderPage(w http.ResponseWriter, page string, data any) {
@@ -75,12 +83,12 @@ func (s *Server) handleProfilesPage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleExportPage(w http.ResponseWriter, r *http.Request) {
-	profiles := s.getProfileSummaries()
+	profiles := s.getProfileSummaries("")
 	s.renderPage(w, "export", map[string]any{"Page": "export", "Title": "Export", "Profiles": profiles})
 }
 
 func (s *Server) handleImportPage(w http.ResponseWriter, r *http.Request) {
-	profiles := s.getProfileSummaries()
+	profiles := s.getProfileSummaries("")
 	s.renderPage(w, "import", map[string]any{"Page": "import", "Title": "Import", "Profiles": profiles})
 }
 
@@ -111,24 +119,49 @@ func (s *Server) htmxGenerateFernetKey(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(key))
 }
 
+// htmxGenerateProfileFernetKey puts a new key in the profile form's Fernet field, shown
+// in clear with a reminder to save it, as Ctrl+G does in the TUI.
+func (s *Server) htmxGenerateProfileFernetKey(w http.ResponseWriter, r *http.Request) {
+	key, err := s.migrator.GenerateFernetKey()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.renderPartial(w, "profile-fernet-generated", map[string]any{"Key": key})
+}
+
 func (s *Server) htmxValidateFernet(w http.ResponseWriter, r *http.Request) {
 	key := r.FormValue("key")
+	info, err := services.InspectKey(key)
 	if s.migrator.ValidateFernetKey(key) {
-		s.renderPartial(w, "validate-valid", nil)
+		s.renderPartial(w, "validate-valid", info)
 	} else {
-		s.renderPartial(w, "validate-invalid", nil)
+		s.renderPartial(w, "validate-invalid", map[string]any{"Err": err})
 	}
 }
 
 func (s *Server) htmxListProfiles(w http.ResponseWriter, r *http.Request) {
-	profiles := s.getProfileSummaries()
-	s.renderPartial(w, "profiles-list", map[string]any{"Profiles": profiles})
+	query := r.FormValue("q")
+	profiles := s.getProfileSummaries(query)
+	s.renderPartial(w, "profiles-list", map[string]any{
+		"Profiles":            profiles,
+		"Query":               query,
+		"ConfirmDeleteByName": s.confirmDeleteByName,
+	})
 }
 
 func (s *Server) htmxSaveProfile(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 
-	port, _ := strconv.Atoi(r.FormValue("db_port"))
+	port, err := models.ParsePort(r.FormValue("db_port"))
+	if err != nil {
+		s.htmxFormError(w, err.Error())
+		return
+	}
+	if err := models.ValidateHost(r.FormValue("db_host")); err != nil {
+		s.htmxFormError(w, err.Error())
+		return
+	}
 
 	profile := models.NewProfile(r.FormValue("name"))
 	profile.DBHost = r.FormValue("db_host")
@@ -160,19 +193,28 @@ func (s *Server) htmxSaveProfile(w http.ResponseWriter, r *http.Request) {
 		profile.FernetKey = r.FormValue("fernet_key")
 	}
 
-	// Save secrets
+	// Save secrets and metadata
 	keys := profile.GetSecretKeys()
-	s.secrets.Set(keys.Password, profile.DBPassword)
-	s.secrets.Set(keys.FernetKey, profile.FernetKey)
-
-	// Save metadata
-	metaKey := "profile:" + profile.ID + ":meta"
-	s.secrets.Set(metaKey, profileToJSON(profile))
+	s.secrets.SetBatch(map[string]string{
+		keys.Password:                     profile.DBPassword,
+		keys.FernetKey:                    profile.FernetKey,
+		"profile:" + profile.ID + ":meta": profileToJSON(profile),
+	})
+	s.connections.invalidate(profile.ID)
+	s.stats.invalidate(profile.ID)
 
 	// Return updated list
 	s.htmxListProfiles(w, r)
 }
 
+// htmxFormError shows a validation error inside the profile form instead of
+// replacing the profile list, keeping the modal open.
+func (s *Server) htmxFormError(w http.ResponseWriter, msg string) {
+	w.Header().Set("HX-Retarget", "#form-error")
+	w.Header().Set("HX-Reswap", "innerHTML")
+	s.renderPartial(w, "form-error", msg)
+}
+
 func (s *Server) htmxGetProfile(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	profile := s.loadProfile(id)
@@ -213,10 +255,13 @@ func (s *Server) htmxTestProfile(w http.ResponseWriter, r *http.Request) {
 func (s *Server) htmxDeleteProfile(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
-	// Delete all keys
-	s.secrets.Delete("profile:" + id + ":password")
-	s.secrets.Delete("profile:" + id + ":fernet")
-	s.secrets.Delete("profile:" + id + ":meta")
+	// A mismatched name leaves the profile in the list
+	if s.deleteConfirmed(id, r.Header.Get("HX-Prompt")) {
+		if err := s.deleteProfileSecrets(id); err != nil {
+			http.Error(w, "Failed to delete profile: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
 	s.htmxListProfiles(w, r)
 }
@@ -234,11 +279,27 @@ func (s *Server) htmxListConnections(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A cached list is shown with its age and a way to reload it
+	now := time.Now()
+	if s.connCacheTTL > 0 && r.URL.Query().Get("refresh") == "" {
+		if cached, ok := s.connections.get(profileID, s.connCacheTTL, now); ok {
+			s.renderPartial(w, "connections-list", map[string]any{
+				"Connections": cached.connections,
+				"ProfileID":   profileID,
+				"CachedAge":   now.Sub(cached.loaded).Round(time.Second).String(),
+			})
+			return
+		}
+	}
+
 	connections, err := s.migrator.ListConnections(r.Context(), profile)
 	if err != nil {
 		s.renderPartial(w, "connections-list", map[string]any{"Connections": nil, "Error": err.Error()})
 		return
 	}
+	if s.connCacheTTL > 0 {
+		s.connections.set(profileID, connections, now)
+	}
 
 	s.renderPartial(w, "connections-list", map[string]any{"Connections": connections})
 }
@@ -249,63 +310,159 @@ func (s *Server) htmxExport(w http.ResponseWriter, r *http.Request) {
 	profileID := r.FormValue("profile_id")
 	profile := s.loadProfile(profileID)
 	if profile == nil {
-		s.renderPartial(w, "export-result", &models.ExportResult{Error: "Profile not found"})
+		s.renderPartial(w, "export-result", exportResultView{ExportResult: &models.ExportResult{Error: "Profile not found"}})
 		return
 	}
 
-	// Generate filename with profile name and timestamp
-	timestamp := time.Now().Format("2006-01-02_150405")
-	safeName := strings.ReplaceAll(profile.Name, " ", "_")
-	filename := fmt.Sprintf("airflow_%s_%s.csv", safeName, timestamp)
-	tempPath := filepath.Join(os.TempDir(), filename)
+	// Every export gets its own file, the download name may repeat across exports
+	file, err := os.CreateTemp("", "airflow-export-*.csv")
+	if err != nil {
+		s.renderPartial(w, "export-result", exportResultView{ExportResult: &models.ExportResult{Error: "Failed to create export file: " + err.Error()}})
+		return
+	}
+	file.Close()
+	tempPath := file.Name()
 
 	req := models.ExportRequest{
 		SourceProfile:     profile,
 		OutputPath:        tempPath,
 		FileEncryptionKey: r.FormValue("file_key"),
+		FilePassphrase:    r.FormValue("file_passphrase"),
 		ConnectionIDs:     r.Form["connection_ids"],
 	}
 
 	result, _ := s.migrator.Export(r.Context(), req)
 
-	if result.Success {
-		// Store file info for download
+	if !result.Success {
+		os.Remove(tempPath)
+	} else {
+		// Store file info for download under the name the browser saves it as
+		filename := s.exportFileName(profile, result.ConnectionCount)
 		result.OutputPath = filename
-		result.DownloadURL = "/download/" + filepath.Base(tempPath)
+		result.DownloadURL = "/download/" + filepath.Base(tempPath) + "?name=" + url.QueryEscape(filename)
+
+		entry := exportHistoryEntry{
+			Filename:    filename,
+			ProfileID:   profile.ID,
+			ProfileName: profile.Name,
+			ExportedAt:  time.Now(),
+			Count:       result.ConnectionCount,
+		}
+
+		// A kept file can be downloaded again until it expires, e.g. after a dropped download
+		if r.FormValue("keep_download") != "" {
+			token, err := s.downloads.keep(tempPath, filename)
+			if err != nil {
+				result.Warnings = append(result.Warnings, err.Error()+", the file can be downloaded once")
+			} else {
+				result.DownloadURL = "/downloads/" + token
+				result.DownloadExpires = time.Now().Add(downloadTTL).UTC().Format(time.RFC3339)
+				entry.Token = token
+				entry.Expires = time.Now().Add(downloadTTL)
+			}
+		}
+		s.recordExport(entry)
 	}
 
-	s.renderPartial(w, "export-result", result)
+	s.renderPartial(w, "export-result", newExportResultView(r, result))
+}
+
+// exportFileName names a web export for its download: with the filename template
+// when one is set (checked at startup), airflow_<profile>_<timestamp>.csv otherwise.
+func (s *Server) exportFileName(profile *models.Profile, count int) string {
+	now := time.Now()
+	if s.filenameTemplate != "" {
+		name, err := services.RenderFileName(s.filenameTemplate, services.FileNameData{
+			Profile:   profile.Name,
+			Timestamp: services.FileNameTime{Time: now},
+			Count:     count,
+		})
+		if err == nil {
+			return name
+		}
+	}
+	safeName := strings.ReplaceAll(profile.Name, " ", "_")
+	return fmt.Sprintf("airflow_%s_%s.csv", safeName, now.Format("2006-01-02_150405"))
 }
 
 func (s *Server) htmxImportPreview(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form (max 10MB)
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		http.Error(w, "Failed to parse form", http.StatusBadRequest)
+	if err := s.parseUploadForm(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	fileKey := r.FormValue("file_key")
 	if fileKey == "" {
-		http.Error(w, "Decryption key is required", http.StatusBadRequest)
+		http.Error(w, "Decryption key or passphrase is required", http.StatusBadRequest)
 		return
 	}
 
-	// Get uploaded file
-	file, header, err := r.FormFile("file")
+	tempFile, err := s.saveUpload(r, "airflow-preview-")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer os.Remove(tempFile)
+
+	// Create Fernet to decrypt
+	fernet, err := services.FernetForFile(tempFile, fileKey)
+	if err != nil {
+		http.Error(w, "Invalid decryption key", http.StatusBadRequest)
+		return
+	}
+
+	// Fail fast on a wrong key before decrypting every record
+	if ok, err := services.CanDecryptFile(tempFile, fernet); err == nil && !ok {
+		http.Error(w, "This key does not decrypt this file", http.StatusBadRequest)
+		return
+	}
+
+	// Read and decrypt CSV
+	records, err := services.ReadEncryptedCSV(tempFile, fernet)
+	if err != nil {
+		http.Error(w, "Failed to decrypt file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// With a target profile, mark how each record compares to what it already holds
+	var statuses map[string]models.PreviewStatus
+	if profile := s.loadProfile(r.FormValue("profile_id")); profile != nil {
+		statuses, err = s.migrator.PreviewImport(r.Context(), profile, records, r.FormValue("prefix"))
+		if err != nil {
+			http.Error(w, "Failed to compare with the target: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Render connections list
+	s.renderPartial(w, "import-connections-list", map[string]any{
+		"Records":   records,
+		"Statuses":  statuses,
+		"Collision": r.FormValue("collision"),
+	})
+}
+
+// htmxImportCheckKey tells whether file_key decrypts the first record of the uploaded
+// file. The page only uploads the start of the file, which is enough for one record.
+func (s *Server) htmxImportCheckKey(w http.ResponseWriter, r *http.Request) {
+	if err := s.parseUploadForm(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
 	if err != nil {
 		http.Error(w, "No file uploaded", http.StatusBadRequest)
 		return
 	}
 	defer file.Close()
 
-	// Save to temp file
-	tempFile := filepath.Join(os.TempDir(), "airflow-preview-"+header.Filename)
-	out, err := os.Create(tempFile)
+	out, err := os.CreateTemp("", "airflow-check-*.csv")
 	if err != nil {
 		http.Error(w, "Failed to create temp file", http.StatusInternalServerError)
 		return
 	}
-	defer os.Remove(tempFile)
+	defer os.Remove(out.Name())
 	defer out.Close()
 
 	if _, err := io.Copy(out, file); err != nil {
@@ -314,28 +471,27 @@ func (s *Server) htmxImportPreview(w http.ResponseWriter, r *http.Request) {
 	}
 	out.Close()
 
-	// Create Fernet to decrypt
-	fernet, err := services.NewFernet(fileKey)
+	fernet, err := services.FernetForFile(out.Name(), r.FormValue("file_key"))
 	if err != nil {
-		http.Error(w, "Invalid decryption key", http.StatusBadRequest)
+		s.renderPartial(w, "validate-invalid", nil)
 		return
 	}
 
-	// Read and decrypt CSV
-	records, err := services.ReadEncryptedCSV(tempFile, fernet)
-	if err != nil {
-		http.Error(w, "Failed to decrypt file: "+err.Error(), http.StatusBadRequest)
-		return
+	ok, err := services.CanDecryptFile(out.Name(), fernet)
+	switch {
+	case err != nil:
+		// Unreadable start of file: say nothing, Load Connections reports the details
+		w.WriteHeader(http.StatusOK)
+	case ok:
+		s.renderPartial(w, "file-key-matches", nil)
+	default:
+		s.renderPartial(w, "file-key-mismatch", nil)
 	}
-
-	// Render connections list
-	s.renderPartial(w, "import-connections-list", map[string]any{"Records": records})
 }
 
 func (s *Server) htmxImport(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form (max 10MB)
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		s.renderPartial(w, "import-result", &models.ImportResult{Error: "Failed to parse form: " + err.Error()})
+	if err := s.parseUploadForm(w, r); err != nil {
+		s.renderPartial(w, "import-result", &models.ImportResult{Error: err.Error()})
 		return
 	}
 
@@ -346,44 +502,133 @@ func (s *Server) htmxImport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get uploaded file
-	file, header, err := r.FormFile("file")
-	if err != nil {
-		s.renderPartial(w, "import-result", &models.ImportResult{Error: "No file uploaded"})
+	// Reject a bad strategy before the upload is copied or the database is touched
+	collision := models.CollisionStrategy(r.FormValue("collision"))
+	if !collision.Valid() {
+		s.renderPartial(w, "import-result", &models.ImportResult{
+			Error: fmt.Sprintf("Unknown collision strategy %q, choose stop, skip or overwrite", collision),
+		})
 		return
 	}
-	defer file.Close()
 
-	// Save to temp file
-	tempFile := filepath.Join(os.TempDir(), "airflow-import-"+header.Filename)
-	out, err := os.Create(tempFile)
+	tempFile, err := s.saveUpload(r, "airflow-import-")
 	if err != nil {
-		s.renderPartial(w, "import-result", &models.ImportResult{Error: "Failed to create temp file"})
+		s.renderPartial(w, "import-result", &models.ImportResult{Error: err.Error()})
 		return
 	}
 	defer os.Remove(tempFile)
-	defer out.Close()
 
-	if _, err := io.Copy(out, file); err != nil {
-		s.renderPartial(w, "import-result", &models.ImportResult{Error: "Failed to save file"})
+	// The preview list marks the form, so an empty selection is not taken as "all"
+	connectionIDs := r.Form["connection_ids"]
+	if r.FormValue("connection_selection") != "" && len(connectionIDs) == 0 {
+		s.renderPartial(w, "import-result", &models.ImportResult{Error: "Select at least one connection to import"})
 		return
 	}
-	out.Close()
 
-	collision := models.CollisionStrategy(r.FormValue("collision"))
+	// The preview count guards against importing another file than the one previewed
+	var expectedCount int
+	if v := r.FormValue("expected_count"); v != "" {
+		if expectedCount, err = strconv.Atoi(v); err != nil {
+			s.renderPartial(w, "import-result", &models.ImportResult{Error: "Invalid expected count"})
+			return
+		}
+	}
+
+	// Purging deletes data, so the profile name must be typed to confirm it
+	purge := r.FormValue("purge") == "on"
+	if purge && r.FormValue("purge_confirm") != profile.Name {
+		s.renderPartial(w, "import-result", &models.ImportResult{Error: "Type the target profile name to confirm the purge"})
+		return
+	}
+
+	// The single key field takes the passphrase of a passphrase-protected file
+	fileKey, passphrase := r.FormValue("file_key"), ""
+	if salt, err := services.ReadPassphraseSalt(tempFile); err == nil && salt != nil {
+		fileKey, passphrase = "", fileKey
+	}
 
 	req := models.ImportRequest{
 		TargetProfile:     profile,
 		InputPath:         tempFile,
-		FileDecryptionKey: r.FormValue("file_key"),
+		FileDecryptionKey: fileKey,
+		FilePassphrase:    passphrase,
 		CollisionStrategy: collision,
 		ConnectionPrefix:  r.FormValue("prefix"),
+		ConnectionIDs:     connectionIDs,
+		ExpectedCount:     expectedCount,
+		ContinueOnError:   r.FormValue("continue_on_error") == "on",
+
+		CaseInsensitiveCollision: r.FormValue("case_insensitive") == "on",
+		PurgeBeforeImport:        purge,
+		MergeExtra:               r.FormValue("merge_extra") == "on",
 	}
 
 	result, _ := s.migrator.Import(r.Context(), req)
+	s.connections.invalidate(req.TargetProfile.ID)
 	s.renderPartial(w, "import-result", result)
 }
 
+// uploadFormSlack leaves room for the key and options sent along with the file
+const uploadFormSlack = 1 << 20
+
+// parseUploadForm parses a multipart form whose body may not exceed the upload limit.
+func (s *Server) parseUploadForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxUploadSize+uploadFormSlack)
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return fmt.Errorf("File is larger than the %s upload limit", formatUploadSize(s.maxUploadSize))
+		}
+		return fmt.Errorf("Failed to parse form: %v", err)
+	}
+	return nil
+}
+
+// saveUpload validates the uploaded "file" field and copies it to a new temp file
+// whose name starts with prefix. The caller removes the returned file.
+func (s *Server) saveUpload(r *http.Request, prefix string) (string, error) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return "", errors.New("No file uploaded")
+	}
+	defer file.Close()
+
+	// Only the base name is kept, browsers on Windows may send backslashes
+	name := filepath.Base(strings.ReplaceAll(header.Filename, `\`, "/"))
+	if name == "." || name == "/" || name == ".." {
+		return "", errors.New("Invalid file name")
+	}
+	if !strings.EqualFold(filepath.Ext(name), ".csv") {
+		return "", errors.New("Only .csv files can be imported")
+	}
+	if header.Size == 0 {
+		return "", errors.New("The uploaded file is empty")
+	}
+	if header.Size > s.maxUploadSize {
+		return "", fmt.Errorf("File is larger than the %s upload limit", formatUploadSize(s.maxUploadSize))
+	}
+
+	out, err := os.CreateTemp("", prefix+"*-"+name)
+	if err != nil {
+		return "", errors.New("Failed to create temp file")
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, file); err != nil {
+		os.Remove(out.Name())
+		return "", errors.New("Failed to save file")
+	}
+	return out.Name(), nil
+}
+
+// formatUploadSize renders an upload limit in whole megabytes where possible
+func formatUploadSize(size int64) string {
+	if size%(1<<20) == 0 {
+		return fmt.Sprintf("%dMB", size>>20)
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
+
 func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
 	filename := r.PathValue("filename")
 	if filename == "" {
@@ -408,6 +653,11 @@ func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	// Save it under the export's name rather than the unique temp one
+	if name, err := services.SanitizeFileName(r.URL.Query().Get("name")); err == nil {
+		filename = name
+	}
+
 	// Set headers for download
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
 	w.Header().Set("Content-Type", "text/csv")
@@ -420,14 +670,54 @@ func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
 	os.Remove(tempPath)
 }
 
+// exportResultView adds the ready-to-share snippets to an export result
+type exportResultView struct {
+	*models.ExportResult
+	CopyCommand string // curl command fetching the file
+	ShareNote   string // Download link and key in one message
+}
+
+func newExportResultView(r *http.Request, result *models.ExportResult) exportResultView {
+	view := exportResultView{ExportResult: result}
+	if !result.Success || result.DownloadURL == "" {
+		return view
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	link := scheme + "://" + r.Host + result.DownloadURL
+
+	view.CopyCommand = fmt.Sprintf("curl -fSL -o %s %s", shellQuote(result.OutputPath), shellQuote(link))
+	usage := "single use"
+	if result.DownloadExpires != "" {
+		usage = "until " + result.DownloadExpires
+	}
+	if result.PassphraseProtected {
+		view.ShareNote = fmt.Sprintf("Airflow connections export (%d connections)\nDownload (%s): %s\nImport it from the Import page using the passphrase, sent separately.",
+			result.ConnectionCount, usage, link)
+		return view
+	}
+	view.ShareNote = fmt.Sprintf("Airflow connections export (%d connections)\nDownload (%s): %s\nFile encryption key: %s\nImport it from the Import page using this key.",
+		result.ConnectionCount, usage, link, result.FileEncryptionKey)
+	return view
+}
+
+// shellQuote wraps s in single quotes so it pastes safely into a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // Helpers
-func (s *Server) getProfileSummaries() []models.ProfileSummary {
+// getProfileSummaries lists the profiles sorted by name, keeping those matching query.
+func (s *Server) getProfileSummaries(query string) []models.ProfileSummary {
 	var profiles []models.ProfileSummary
 
 	for _, key := range s.secrets.List() {
 		if len(key) > 8 && key[:8] == "profile:" && len(key) > 5 && key[len(key)-5:] == ":meta" {
 			if metaJSON, err := s.secrets.Get(key); err == nil {
-				if summary := jsonToProfileSummary(metaJSON); summary != nil {
+				if summary := jsonToProfileSummary(metaJSON); summary != nil && summary.Matches(query) {
 					profiles = append(profiles, *summary)
 				}
 			}
@@ -1,16 +1,24 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/flevanti/airflow-migrator/api"
 	"github.com/flevanti/airflow-migrator/internal/app"
+	"github.com/flevanti/airflow-migrator/internal/core/services"
 )
 
 func main() {
+	configDir := app.ConfigDirFlag(flag.CommandLine)
+	allowWeakPassword := app.WeakPasswordFlag(flag.CommandLine)
+	flag.Parse()
+
 	// Initialize app (config, password, secrets)
-	application, err := app.Initialize()
+	application, err := app.Initialize(*configDir, *allowWeakPassword)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -18,6 +26,49 @@ func main() {
 
 	// Start HTTP server
 	server := api.NewServer(application.Migrator, application.Secrets, application.ConfigDir)
+	if os.Getenv("READ_ONLY") == "true" {
+		server.SetReadOnly(true)
+		fmt.Println("Read-only mode: export, import, profile and delete routes are disabled")
+	}
+	if os.Getenv("CONFIRM_DELETE_BY_NAME") == "true" {
+		server.SetConfirmDeleteByName(true)
+	}
+
+	if v := os.Getenv("MAX_UPLOAD_MB"); v != "" {
+		mb, err := strconv.Atoi(v)
+		if err != nil || mb <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: MAX_UPLOAD_MB must be a positive number, got %q\n", v)
+			os.Exit(1)
+		}
+		server.SetMaxUploadSize(int64(mb) << 20)
+	}
+
+	// Check the export filename template up front rather than on the first export
+	if tmpl := os.Getenv("EXPORT_FILENAME_TEMPLATE"); tmpl != "" {
+		if _, err := services.RenderFileName(tmpl, services.FileNameData{Profile: "profile"}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: EXPORT_FILENAME_TEMPLATE: %v\n", err)
+			os.Exit(1)
+		}
+		server.SetFilenameTemplate(tmpl)
+	}
+
+	if v := os.Getenv("CONNECTION_CACHE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl < 0 {
+			fmt.Fprintf(os.Stderr, "Error: CONNECTION_CACHE_TTL must be a duration such as 30s or 0, got %q\n", v)
+			os.Exit(1)
+		}
+		server.SetConnectionCacheTTL(ttl)
+	}
+
+	if v := os.Getenv("LOCK_AFTER"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			fmt.Fprintf(os.Stderr, "Error: LOCK_AFTER must be a duration such as 15m or 0, got %q\n", v)
+			os.Exit(1)
+		}
+		server.SetLockAfter(d)
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
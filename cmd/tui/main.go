@@ -1,17 +1,36 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/flevanti/airflow-migrator/internal/app"
+	"github.com/flevanti/airflow-migrator/internal/core/services"
+	"github.com/flevanti/airflow-migrator/internal/headless"
 	"github.com/flevanti/airflow-migrator/internal/tui"
+	"golang.org/x/term"
 )
 
 func main() {
+	configDir := app.ConfigDirFlag(flag.CommandLine)
+	allowWeakPassword := app.WeakPasswordFlag(flag.CommandLine)
+	ascii := flag.Bool("ascii", false, "draw plain ASCII symbols instead of emoji")
+	profile := flag.String("profile", "", "open the export of this profile, by name or ID")
+	lockAfter := flag.Duration("lock-after", 0, "lock the secrets store after this long without input, e.g. 10m (0 never locks)")
+	flag.Parse()
+
+	// Scripts pipe one JSON command instead of running the TUI, see package headless
+	if os.Getenv("AIRFLOW_MIGRATOR_JSON") == "1" && !term.IsTerminal(int(os.Stdin.Fd())) {
+		if !headless.Serve(*configDir, os.Stdin, os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Initialize app (password prompt happens here, before TUI)
-	application, err := app.Initialize()
+	application, err := app.Initialize(*configDir, *allowWeakPassword)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
@@ -23,6 +42,31 @@ func main() {
 		application.Secrets,
 		application.Migrator,
 	)
+	model.ConfirmDeleteByName = os.Getenv("CONFIRM_DELETE_BY_NAME") == "true"
+	model.LockAfter = max(*lockAfter, 0)
+
+	// Check the export filename template up front rather than on the first export
+	if tmpl := os.Getenv("EXPORT_FILENAME_TEMPLATE"); tmpl != "" {
+		if _, err := services.RenderFileName(tmpl, services.FileNameData{Profile: "profile"}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: EXPORT_FILENAME_TEMPLATE: %v\n", err)
+			os.Exit(1)
+		}
+		model.FilenameTemplate = tmpl
+	}
+
+	// Display settings; a broken settings file falls back to the defaults
+	settings, err := tui.LoadSettings(application.ConfigDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	model.Settings = settings
+	model.NoColor = os.Getenv("NO_COLOR") != ""
+	model.ForceASCII = *ascii || tui.ASCIITerminal()
+	model.ApplySettings()
+
+	if *profile != "" {
+		model.StartExportWith(*profile)
+	}
 
 	// Run the TUI
 	p := tea.NewProgram(
@@ -30,7 +74,9 @@ func main() {
 		tea.WithAltScreen(),
 	)
 
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	application.Secrets.Close()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
 		os.Exit(1)
 	}
@@ -2,6 +2,8 @@ package app
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -27,6 +29,30 @@ const (
 	DevEmail     = "levanti.francesco@gmail.com"
 )
 
+// MinMasterPasswordLength is the shortest master password accepted for a new store
+const MinMasterPasswordLength = 12
+
+// commonPasswords are refused as master passwords whatever their length
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "password123": true, "passw0rd": true, "123456": true,
+	"12345678": true, "123456789": true, "1234567890": true, "123456789012": true, "qwerty": true,
+	"qwerty123": true, "qwertyuiop": true, "letmein": true, "welcome": true, "iloveyou": true,
+	"admin": true, "administrator": true, "changeme": true, "secret": true, "airflow": true,
+	"airflowairflow": true, "airflow123": true, "masterpassword": true,
+}
+
+// CheckMasterPassword returns why password is too weak to protect a new store, or nil.
+// Existing stores are opened whatever their password.
+func CheckMasterPassword(password string) error {
+	if commonPasswords[strings.ToLower(password)] {
+		return fmt.Errorf("master password is a commonly used password")
+	}
+	if n := len([]rune(password)); n < MinMasterPasswordLength {
+		return fmt.Errorf("master password has %d characters, at least %d are needed", n, MinMasterPasswordLength)
+	}
+	return nil
+}
+
 // CurrentYear returns the current year for copyright notices
 func CurrentYear() int {
 	return time.Now().Year()
@@ -39,9 +65,11 @@ type App struct {
 	Migrator  *core.Migrator
 }
 
-// Initialize sets up the application: config dir, password prompt, secrets store
-func Initialize() (*App, error) {
-	configDir := GetConfigDir()
+// Initialize sets up the application: config dir, password prompt, secrets store.
+// A non-empty configDir takes precedence over the env var and the default. A new store
+// needs a password passing CheckMasterPassword unless allowWeakPassword is set.
+func Initialize(configDir string, allowWeakPassword bool) (*App, error) {
+	configDir = ResolveConfigDir(configDir)
 
 	// Ensure config dir exists
 	if err := os.MkdirAll(configDir, 0700); err != nil {
@@ -51,24 +79,77 @@ func Initialize() (*App, error) {
 	fmt.Printf("Config directory: %s\n", configDir)
 
 	// Get master password
-	password, err := getMasterPassword(configDir)
+	password, err := getMasterPassword(configDir, allowWeakPassword)
 	if err != nil {
 		return nil, err
 	}
 
-	// Initialize secret store
+	return open(configDir, password, confirmRecover)
+}
+
+// Open opens the existing secrets store of configDir with password, without printing
+// or prompting, for headless use. Unlike Initialize it does not create a new store.
+func Open(configDir, password string) (*App, error) {
+	configDir = ResolveConfigDir(configDir)
+	if !secrets.Exists(configDir) {
+		return nil, fmt.Errorf("no secrets store in %s, run the TUI or server once to create it", configDir)
+	}
+	return open(configDir, password, nil)
+}
+
+// open initializes the secret store and the migrator. When the store is corrupt and
+// confirm agrees, its readable entries are recovered; a nil confirm never recovers.
+func open(configDir, password string, confirm func() bool) (*App, error) {
 	store, err := secrets.New(configDir, password)
+	if errors.Is(err, secrets.ErrCorruptStore) && confirm != nil && confirm() {
+		var recovered int
+		if recovered, err = store.Recover(); err == nil {
+			fmt.Printf("Recovered %d entries, the corrupt file is kept as credentials.enc.corrupt\n", recovered)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open secrets store: %w", err)
 	}
 
+	// Profiles saved with timestamp IDs by older versions move to UUIDs once
+	migrator := core.New()
+	migrated, err := migrator.MigrateProfileIDs(store)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to migrate profile IDs: %w", err)
+	}
+	for oldID, newID := range migrated {
+		fmt.Fprintf(os.Stderr, "Profile %s now has ID %s, update schedules.json if it names the old one\n", oldID, newID)
+	}
+
 	return &App{
 		ConfigDir: configDir,
 		Secrets:   store,
-		Migrator:  core.New(),
+		Migrator:  migrator,
 	}, nil
 }
 
+// ConfigDirFlag registers -config-dir, with -config as an alias, on fs.
+func ConfigDirFlag(fs *flag.FlagSet) *string {
+	dir := new(string)
+	fs.StringVar(dir, "config-dir", "", "config directory (overrides AIRFLOW_MIGRATOR_CONFIG)")
+	fs.StringVar(dir, "config", "", "alias for -config-dir")
+	return dir
+}
+
+// WeakPasswordFlag registers -allow-weak-password on fs, see Initialize.
+func WeakPasswordFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("allow-weak-password", false, "accept a short or common master password for a new store")
+}
+
+// ResolveConfigDir returns dir when set, otherwise the env var or default from GetConfigDir
+func ResolveConfigDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	return GetConfigDir()
+}
+
 // GetConfigDir returns the configuration directory path
 func GetConfigDir() string {
 	// Check env var first
@@ -84,7 +165,31 @@ func GetConfigDir() string {
 	return filepath.Join(home, ".config", "airflow-migrator")
 }
 
-func getMasterPassword(configDir string) (string, error) {
+// confirmRecover asks whether to salvage a corrupt secrets store.
+func confirmRecover() bool {
+	fmt.Println("The master password is correct but the credentials file is corrupt.")
+	fmt.Print("Recover the readable entries? The corrupt file is kept. [y/N]: ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// checkNewPassword refuses a weak password for a new store, or only warns about it when
+// allowWeak is set.
+func checkNewPassword(password string, allowWeak bool) error {
+	err := CheckMasterPassword(password)
+	if err == nil {
+		return nil
+	}
+	if allowWeak {
+		fmt.Printf("Warning: %v. It protects your database credentials; allowed by -allow-weak-password.\n", err)
+		return nil
+	}
+	return fmt.Errorf("%v. Choose a stronger one, or pass -allow-weak-password to use it anyway", err)
+}
+
+func getMasterPassword(configDir string, allowWeak bool) (string, error) {
 	isNew := !secrets.Exists(configDir)
 
 	if isNew {
@@ -97,21 +202,33 @@ func getMasterPassword(configDir string) (string, error) {
 	// Read password without echo
 	passwordBytes, err := term.ReadPassword(int(syscall.Stdin))
 	fmt.Println() // newline after password
+	defer clear(passwordBytes)
 
 	if err != nil {
 		// Fallback for non-terminal (e.g., piped input)
 		reader := bufio.NewReader(os.Stdin)
 		password, _ := reader.ReadString('\n')
-		return strings.TrimSpace(password), nil
+		password = strings.TrimSpace(password)
+		if isNew {
+			if err := checkNewPassword(password, allowWeak); err != nil {
+				return "", err
+			}
+		}
+		return password, nil
 	}
 
 	password := string(passwordBytes)
 
-	// Confirm on first run
+	// Check and confirm on first run
 	if isNew {
+		if err := checkNewPassword(password, allowWeak); err != nil {
+			return "", err
+		}
+
 		fmt.Print("Confirm master password: ")
 		confirmBytes, err := term.ReadPassword(int(syscall.Stdin))
 		fmt.Println()
+		defer clear(confirmBytes)
 
 		if err != nil {
 			return "", fmt.Errorf("failed to read confirmation")
@@ -1,6 +1,8 @@
 package app
 
 import (
+	"flag"
+	"strings"
 	"testing"
 	"time"
 )
@@ -58,6 +60,30 @@ func TestGetConfigDir(t *testing.T) {
 	}
 }
 
+func TestResolveConfigDir(t *testing.T) {
+	t.Setenv("AIRFLOW_MIGRATOR_CONFIG", "/from/env")
+
+	if got := ResolveConfigDir("/from/flag"); got != "/from/flag" {
+		t.Errorf("ResolveConfigDir(flag) = %q, want /from/flag", got)
+	}
+	if got := ResolveConfigDir(""); got != "/from/env" {
+		t.Errorf("ResolveConfigDir(\"\") = %q, want /from/env", got)
+	}
+}
+
+func TestConfigDirFlag(t *testing.T) {
+	for _, name := range []string{"-config-dir", "-config", "--config-dir"} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		dir := ConfigDirFlag(fs)
+		if err := fs.Parse([]string{name, "/work"}); err != nil {
+			t.Fatalf("Parse(%s) error: %v", name, err)
+		}
+		if *dir != "/work" {
+			t.Errorf("%s: dir = %q, want /work", name, *dir)
+		}
+	}
+}
+
 func TestGitHubURLs(t *testing.T) {
 	// GitHubPR and GitHubIssues should be based on GitHub
 	if len(GitHubPR) <= len(GitHub) {
@@ -67,3 +93,30 @@ func TestGitHubURLs(t *testing.T) {
 		t.Error("GitHubIssues should be longer than GitHub base URL")
 	}
 }
+
+func TestCheckMasterPassword(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  string
+	}{
+		{"", "has 0 characters"},
+		{"x", "has 1 characters"},
+		{"Password123", "commonly used"},
+		{"qwertyuiop", "commonly used"},
+		{"correct horse battery", ""},
+		{"ünïcödé-pass", ""},
+	}
+
+	for _, tt := range tests {
+		err := CheckMasterPassword(tt.password)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("CheckMasterPassword(%q) = %v, want nil", tt.password, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("CheckMasterPassword(%q) = %v, want %q", tt.password, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// BackupManifestFile is the name of the manifest written by ExportAll.
+const BackupManifestFile = "manifest.json"
+
+// backupTestTimeout bounds the reachability check of each profile in ExportAll.
+const backupTestTimeout = 10 * time.Second
+
+// ExportAll exports the connections of every profile into its own encrypted file
+// under dir, each with a generated key, and writes a manifest mapping profile to
+// file and key. Unreachable profiles are skipped and reported in the result.
+func (m *Migrator) ExportAll(ctx context.Context, profiles []*models.Profile, dir string) (*models.ExportAllResult, error) {
+	result := &models.ExportAllResult{
+		Directory:    dir,
+		ManifestPath: filepath.Join(dir, BackupManifestFile),
+		Manifest:     models.BackupManifest{CreatedAt: time.Now().UTC().Format(time.RFC3339)},
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		result.Error = fmt.Sprintf("failed to create backup directory: %v", err)
+		return result, nil
+	}
+
+	skip := func(p *models.Profile, reason string) {
+		result.Skipped = append(result.Skipped, models.BackupFailure{
+			ProfileID:   p.ID,
+			ProfileName: p.Name,
+			Error:       reason,
+		})
+	}
+
+	for _, p := range profiles {
+		testCtx, cancel := context.WithTimeout(ctx, backupTestTimeout)
+		err := m.TestConnection(testCtx, p)
+		cancel()
+		if err != nil {
+			skip(p, fmt.Sprintf("unreachable: %v", err))
+			continue
+		}
+
+		file := backupFileName(p)
+		exported, err := m.Export(ctx, models.ExportRequest{
+			SourceProfile: p,
+			OutputPath:    filepath.Join(dir, file),
+		})
+		if err != nil {
+			skip(p, err.Error())
+			continue
+		}
+		if !exported.Success {
+			skip(p, exported.Error)
+			continue
+		}
+
+		result.Manifest.Profiles = append(result.Manifest.Profiles, models.BackupEntry{
+			ProfileID:         p.ID,
+			ProfileName:       p.Name,
+			File:              file,
+			FileEncryptionKey: exported.FileEncryptionKey,
+			ConnectionCount:   exported.ConnectionCount,
+			Warnings:          exported.Warnings,
+		})
+	}
+
+	// The manifest holds every file key, so it is as sensitive as the secrets store
+	data, err := json.MarshalIndent(result.Manifest, "", "  ")
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to marshal manifest: %v", err)
+		return result, nil
+	}
+	if err := os.WriteFile(result.ManifestPath, data, 0600); err != nil {
+		result.Error = fmt.Sprintf("failed to write manifest: %v", err)
+		return result, nil
+	}
+
+	result.Success = true
+	return result, nil
+}
+
+// backupFileName returns a file name for a profile's backup, made unique by the profile ID.
+func backupFileName(p *models.Profile) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, p.Name)
+
+	id := p.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return fmt.Sprintf("%s-%s.csv", strings.Trim(name, "-"), id)
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/core/services"
+)
+
+func TestBackupFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{"Prod", "0f8fad5b-d9cb-469f-a165-70867728950e", "prod-0f8fad5b.csv"},
+		{"Dev / EU (copy)", "7c9e6679-7425-40de-944b-e07fc1f90ae7", "dev---eu--copy-7c9e6679.csv"},
+		{"stg_1", "abc", "stg_1-abc.csv"},
+	}
+
+	for _, tt := range tests {
+		got := backupFileName(&models.Profile{ID: tt.id, Name: tt.name})
+		if got != tt.want {
+			t.Errorf("backupFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMigrator_ExportAllSkipsUnreachable(t *testing.T) {
+	key, _ := services.GenerateKey()
+	profile := models.NewProfile("offline")
+	profile.DBHost = "127.0.0.1"
+	profile.DBPort = 1 // nothing listens here
+	profile.DBName = "airflow"
+	profile.DBUser = "airflow"
+	profile.DBSSLMode = "disable"
+	profile.FernetKey = key
+
+	dir := filepath.Join(t.TempDir(), "backup")
+	result, err := New().ExportAll(context.Background(), []*models.Profile{profile}, dir)
+	if err != nil || !result.Success {
+		t.Fatalf("ExportAll failed: %v %s", err, result.Error)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].ProfileID != profile.ID {
+		t.Fatalf("unreachable profile should be skipped: %+v", result.Skipped)
+	}
+	if len(result.Manifest.Profiles) != 0 {
+		t.Errorf("manifest should be empty: %+v", result.Manifest.Profiles)
+	}
+
+	data, err := os.ReadFile(result.ManifestPath)
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var manifest models.BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.CreatedAt == "" {
+		t.Errorf("invalid manifest %s: %v", data, err)
+	}
+	if info, _ := os.Stat(result.ManifestPath); info.Mode().Perm() != 0600 {
+		t.Errorf("manifest permissions = %v, want 0600", info.Mode().Perm())
+	}
+}
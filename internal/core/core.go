@@ -3,15 +3,28 @@ package core
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/flevanti/airflow-migrator/internal/core/models"
 	"github.com/flevanti/airflow-migrator/internal/core/services"
 )
 
+// checkpointInterval is how many processed connections go between checkpoint saves.
+const checkpointInterval = 100
+
 // Migrator is the main API for the Airflow Connection Migrator.
 // Both HTTP and TUI frontends use this same interface.
-type Migrator struct{}
+type Migrator struct {
+	schedules scheduleRegistry // Exports started by ScheduleExport
+}
 
 // New creates a new Migrator instance.
 func New() *Migrator {
@@ -23,13 +36,38 @@ func (m *Migrator) Export(ctx context.Context, req models.ExportRequest) (*model
 	result := &models.ExportResult{OutputPath: req.OutputPath}
 
 	// Validate request
-	if err := req.SourceProfile.Validate(); err != nil {
+	if err := req.Validate(); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if req.Delimiter != 0 && !services.ValidDelimiter(req.Delimiter) {
+		result.Error = fmt.Sprintf("invalid delimiter %q", req.Delimiter)
+		return result, nil
+	}
+	if err := services.ValidateFields(req.Fields); err != nil {
 		result.Error = err.Error()
 		return result, nil
 	}
+	if _, err := services.SplitRecords(nil, req.SplitBy); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	// An upload without a local copy goes through a temporary file
+	if req.S3 != nil && req.OutputPath == "" && !req.DryRun {
+		tempDir, err := os.MkdirTemp("", "airflow-migrator-s3-")
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to create temporary directory: %v", err)
+			return result, nil
+		}
+		defer os.RemoveAll(tempDir)
+		req.OutputPath = filepath.Join(tempDir, path.Base(req.S3.Key))
+		// The file is removed on return, so the result must not point at it
+		defer func() { result.OutputPath = "" }()
+	}
 
 	// Connect to source database
-	db, err := services.NewDatabase(req.SourceProfile)
+	db, err := services.NewDatabase(ctx, req.SourceProfile)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to connect to database: %v", err)
 		return result, nil
@@ -43,23 +81,6 @@ func (m *Migrator) Export(ctx context.Context, req models.ExportRequest) (*model
 		return result, nil
 	}
 
-	// Get or generate file encryption key
-	fileKey := req.FileEncryptionKey
-	if fileKey == "" {
-		fileKey, err = services.GenerateKey()
-		if err != nil {
-			result.Error = fmt.Sprintf("failed to generate file key: %v", err)
-			return result, nil
-		}
-	}
-	result.FileEncryptionKey = fileKey
-
-	fileFernet, err := services.NewFernet(fileKey)
-	if err != nil {
-		result.Error = fmt.Sprintf("invalid file encryption key: %v", err)
-		return result, nil
-	}
-
 	// List connections
 	connections, err := db.ListConnections(ctx)
 	if err != nil {
@@ -74,46 +95,233 @@ func (m *Migrator) Export(ctx context.Context, req models.ExportRequest) (*model
 			idSet[id] = true
 		}
 		var filtered []*models.Connection
+		found := make(map[string]bool)
 		for _, conn := range connections {
 			if idSet[conn.ID] {
 				filtered = append(filtered, conn)
+				found[conn.ID] = true
 			}
 		}
 		connections = filtered
+
+		// Requested connections missing from the source would otherwise go unnoticed
+		for _, id := range req.ConnectionIDs {
+			if !found[id] {
+				found[id] = true // Report duplicates once
+				result.NotFoundIDs = append(result.NotFoundIDs, id)
+			}
+		}
+		if n := len(result.NotFoundIDs); n > 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%d of %d requested connections were not found: %s",
+				n, len(idSet), strings.Join(result.NotFoundIDs, ", ")))
+		}
+	}
+
+	if req.MaxConnections > 0 && len(connections) > req.MaxConnections && !req.Force && !req.DryRun {
+		result.Error = fmt.Sprintf("export of %d connections exceeds the limit of %d, set force to export them anyway", len(connections), req.MaxConnections)
+		return result, nil
+	}
+
+	// Connections are read as stored, URIs in the host column are only split on request
+	if req.ExpandHostURIs {
+		for _, conn := range connections {
+			if services.ExpandHostURI(conn) {
+				result.ExpandedIDs = append(result.ExpandedIDs, conn.ID)
+			}
+		}
+		if n := len(result.ExpandedIDs); n > 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%d connections had a URI in the host column, split into their fields", n))
+		}
 	}
 
 	// Process connections: decrypt password/extra with source key based on flags
 	var records []*models.ExportRecord
 	for _, conn := range connections {
-		// Decrypt password only if IsEncrypted flag is true
-		if conn.IsEncrypted && conn.Password != "" {
-			decrypted, err := sourceFernet.DecryptString(conn.Password)
-			if err == nil {
-				conn.Password = decrypted
+		if plaintextPassword(conn) {
+			result.PlaintextPasswordIDs = append(result.PlaintextPasswordIDs, conn.ID)
+		}
+		if err := decryptConnection(conn, sourceFernet); err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+
+		// Store decrypted values - will be encrypted as blob by WriteEncryptedCSV
+		// Flags are preserved in the export record
+		record := conn.ToExportRecord()
+		if req.NormalizeExtraJSON {
+			record.Extra, _ = services.NormalizeExtra(record.Extra)
+		}
+		records = append(records, record)
+	}
+
+	// Imported connections keep the time they were first exported
+	if req.ProvenanceDir != "" {
+		provenance, err := services.LoadProvenance(req.ProvenanceDir)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		for _, r := range records {
+			if exportedAt, ok := provenance.ExportedAt(req.SourceProfile.ID, r.ConnID); ok {
+				r.ExportedAt = exportedAt
 			}
-			// If decryption fails, keep original value
 		}
+	}
 
-		// Decrypt extra only if IsExtraEncrypted flag is true
-		if conn.IsExtraEncrypted && conn.Extra != "" {
-			decrypted, err := sourceFernet.DecryptString(conn.Extra)
-			if err == nil {
-				conn.Extra = decrypted
+	// A mix of plaintext and encrypted passwords usually means a broken key rotation
+	if n := len(result.PlaintextPasswordIDs); n > 0 {
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("%d of %d connections had plaintext passwords", n, len(connections)))
+	}
+
+	// Keep only connections that changed since the last export
+	var state *services.ExportState
+	if req.OnlyChanged {
+		stateDir := req.StateDir
+		if stateDir == "" {
+			stateDir = filepath.Dir(req.OutputPath)
+		}
+		state, err = services.LoadExportState(stateDir)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+
+		var changed []*models.ExportRecord
+		for _, r := range records {
+			if state.Changed(req.SourceProfile, r) {
+				changed = append(changed, r)
 			}
 		}
+		result.UnchangedCount = len(records) - len(changed)
+		records = changed
+	}
 
-		// Store decrypted values - will be encrypted as blob by WriteEncryptedCSV
-		// Flags are preserved in the export record
-		records = append(records, conn.ToExportRecord())
-		result.ExportedIDs = append(result.ExportedIDs, conn.ID)
+	// Order rows by conn_id so files from the same input diff cleanly
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ConnID < records[j].ConnID
+	})
+
+	result.TypeCounts = make(map[string]int)
+	for _, r := range records {
+		result.ExportedIDs = append(result.ExportedIDs, r.ConnID)
+		result.TypeCounts[r.ConnType]++
+	}
+
+	// Name the file from the template now that the count is known
+	if req.FilenameTemplate != "" {
+		name, err := services.RenderFileName(req.FilenameTemplate, services.FileNameData{
+			Profile:   req.SourceProfile.Name,
+			Timestamp: services.FileNameTime{Time: time.Now()},
+			Count:     len(records),
+		})
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		req.OutputPath = filepath.Join(filepath.Dir(req.OutputPath), name)
+		result.OutputPath = req.OutputPath
+	}
+
+	// A dry run stops before any key or file is made
+	if req.DryRun {
+		result.Success = true
+		result.ConnectionCount = len(records)
+		return result, nil
+	}
+
+	// Appending to an existing file reuses its key, or its passphrase salt
+	appendTo := false
+	if req.Append {
+		if info, err := os.Stat(req.OutputPath); err == nil && info.Size() > 0 {
+			appendTo = true
+		}
+	}
+	if appendTo && req.FilePassphrase == "" && req.FileEncryptionKey == "" {
+		result.Error = "appending to an existing file needs its file encryption key or passphrase"
+		return result, nil
+	}
+
+	// Derive the file key from the passphrase, or get or generate a Fernet key
+	var fileKey string
+	var fileFernet *services.Fernet
+	if appendTo && req.FilePassphrase != "" {
+		if fileFernet, err = services.FileFernet(req.OutputPath, "", req.FilePassphrase); err != nil {
+			result.Error = fmt.Sprintf("cannot append with passphrase: %v", err)
+			return result, nil
+		}
+		result.PassphraseProtected = true
+	} else if req.FilePassphrase != "" {
+		salt, err := services.NewPassphraseSalt()
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to generate passphrase salt: %v", err)
+			return result, nil
+		}
+		if fileFernet, err = services.NewFernetFromPassphrase(req.FilePassphrase, salt); err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		result.PassphraseProtected = true
+	} else {
+		fileKey = req.FileEncryptionKey
+		if fileKey == "" {
+			fileKey, err = services.GenerateKey()
+			if err != nil {
+				result.Error = fmt.Sprintf("failed to generate file key: %v", err)
+				return result, nil
+			}
+		}
+		result.FileEncryptionKey = fileKey
+
+		if fileFernet, err = services.NewFernet(fileKey); err != nil {
+			result.Error = fmt.Sprintf("invalid file encryption key: %v", err)
+			return result, nil
+		}
+	}
+
+	// Drop unselected fields from the file only; the export state keeps full records
+	written := records
+	if len(req.Fields) > 0 {
+		written = make([]*models.ExportRecord, len(records))
+		for i, r := range records {
+			if written[i], err = services.ProjectRecord(r, req.Fields); err != nil {
+				result.Error = err.Error()
+				return result, nil
+			}
+		}
 	}
 
 	// Write encrypted CSV (entire connection blob encrypted with file key)
-	if err := services.WriteEncryptedCSV(req.OutputPath, records, fileFernet); err != nil {
-		result.Error = fmt.Sprintf("failed to write CSV: %v", err)
+	if req.Append {
+		if err := services.AppendEncryptedCSV(req.OutputPath, written, fileFernet, req.Delimiter); err != nil {
+			result.Error = fmt.Sprintf("failed to append to CSV: %v", err)
+			return result, nil
+		}
+	} else if !req.Split() {
+		if err := services.WriteEncryptedCSV(req.OutputPath, written, fileFernet, req.Delimiter); err != nil {
+			result.Error = fmt.Sprintf("failed to write CSV: %v", err)
+			return result, nil
+		}
+	} else if result.Files, err = writeSplitExport(req, records, written, fileFernet, fileKey); err != nil {
+		result.Error = err.Error()
 		return result, nil
 	}
 
+	if req.S3 != nil {
+		if result.ObjectURL, err = services.UploadS3(ctx, req.S3, req.OutputPath); err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+	}
+
+	if state != nil {
+		state.Update(req.SourceProfile, records)
+		if err := state.Save(); err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+	}
+
 	result.Success = true
 	result.ConnectionCount = len(records)
 	return result, nil
@@ -124,32 +332,51 @@ func (m *Migrator) Import(ctx context.Context, req models.ImportRequest) (*model
 	result := &models.ImportResult{}
 
 	// Validate request
-	if err := req.TargetProfile.Validate(); err != nil {
+	if err := req.Validate(); err != nil {
 		result.Error = err.Error()
 		return result, nil
 	}
-
-	// Get file Fernet for decryption
-	fileFernet, err := services.NewFernet(req.FileDecryptionKey)
+	isolation, err := services.ParseIsolationLevel(req.IsolationLevel)
 	if err != nil {
-		result.Error = fmt.Sprintf("invalid file decryption key: %v", err)
+		result.Error = err.Error()
 		return result, nil
 	}
+	batchSize := req.BatchSize
+	if batchSize == 0 {
+		batchSize = models.DefaultImportBatchSize
+	}
 
-	// Read and decrypt CSV
-	records, err := services.ReadEncryptedCSV(req.InputPath, fileFernet)
-	if err != nil {
-		result.Error = fmt.Sprintf("failed to read CSV: %v", err)
+	// Read the input file, or take the planned records
+	var records []*models.ExportRecord
+	if req.Records != nil {
+		// The import changes records in place, copies keep the plan as reviewed
+		for _, r := range req.Records {
+			record := *r
+			records = append(records, &record)
+		}
+	} else {
+		var cleanup func()
+		records, cleanup, err = readImportRecords(ctx, &req)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		defer cleanup()
+	}
+
+	// A file that changed since the preview, e.g. a stale re-upload, is not imported
+	if req.ExpectedCount > 0 && len(records) != req.ExpectedCount {
+		result.Error = fmt.Sprintf("the file holds %d connections but %d were previewed, check it is the same file", len(records), req.ExpectedCount)
 		return result, nil
 	}
 
-	if len(records) == 0 {
+	if len(records) == 0 && !req.PurgeBeforeImport {
 		result.Success = true
 		return result, nil
 	}
 
 	// Connect to target database
-	db, err := services.NewDatabase(req.TargetProfile)
+	db, err := services.NewDatabase(ctx, req.TargetProfile)
 	if err != nil {
 		result.Error = fmt.Sprintf("failed to connect to database: %v", err)
 		return result, nil
@@ -178,6 +405,123 @@ func (m *Migrator) Import(ctx context.Context, req models.ImportRequest) (*model
 		records = filtered
 	}
 
+	// Rewrite account-specific extra values before they are re-encrypted
+	if len(req.ExtraRewrite) > 0 {
+		for _, r := range records {
+			r.Extra, _ = services.RewriteExtra(r.Extra, req.ExtraRewrite)
+		}
+	}
+	if req.NormalizeExtraJSON {
+		for _, r := range records {
+			r.Extra, _ = services.NormalizeExtra(r.Extra)
+		}
+	}
+
+	// Fill in missing connection types, leaving out those that cannot be guessed
+	if req.InferConnType {
+		var typed []*models.ExportRecord
+		for _, r := range records {
+			if r.ConnType == "" {
+				r.ConnType = services.InferConnType(r.Host, r.Port, r.Extra)
+			}
+			if r.ConnType == "" {
+				result.InvalidIDs = append(result.InvalidIDs, r.ConnID)
+				continue
+			}
+			typed = append(typed, r)
+		}
+		records = typed
+	}
+
+	// Loaded before anything is written, so a damaged file stops the import early
+	var provenance *services.Provenance
+	if req.ProvenanceDir != "" {
+		if provenance, err = services.LoadProvenance(req.ProvenanceDir); err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+	}
+
+	// Imports outside a transaction keep a checkpoint of processed connections.
+	// A purge is all or nothing, so there is nothing to resume, and planned records
+	// have no file to key the checkpoint by.
+	var checkpoint *services.ImportCheckpoint
+	if !req.PurgeBeforeImport && req.Records == nil {
+		checkpointDir := req.CheckpointDir
+		if checkpointDir == "" {
+			checkpointDir = filepath.Dir(req.InputPath)
+		}
+		key, err := services.ImportCheckpointKey(req.InputPath, req.TargetProfile.ID, req.ConnectionPrefix)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+
+		checkpoint = services.NewImportCheckpoint(checkpointDir, key)
+		if req.Resume {
+			if checkpoint, err = services.LoadImportCheckpoint(checkpointDir, key); err != nil {
+				result.Error = err.Error()
+				return result, nil
+			}
+			var remaining []*models.ExportRecord
+			for _, r := range records {
+				if !checkpoint.Done(r.ConnID) {
+					remaining = append(remaining, r)
+				}
+			}
+			result.ResumedCount = len(records) - len(remaining)
+			records = remaining
+		}
+	}
+
+	// processed marks a connection as done in the checkpoint, saving it periodically.
+	processed := func(connID string) {
+		if checkpoint == nil {
+			return
+		}
+		checkpoint.Mark(connID)
+		if len(checkpoint.Processed)%checkpointInterval == 0 {
+			checkpoint.Save()
+		}
+	}
+
+	// Purging runs in the same transaction as the import so the target is never left empty
+	if req.PurgeBeforeImport {
+		tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		defer tx.Rollback()
+		db = tx
+
+		purged, err := db.DeleteAllConnections(ctx, req.ConnectionPrefix)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		result.PurgedCount = int(purged)
+	}
+
+	// failed handles a per-connection error and reports whether the import must stop.
+	// A failed statement aborts a transaction, so ContinueOnError only applies outside one.
+	failed := func(action, connID string, err error) bool {
+		if req.ContinueOnError && !db.InTx() {
+			result.Failures = append(result.Failures, models.ImportFailure{ConnID: connID, Error: err.Error()})
+			return false
+		}
+		if checkpoint != nil {
+			checkpoint.Save()
+		}
+		if db.InTx() {
+			*result = models.ImportResult{}
+			result.Error = fmt.Sprintf("failed to %s %s: %v (rolled back, no changes applied)", action, connID, err)
+		} else {
+			result.Error = fmt.Sprintf("failed to %s %s: %v", action, connID, err)
+		}
+		return true
+	}
+
 	// Build list of IDs to check
 	var idsToCheck []string
 	for _, r := range records {
@@ -188,23 +532,120 @@ func (m *Migrator) Import(ctx context.Context, req models.ImportRequest) (*model
 		idsToCheck = append(idsToCheck, connID)
 	}
 
-	// Check for existing connections
-	existingIDs, err := db.GetExistingConnectionIDs(ctx, idsToCheck)
-	if err != nil {
-		result.Error = fmt.Sprintf("failed to check existing connections: %v", err)
-		return result, nil
+	// Check for existing connections. Overwrite upserts every connection, so it only
+	// needs them to tell exact matches from case variants
+	var existingIDs []string
+	if req.CollisionStrategy != models.CollisionOverwrite || req.CaseInsensitiveCollision {
+		existingIDs, err = db.GetExistingConnectionIDs(ctx, idsToCheck)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to check existing connections: %v", err)
+			return result, nil
+		}
 	}
 	existingSet := make(map[string]bool)
 	for _, id := range existingIDs {
 		existingSet[id] = true
 	}
 
+	// Find conn_ids that only differ in case from existing ones
+	caseVariants := make(map[string]string) // imported ID -> existing ID
+	if req.CaseInsensitiveCollision {
+		matches, err := db.GetCaseInsensitiveMatches(ctx, idsToCheck)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to check existing connections: %v", err)
+			return result, nil
+		}
+		for _, id := range idsToCheck {
+			if existingSet[id] {
+				continue
+			}
+			if existingID, ok := matches[strings.ToLower(id)]; ok {
+				caseVariants[id] = existingID
+				result.CaseCollisions = append(result.CaseCollisions, models.CaseCollision{ConnID: id, ExistingID: existingID})
+			}
+		}
+	}
+
 	// Handle collision strategy
-	if req.CollisionStrategy == models.CollisionStop && len(existingIDs) > 0 {
-		result.Error = fmt.Sprintf("connections already exist: %v", existingIDs)
+	if req.CollisionStrategy == models.CollisionStop && (len(existingIDs) > 0 || len(caseVariants) > 0) {
+		if len(caseVariants) > 0 {
+			var variants []string
+			for _, c := range result.CaseCollisions {
+				variants = append(variants, fmt.Sprintf("%s (exists as %s)", c.ConnID, c.ExistingID))
+			}
+			result.Error = fmt.Sprintf("connections already exist: %v, case variants: %v", existingIDs, variants)
+		} else {
+			result.Error = fmt.Sprintf("connections already exist: %v", existingIDs)
+		}
 		return result, nil
 	}
 
+	// insertNew inserts a connection that did not exist at the check, one row at a time,
+	// and reports whether the import must stop.
+	insertNew := func(record *models.ExportRecord, conn *models.Connection) bool {
+		if req.CollisionStrategy == models.CollisionSkip {
+			inserted, err := db.InsertConnectionIfAbsent(ctx, conn)
+			if err != nil {
+				return failed("insert", conn.ID, err)
+			}
+			if !inserted {
+				result.SkippedIDs = append(result.SkippedIDs, conn.ID)
+				result.SkippedCount++
+				processed(record.ConnID)
+				return false
+			}
+		} else if err := db.InsertConnection(ctx, conn); err != nil {
+			return failed("insert", conn.ID, err)
+		}
+		result.ImportedIDs = append(result.ImportedIDs, conn.ID)
+		result.ImportedCount++
+		processed(record.ConnID)
+		return false
+	}
+
+	// New connections are queued and inserted batchSize at a time. A failed batch is
+	// retried row by row so the failing connection is reported as before
+	type pendingInsert struct {
+		record *models.ExportRecord
+		conn   *models.Connection
+	}
+	var pending []pendingInsert
+	flush := func() bool {
+		if len(pending) == 0 {
+			return false
+		}
+		batch := pending
+		pending = nil
+
+		conns := make([]*models.Connection, len(batch))
+		for i, p := range batch {
+			conns[i] = p.conn
+		}
+		inserted, err := db.InsertConnectionBatch(ctx, conns, req.CollisionStrategy == models.CollisionSkip)
+		if err != nil {
+			for _, p := range batch {
+				if insertNew(p.record, p.conn) {
+					return true
+				}
+			}
+			return false
+		}
+
+		for _, p := range batch {
+			// A conn_id repeated in the file is only inserted once
+			if inserted[p.conn.ID] {
+				delete(inserted, p.conn.ID)
+				result.ImportedIDs = append(result.ImportedIDs, p.conn.ID)
+				result.ImportedCount++
+			} else {
+				result.SkippedIDs = append(result.SkippedIDs, p.conn.ID)
+				result.SkippedCount++
+			}
+			processed(p.record.ConnID)
+		}
+		return false
+	}
+
 	// Process and import
 	for _, record := range records {
 		conn := record.ToConnection()
@@ -214,17 +655,37 @@ func (m *Migrator) Import(ctx context.Context, req models.ImportRequest) (*model
 			conn.ID = req.ConnectionPrefix + conn.ID
 		}
 
-		// Check if exists
-		exists := existingSet[conn.ID]
+		// Check if exists, either exactly or as a case variant
+		variantID, isVariant := caseVariants[conn.ID]
+		exists := existingSet[conn.ID] || isVariant
 
 		if exists {
 			switch req.CollisionStrategy {
 			case models.CollisionSkip:
 				result.SkippedIDs = append(result.SkippedIDs, conn.ID)
 				result.SkippedCount++
+				processed(record.ConnID)
+				continue
+			case models.CollisionOverwrite, models.CollisionStop:
+				// Will update below, stop has already returned when anything exists
+			default:
+				// Validate rejects other strategies, this guards against falling through to an insert
+				result.Error = fmt.Sprintf("unknown collision strategy %q", req.CollisionStrategy)
+				return result, nil
+			}
+		}
+
+		// Keep the target-only keys of an overwritten extra
+		if req.MergeExtra {
+			existingID := conn.ID
+			if isVariant {
+				existingID = variantID
+			}
+			if err := mergeTargetExtra(ctx, db, conn, existingID, targetFernet); err != nil {
+				if failed("merge extra of", conn.ID, err) {
+					return result, nil
+				}
 				continue
-			case models.CollisionOverwrite:
-				// Will update below
 			}
 		}
 
@@ -238,21 +699,101 @@ func (m *Migrator) Import(ctx context.Context, req models.ImportRequest) (*model
 			conn.Extra = encrypted
 		}
 
-		// Insert or update
-		if exists {
-			if err := db.UpdateConnection(ctx, conn); err != nil {
-				result.Error = fmt.Sprintf("failed to update %s: %v", conn.ID, err)
-				return result, nil
+		// A case variant is replaced by the imported casing in one statement, so a
+		// failure keeps the existing connection
+		if isVariant {
+			if err := db.ReplaceConnection(ctx, variantID, conn); err != nil {
+				if failed("replace", variantID, err) {
+					return result, nil
+				}
+				continue
 			}
 			result.OverwrittenIDs = append(result.OverwrittenIDs, conn.ID)
 			result.OverwrittenCount++
-		} else {
-			if err := db.InsertConnection(ctx, conn); err != nil {
-				result.Error = fmt.Sprintf("failed to insert %s: %v", conn.ID, err)
+			processed(record.ConnID)
+			continue
+		}
+
+		// Update, or queue the insert of a new connection. Overwrite and skip resolve
+		// conflicts in the statement itself, so a connection created after the
+		// existence check does not fail the import
+		inserted := true
+		switch {
+		case req.CollisionStrategy == models.CollisionOverwrite:
+			inserted, err = db.UpsertConnection(ctx, conn)
+			if err != nil {
+				if failed("upsert", conn.ID, err) {
+					return result, nil
+				}
+				continue
+			}
+		case exists:
+			if err := db.UpdateConnection(ctx, conn); err != nil {
+				if failed("update", conn.ID, err) {
+					return result, nil
+				}
+				continue
+			}
+			inserted = false
+		case batchSize > 1:
+			pending = append(pending, pendingInsert{record: record, conn: conn})
+			if len(pending) >= batchSize && flush() {
+				return result, nil
+			}
+			continue
+		default:
+			if insertNew(record, conn) {
 				return result, nil
 			}
+			continue
+		}
+
+		if inserted {
 			result.ImportedIDs = append(result.ImportedIDs, conn.ID)
 			result.ImportedCount++
+		} else {
+			result.OverwrittenIDs = append(result.OverwrittenIDs, conn.ID)
+			result.OverwrittenCount++
+		}
+		processed(record.ConnID)
+	}
+
+	if flush() {
+		return result, nil
+	}
+
+	if len(result.Failures) > 0 {
+		if checkpoint != nil {
+			checkpoint.Save()
+		}
+		result.Error = fmt.Sprintf("%d of %d connections failed to import", len(result.Failures), len(records))
+		return result, nil
+	}
+
+	if db.InTx() {
+		if err := db.Commit(); err != nil {
+			*result = models.ImportResult{Error: err.Error()}
+			return result, nil
+		}
+	}
+
+	if checkpoint != nil {
+		checkpoint.Remove()
+	}
+
+	if provenance != nil {
+		exportedAt := make(map[string]string, len(records))
+		for _, r := range records {
+			exportedAt[req.ConnectionPrefix+r.ConnID] = r.ExportedAt
+		}
+		for _, ids := range [][]string{result.ImportedIDs, result.OverwrittenIDs} {
+			for _, id := range ids {
+				provenance.Record(req.TargetProfile.ID, id, exportedAt[id])
+			}
+		}
+		if err := provenance.Save(); err != nil {
+			result.Error = err.Error()
+			return result, nil
 		}
 	}
 
@@ -260,20 +801,486 @@ func (m *Migrator) Import(ctx context.Context, req models.ImportRequest) (*model
 	return result, nil
 }
 
+// decryptConnection decrypts the password and extra of a connection read from Airflow.
+// Only fields flagged as encrypted in the DB are decrypted; a flagged field that does
+// not decrypt with the source key is an error rather than being passed through as-is.
+func decryptConnection(conn *models.Connection, fernet *services.Fernet) error {
+	if conn.IsEncrypted && conn.Password != "" {
+		decrypted, err := fernet.DecryptString(conn.Password)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt password of %s: %w", conn.ID, err)
+		}
+		conn.Password = decrypted
+	}
+
+	if conn.IsExtraEncrypted && conn.Extra != "" {
+		decrypted, err := fernet.DecryptString(conn.Extra)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt extra of %s: %w", conn.ID, err)
+		}
+		conn.Extra = decrypted
+	}
+
+	return nil
+}
+
+// mergeTargetExtra merges the plaintext extra of conn into the extra of the existing
+// connection existingID, if there is one. An empty extra of conn takes the existing
+// extra along with its encryption flag.
+func mergeTargetExtra(ctx context.Context, db *services.Database, conn *models.Connection, existingID string, targetFernet *services.Fernet) error {
+	existing, err := db.GetConnection(ctx, existingID)
+	if err != nil || existing == nil {
+		return err
+	}
+
+	extra := existing.Extra
+	if existing.IsExtraEncrypted && extra != "" {
+		if extra, err = targetFernet.DecryptString(extra); err != nil {
+			return fmt.Errorf("existing extra does not decrypt with the target Fernet key")
+		}
+	}
+
+	if merged, ok := services.MergeExtra(extra, conn.Extra); ok {
+		if conn.Extra == "" {
+			conn.IsExtraEncrypted = existing.IsExtraEncrypted
+		}
+		conn.Extra = merged
+	}
+	return nil
+}
+
+// writeSplitExport writes one encrypted file per group of records, sharing fileFernet
+// (made from fileKey, or from a passphrase when fileKey is empty) unless req.KeyPerFile
+// asks for a generated key per file. Groups are taken from the full records and written
+// as their projections in written, which has the same order.
+func writeSplitExport(req models.ExportRequest, records, written []*models.ExportRecord, fileFernet *services.Fernet, fileKey string) ([]models.ExportFile, error) {
+	groups, err := services.SplitRecords(records, req.SplitBy)
+	if err != nil {
+		return nil, err
+	}
+	projected := make(map[*models.ExportRecord]*models.ExportRecord, len(records))
+	for i, r := range records {
+		projected[r] = written[i]
+	}
+
+	files := make([]models.ExportFile, 0, len(groups))
+	for _, g := range groups {
+		key, fernet := fileKey, fileFernet
+		if req.KeyPerFile && req.FileEncryptionKey == "" && req.FilePassphrase == "" {
+			if key, err = services.GenerateKey(); err != nil {
+				return nil, fmt.Errorf("failed to generate file key: %v", err)
+			}
+			if fernet, err = services.NewFernet(key); err != nil {
+				return nil, fmt.Errorf("invalid file encryption key: %v", err)
+			}
+		}
+
+		rows := make([]*models.ExportRecord, len(g.Records))
+		for i, r := range g.Records {
+			rows[i] = projected[r]
+		}
+
+		path := services.SplitPath(req.OutputPath, g.Name)
+		if err := services.WriteEncryptedCSV(path, rows, fernet, req.Delimiter); err != nil {
+			return nil, fmt.Errorf("failed to write CSV: %v", err)
+		}
+		files = append(files, models.ExportFile{Group: g.Name, Path: path, Count: len(g.Records), FileEncryptionKey: key})
+	}
+	return files, nil
+}
+
+// readImportRecords reads the connections of the input file of req. An S3 input is
+// downloaded first and req is pointed at the local copy, which cleanup removes.
+func readImportRecords(ctx context.Context, req *models.ImportRequest) (records []*models.ExportRecord, cleanup func(), err error) {
+	cleanup = func() {}
+	if source, _ := req.S3Source(); source != nil {
+		tempDir, err := os.MkdirTemp("", "airflow-migrator-s3-")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create temporary directory: %v", err)
+		}
+		cleanup = func() { os.RemoveAll(tempDir) }
+
+		req.InputPath = filepath.Join(tempDir, path.Base(source.Key))
+		if err := services.DownloadS3(ctx, source, req.InputPath); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		// Checkpoints are keyed by content, so a stable directory lets a rerun resume
+		if req.CheckpointDir == "" {
+			req.CheckpointDir = os.TempDir()
+		}
+	}
+
+	switch req.SourceFormat {
+	case "", models.SourceFormatEncrypted:
+		fileFernet, err := services.FileFernet(req.InputPath, req.FileDecryptionKey, req.FilePassphrase)
+		if err != nil {
+			cleanup()
+			if req.FilePassphrase != "" {
+				return nil, nil, fmt.Errorf("cannot use passphrase: %v", err)
+			}
+			return nil, nil, fmt.Errorf("invalid file decryption key: %v", err)
+		}
+		records, err = services.ReadEncryptedCSV(req.InputPath, fileFernet)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to read CSV: %v", err)
+		}
+	case models.SourceFormatMappedCSV:
+		records, err = services.ReadMappedCSV(req.InputPath, req.ColumnMapping)
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to read CSV: %v", err)
+		}
+	default:
+		cleanup()
+		return nil, nil, fmt.Errorf("unknown source format: %s", req.SourceFormat)
+	}
+	return records, cleanup, nil
+}
+
+// PlanImport reads the input file of req and returns what the import would do with
+// each connection, without writing anything. Its Records can be set as the Records of
+// the same request to apply exactly the planned connections later.
+func (m *Migrator) PlanImport(ctx context.Context, req models.ImportRequest) (*models.ImportPlan, error) {
+	plan := &models.ImportPlan{PurgeFirst: req.PurgeBeforeImport}
+
+	if err := req.Validate(); err != nil {
+		plan.Error = err.Error()
+		return plan, nil
+	}
+
+	records := req.Records
+	if records == nil {
+		var cleanup func()
+		var err error
+		if records, cleanup, err = readImportRecords(ctx, &req); err != nil {
+			plan.Error = err.Error()
+			return plan, nil
+		}
+		defer cleanup()
+	}
+	plan.Records = records
+	plan.Total = len(records)
+
+	// The plan lists the connections the import would process, in file order
+	planned := records
+	if len(req.ConnectionIDs) > 0 {
+		planned = nil
+		for _, r := range records {
+			if slices.Contains(req.ConnectionIDs, r.ConnID) {
+				planned = append(planned, r)
+			}
+		}
+	}
+
+	statuses := make(map[string]models.PreviewStatus)
+	if !req.PurgeBeforeImport && len(planned) > 0 {
+		var err error
+		if statuses, err = m.PreviewImport(ctx, req.TargetProfile, planned, req.ConnectionPrefix); err != nil {
+			plan.Error = err.Error()
+			return plan, nil
+		}
+	}
+
+	plan.Connections = make([]models.ImportPlanEntry, 0, len(planned))
+	for _, r := range planned {
+		status := statuses[r.ConnID]
+		if status == "" {
+			// Everything is new after a purge
+			status = models.PreviewNew
+		}
+		plan.Connections = append(plan.Connections, models.ImportPlanEntry{
+			ConnID: req.ConnectionPrefix + r.ConnID,
+			Status: status,
+			Action: planAction(status, req.CollisionStrategy),
+		})
+	}
+
+	plan.Success = true
+	return plan, nil
+}
+
+// planAction returns what an import with strategy does with a connection of status.
+func planAction(status models.PreviewStatus, strategy models.CollisionStrategy) string {
+	if status == models.PreviewNew {
+		return models.PlanInsert
+	}
+	switch strategy {
+	case models.CollisionOverwrite:
+		return models.PlanOverwrite
+	case models.CollisionStop:
+		return models.PlanConflict
+	default:
+		return models.PlanSkip
+	}
+}
+
+// PreviewImport compares records with the target database as an import with prefix
+// would, and returns the status of each record keyed by its conn_id in the file.
+// Existing connections are decrypted with the profile's Fernet key for a field-level
+// compare; those that do not decrypt count as different.
+func (m *Migrator) PreviewImport(ctx context.Context, profile *models.Profile, records []*models.ExportRecord, prefix string) (map[string]models.PreviewStatus, error) {
+	db, err := services.NewDatabase(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, prefix+r.ConnID)
+	}
+	existingIDs, err := db.GetExistingConnectionIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing connections: %w", err)
+	}
+	existingSet := make(map[string]bool)
+	for _, id := range existingIDs {
+		existingSet[id] = true
+	}
+
+	fernet, _ := services.NewFernet(profile.FernetKey)
+	statuses := make(map[string]models.PreviewStatus, len(records))
+	for _, r := range records {
+		connID := prefix + r.ConnID
+		if !existingSet[connID] {
+			statuses[r.ConnID] = models.PreviewNew
+			continue
+		}
+
+		statuses[r.ConnID] = models.PreviewExists
+		existing, err := db.GetConnection(ctx, connID)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil || fernet == nil || decryptConnection(existing, fernet) != nil {
+			continue
+		}
+
+		imported := *r
+		imported.ConnID = connID
+		key := services.StateKey(profile)
+		if services.RecordHash(key, existing.ToExportRecord()) == services.RecordHash(key, &imported) {
+			statuses[r.ConnID] = models.PreviewIdentical
+		}
+	}
+
+	return statuses, nil
+}
+
+// plaintextPassword reports whether conn has a password stored without encryption.
+func plaintextPassword(conn *models.Connection) bool {
+	return conn.Password != "" && !conn.IsEncrypted
+}
+
+// Reencrypt rewrites an export file opened with req.InputKey or req.InputPassphrase
+// as a new file encrypted with req.OutputKey or req.OutputPassphrase, without touching
+// any database. When neither output secret is set a key is generated.
+func (m *Migrator) Reencrypt(ctx context.Context, req models.ReencryptRequest) (*models.ReencryptResult, error) {
+	result := &models.ReencryptResult{OutputPath: req.OutputPath}
+
+	if req.InputPath == "" || req.OutputPath == "" {
+		result.Error = "input and output paths are required"
+		return result, nil
+	}
+	if req.OutputKey != "" && req.OutputPassphrase != "" {
+		result.Error = "use either an output key or an output passphrase, not both"
+		return result, nil
+	}
+	if same, _ := sameFile(req.InputPath, req.OutputPath); same {
+		result.Error = "output path must differ from the input path"
+		return result, nil
+	}
+
+	inputFernet, err := services.FileFernet(req.InputPath, req.InputKey, req.InputPassphrase)
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid file decryption key: %v", err)
+		return result, nil
+	}
+
+	var outputFernet *services.Fernet
+	if req.OutputPassphrase != "" {
+		salt, err := services.NewPassphraseSalt()
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to generate passphrase salt: %v", err)
+			return result, nil
+		}
+		if outputFernet, err = services.NewFernetFromPassphrase(req.OutputPassphrase, salt); err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		result.PassphraseProtected = true
+	} else {
+		outputKey := req.OutputKey
+		if outputKey == "" {
+			outputKey, err = services.GenerateKey()
+			if err != nil {
+				result.Error = fmt.Sprintf("failed to generate file key: %v", err)
+				return result, nil
+			}
+		}
+		result.FileEncryptionKey = outputKey
+
+		if outputFernet, err = services.NewFernet(outputKey); err != nil {
+			result.Error = fmt.Sprintf("invalid file encryption key: %v", err)
+			return result, nil
+		}
+	}
+
+	records, err := services.ReadEncryptedCSV(req.InputPath, inputFernet)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read CSV: %v", err)
+		return result, nil
+	}
+
+	if err := services.WriteEncryptedCSV(req.OutputPath, records, outputFernet, 0); err != nil {
+		result.Error = fmt.Sprintf("failed to write CSV: %v", err)
+		return result, nil
+	}
+
+	result.Success = true
+	result.ConnectionCount = len(records)
+	return result, nil
+}
+
+// sameFile reports whether two paths refer to the same existing file.
+func sameFile(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(infoA, infoB), nil
+}
+
 // ListConnections lists all connections from an Airflow database.
 func (m *Migrator) ListConnections(ctx context.Context, profile *models.Profile) ([]*models.Connection, error) {
-	db, err := services.NewDatabase(profile)
+	return m.ListConnectionsFiltered(ctx, profile, models.ConnectionFilter{})
+}
+
+// ListConnectionsFiltered lists the connections matching filter with their lint findings.
+func (m *Migrator) ListConnectionsFiltered(ctx context.Context, profile *models.Profile, filter models.ConnectionFilter) ([]*models.Connection, error) {
+	db, err := services.NewDatabase(ctx, profile)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
 
-	return db.ListConnections(ctx)
+	connections, err := db.ListConnectionsFiltered(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	fernet, _ := services.NewFernet(profile.FernetKey)
+	for _, conn := range connections {
+		conn.Lints = lintConnection(conn, fernet)
+	}
+
+	return connections, nil
+}
+
+// ListConnectionMeta lists the identifying fields of all connections, without
+// reading passwords or extras. Selection screens use it instead of ListConnections.
+func (m *Migrator) ListConnectionMeta(ctx context.Context, profile *models.Profile) ([]models.ConnectionMeta, error) {
+	db, err := services.NewDatabase(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return db.ListConnectionMeta(ctx)
+}
+
+// GetConnection fetches a single connection with its lint findings.
+// It returns nil if the connection does not exist.
+func (m *Migrator) GetConnection(ctx context.Context, profile *models.Profile, connID string) (*models.Connection, error) {
+	db, err := services.NewDatabase(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	conn, err := db.GetConnection(ctx, connID)
+	if err != nil || conn == nil {
+		return nil, err
+	}
+
+	fernet, _ := services.NewFernet(profile.FernetKey)
+	conn.Lints = lintConnection(conn, fernet)
+	return conn, nil
+}
+
+// lintConnection lints a decrypted copy of conn, leaving the encrypted values untouched.
+func lintConnection(conn *models.Connection, fernet *services.Fernet) []string {
+	var lints []string
+
+	clone := conn.Clone()
+	if fernet == nil || decryptConnection(clone, fernet) != nil {
+		lints = append(lints, "password or extra does not decrypt with the profile's Fernet key")
+		if clone.IsExtraEncrypted {
+			clone.Extra = ""
+		}
+	}
+
+	return append(lints, services.LintConnection(clone)...)
+}
+
+// DeleteConnectionsByPrefix deletes all connections whose ID starts with prefix.
+// confirm must be true and the prefix non-empty; the delete is a single statement
+// so either every matching connection is removed or none is.
+func (m *Migrator) DeleteConnectionsByPrefix(ctx context.Context, profile *models.Profile, prefix string, confirm bool) (*models.DeleteConnectionsResult, error) {
+	result := &models.DeleteConnectionsResult{}
+
+	if prefix == "" {
+		result.Error = "prefix is required"
+		return result, nil
+	}
+	if !confirm {
+		result.Error = "confirmation is required to delete connections"
+		return result, nil
+	}
+	if err := profile.Validate(); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	db, err := services.NewDatabase(ctx, profile)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to connect to database: %v", err)
+		return result, nil
+	}
+	defer db.Close()
+
+	deleted, err := db.DeleteConnectionsByPrefix(ctx, prefix)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	result.Success = true
+	result.DeletedIDs = deleted
+	result.DeletedCount = len(deleted)
+	return result, nil
+}
+
+// CountConnections returns the number of connections in an Airflow database.
+func (m *Migrator) CountConnections(ctx context.Context, profile *models.Profile) (int, error) {
+	db, err := services.NewDatabase(ctx, profile)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	return db.CountConnections(ctx)
 }
 
 // TestConnection tests the database connection.
 func (m *Migrator) TestConnection(ctx context.Context, profile *models.Profile) error {
-	db, err := services.NewDatabase(profile)
+	db, err := services.NewDatabase(ctx, profile)
 	if err != nil {
 		return err
 	}
@@ -1,7 +1,15 @@
 package core
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/core/services"
 )
 
 func TestMigrator_GenerateFernetKey(t *testing.T) {
@@ -58,3 +66,301 @@ func TestMigrator_New(t *testing.T) {
 		t.Error("New() should return non-nil migrator")
 	}
 }
+
+func TestDecryptConnection(t *testing.T) {
+	key, _ := services.GenerateKey()
+	fernet, _ := services.NewFernet(key)
+	otherKey, _ := services.GenerateKey()
+	otherFernet, _ := services.NewFernet(otherKey)
+
+	encPassword, _ := fernet.EncryptString("secret")
+	encExtra, _ := fernet.EncryptString(`{"a": 1}`)
+
+	t.Run("flagged fields are decrypted", func(t *testing.T) {
+		conn := &models.Connection{ID: "c", Password: encPassword, Extra: encExtra, IsEncrypted: true, IsExtraEncrypted: true}
+		if err := decryptConnection(conn, fernet); err != nil {
+			t.Fatalf("decryptConnection() failed: %v", err)
+		}
+		if conn.Password != "secret" || conn.Extra != `{"a": 1}` {
+			t.Errorf("got password %q extra %q", conn.Password, conn.Extra)
+		}
+	})
+
+	t.Run("unflagged fields are left as-is", func(t *testing.T) {
+		conn := &models.Connection{ID: "c", Password: encPassword, Extra: encExtra}
+		if err := decryptConnection(conn, fernet); err != nil {
+			t.Fatalf("decryptConnection() failed: %v", err)
+		}
+		if conn.Password != encPassword || conn.Extra != encExtra {
+			t.Error("unflagged values should not be decrypted")
+		}
+	})
+
+	t.Run("wrong key is an error", func(t *testing.T) {
+		conn := &models.Connection{ID: "c", Password: encPassword, IsEncrypted: true}
+		if err := decryptConnection(conn, otherFernet); err == nil {
+			t.Error("should fail when flagged password does not decrypt")
+		}
+		if conn.Password != encPassword {
+			t.Error("password should be unchanged on failure")
+		}
+	})
+}
+
+func TestPlaintextPassword(t *testing.T) {
+	tests := []struct {
+		name string
+		conn *models.Connection
+		want bool
+	}{
+		{"encrypted", &models.Connection{Password: "gAAAA...", IsEncrypted: true}, false},
+		{"plaintext", &models.Connection{Password: "secret"}, true},
+		{"no password", &models.Connection{}, false},
+	}
+
+	for _, tt := range tests {
+		if got := plaintextPassword(tt.conn); got != tt.want {
+			t.Errorf("%s: plaintextPassword() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMigrator_ImportUnknownSourceFormat(t *testing.T) {
+	key, _ := services.GenerateKey()
+	profile := models.NewProfile("target")
+	profile.DBHost = "localhost"
+	profile.DBName = "airflow"
+	profile.DBUser = "airflow"
+	profile.FernetKey = key
+
+	result, err := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile: profile,
+		InputPath:     "connections.xlsx",
+		SourceFormat:  "xlsx",
+	})
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if result.Success || result.Error != "unknown source format: xlsx" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestMigrator_ImportUnknownCollisionStrategy(t *testing.T) {
+	key, _ := services.GenerateKey()
+	profile := models.NewProfile("target")
+	profile.DBHost = "localhost"
+	profile.DBName = "airflow"
+	profile.DBUser = "airflow"
+	profile.FernetKey = key
+
+	// Rejected before the file is read or the database is opened
+	result, err := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     profile,
+		InputPath:         "missing.csv",
+		FileDecryptionKey: key,
+		CollisionStrategy: "ovewrite",
+	})
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, `unknown collision strategy "ovewrite"`) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestMigrator_DeleteConnectionsByPrefixGuards(t *testing.T) {
+	profile := models.NewProfile("target")
+
+	tests := []struct {
+		name    string
+		prefix  string
+		confirm bool
+		want    string
+	}{
+		{"empty prefix", "", true, "prefix is required"},
+		{"not confirmed", "test_", false, "confirmation is required to delete connections"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := New().DeleteConnectionsByPrefix(context.Background(), profile, tt.prefix, tt.confirm)
+			if err != nil {
+				t.Fatalf("DeleteConnectionsByPrefix returned error: %v", err)
+			}
+			if result.Success || result.Error != tt.want {
+				t.Errorf("got %+v, want error %q", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintConnection_DecryptsCopy(t *testing.T) {
+	key, _ := services.GenerateKey()
+	fernet, _ := services.NewFernet(key)
+	encryptedExtra, _ := fernet.EncryptString("{not json")
+
+	conn := &models.Connection{ID: "api", ConnType: "http", Host: "example.com", Extra: encryptedExtra, IsExtraEncrypted: true}
+
+	lints := lintConnection(conn, fernet)
+	if len(lints) != 1 || lints[0] != "extra is not valid JSON" {
+		t.Errorf("lints with the right key: got %v", lints)
+	}
+	if conn.Extra != encryptedExtra {
+		t.Error("lintConnection must not modify the connection")
+	}
+
+	otherKey, _ := services.GenerateKey()
+	otherFernet, _ := services.NewFernet(otherKey)
+	lints = lintConnection(conn, otherFernet)
+	if len(lints) != 1 || lints[0] != "password or extra does not decrypt with the profile's Fernet key" {
+		t.Errorf("lints with the wrong key: got %v", lints)
+	}
+}
+
+func TestMigrator_Reencrypt(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "sender.csv")
+	outputPath := filepath.Join(dir, "mine.csv")
+
+	senderKey, _ := services.GenerateKey()
+	senderFernet, _ := services.NewFernet(senderKey)
+	records := []*models.ExportRecord{
+		{ConnID: "a", ConnType: "postgres", Password: "secret"},
+		{ConnID: "b", ConnType: "http", Extra: `{"x": 1}`},
+	}
+	if err := services.WriteEncryptedCSV(inputPath, records, senderFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	m := New()
+	result, err := m.Reencrypt(context.Background(), models.ReencryptRequest{InputPath: inputPath, InputKey: senderKey, OutputPath: outputPath})
+	if err != nil || !result.Success {
+		t.Fatalf("Reencrypt failed: %v %s", err, result.Error)
+	}
+	if result.ConnectionCount != 2 || result.FileEncryptionKey == "" || result.FileEncryptionKey == senderKey {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	myFernet, _ := services.NewFernet(result.FileEncryptionKey)
+	got, err := services.ReadEncryptedCSV(outputPath, myFernet)
+	if err != nil {
+		t.Fatalf("ReadEncryptedCSV failed: %v", err)
+	}
+	if len(got) != 2 || got[0].Password != "secret" || got[1].Extra != `{"x": 1}` {
+		t.Errorf("records changed by re-encryption: %+v", got)
+	}
+	if _, err := services.ReadEncryptedCSV(outputPath, senderFernet); err == nil {
+		t.Error("output should not decrypt with the input key")
+	}
+
+	t.Run("wrong input key", func(t *testing.T) {
+		otherKey, _ := services.GenerateKey()
+		result, _ := m.Reencrypt(context.Background(), models.ReencryptRequest{InputPath: inputPath, InputKey: otherKey, OutputPath: filepath.Join(dir, "x.csv")})
+		if result.Success || result.Error == "" {
+			t.Error("Reencrypt with the wrong key should fail")
+		}
+	})
+
+	t.Run("passphrases", func(t *testing.T) {
+		protected := filepath.Join(dir, "protected.csv")
+		result, _ := m.Reencrypt(context.Background(), models.ReencryptRequest{
+			InputPath: inputPath, InputKey: senderKey, OutputPath: protected, OutputPassphrase: "correct horse",
+		})
+		if !result.Success || !result.PassphraseProtected || result.FileEncryptionKey != "" {
+			t.Fatalf("Reencrypt to a passphrase: %+v", result)
+		}
+
+		back := filepath.Join(dir, "back.csv")
+		result, _ = m.Reencrypt(context.Background(), models.ReencryptRequest{
+			InputPath: protected, InputPassphrase: "correct horse", OutputPath: back,
+		})
+		if !result.Success || result.ConnectionCount != 2 {
+			t.Fatalf("Reencrypt from a passphrase: %+v", result)
+		}
+		backFernet, _ := services.NewFernet(result.FileEncryptionKey)
+		if got, err := services.ReadEncryptedCSV(back, backFernet); err != nil || len(got) != 2 {
+			t.Errorf("ReadEncryptedCSV() = %d records, %v", len(got), err)
+		}
+
+		result, _ = m.Reencrypt(context.Background(), models.ReencryptRequest{
+			InputPath: protected, InputPassphrase: "wrong", OutputPath: filepath.Join(dir, "y.csv"),
+		})
+		if result.Success {
+			t.Error("Reencrypt with the wrong passphrase should fail")
+		}
+	})
+
+	t.Run("output overwrites input", func(t *testing.T) {
+		result, _ := m.Reencrypt(context.Background(), models.ReencryptRequest{InputPath: inputPath, InputKey: senderKey, OutputPath: inputPath})
+		if result.Success || result.Error == "" {
+			t.Error("Reencrypt onto the input file should fail")
+		}
+	})
+}
+
+func TestMigrator_ImportExpectedCountMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	key, _ := services.GenerateKey()
+	fernet, _ := services.NewFernet(key)
+	records := []*models.ExportRecord{{ConnID: "a"}, {ConnID: "b"}}
+	if err := services.WriteEncryptedCSV(path, records, fernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	profile := models.NewProfile("target")
+	profile.DBHost = "localhost"
+	profile.DBName = "airflow"
+	profile.DBUser = "airflow"
+	profile.FernetKey = key
+
+	// Fails before the target database is opened
+	result, err := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     profile,
+		InputPath:         path,
+		FileDecryptionKey: key,
+		CollisionStrategy: models.CollisionSkip,
+		ExpectedCount:     3,
+	})
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "holds 2 connections but 3 were previewed") {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestMigrator_ImportFromS3(t *testing.T) {
+	key, _ := services.GenerateKey()
+	fernet, _ := services.NewFernet(key)
+	path := filepath.Join(t.TempDir(), "export.csv")
+	if err := services.WriteEncryptedCSV(path, []*models.ExportRecord{{ConnID: "a"}, {ConnID: "b"}}, fernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, path)
+	}))
+	defer server.Close()
+
+	profile := models.NewProfile("target")
+	profile.DBHost = "localhost"
+	profile.DBName = "airflow"
+	profile.DBUser = "airflow"
+	profile.FernetKey = key
+
+	// The count check runs on the downloaded, decrypted file before the database is opened
+	result, err := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     profile,
+		InputPath:         "s3://backups/export.csv",
+		S3:                &models.S3Object{Endpoint: server.URL, AccessKey: "AK", SecretKey: "SK"},
+		FileDecryptionKey: key,
+		CollisionStrategy: models.CollisionSkip,
+		ExpectedCount:     3,
+	})
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "holds 2 connections") {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
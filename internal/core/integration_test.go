@@ -0,0 +1,1531 @@
+//go:build integration
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/core/services"
+	_ "github.com/lib/pq"
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+)
+
+// Integration tests run against a real Postgres started with dockertest.
+// Run with: go test -tags integration ./internal/core/...
+
+const integrationPassword = "secret"
+
+// connectionTableDDL is a minimal Airflow 2.x connection table.
+const connectionTableDDL = `
+	CREATE TABLE connection (
+		id SERIAL PRIMARY KEY,
+		conn_id VARCHAR(250) NOT NULL UNIQUE,
+		conn_type VARCHAR(500) NOT NULL,
+		description TEXT,
+		host VARCHAR(500),
+		schema VARCHAR(500),
+		login TEXT,
+		password TEXT,
+		port INTEGER,
+		extra TEXT,
+		is_encrypted BOOLEAN,
+		is_extra_encrypted BOOLEAN
+	)
+`
+
+// integrationDB is a throwaway Airflow database in the test container.
+type integrationDB struct {
+	profile *models.Profile
+	db      *sql.DB
+}
+
+var integrationHost, integrationPort string
+
+func TestMain(m *testing.M) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not connect to docker: %v\n", err)
+		os.Exit(1)
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "postgres",
+		Tag:        "16-alpine",
+		Env: []string{
+			"POSTGRES_PASSWORD=" + integrationPassword,
+			"POSTGRES_USER=airflow",
+			"POSTGRES_DB=airflow",
+		},
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not start postgres: %v\n", err)
+		os.Exit(1)
+	}
+	resource.Expire(300)
+
+	integrationHost = "localhost"
+	integrationPort = resource.GetPort("5432/tcp")
+
+	pool.MaxWait = 60 * time.Second
+	if err := pool.Retry(func() error {
+		db, err := sql.Open("postgres", adminDSN("airflow"))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.Ping()
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "postgres did not become ready: %v\n", err)
+		pool.Purge(resource)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	pool.Purge(resource)
+	os.Exit(code)
+}
+
+func adminDSN(dbName string) string {
+	return fmt.Sprintf("postgres://airflow:%s@%s:%s/%s?sslmode=disable",
+		integrationPassword, integrationHost, integrationPort, dbName)
+}
+
+// newIntegrationDB creates a fresh database with an empty connection table.
+func newIntegrationDB(t *testing.T, name string) *integrationDB {
+	t.Helper()
+
+	admin, err := sql.Open("postgres", adminDSN("airflow"))
+	if err != nil {
+		t.Fatalf("failed to open admin connection: %v", err)
+	}
+	defer admin.Close()
+
+	dbName := fmt.Sprintf("%s_%d", name, time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE DATABASE " + dbName); err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+
+	db, err := sql.Open("postgres", adminDSN(dbName))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(connectionTableDDL); err != nil {
+		t.Fatalf("failed to create connection table: %v", err)
+	}
+
+	port, _ := strconv.Atoi(integrationPort)
+	fernetKey, _ := services.GenerateKey()
+
+	profile := models.NewProfile(name)
+	profile.DBHost = integrationHost
+	profile.DBPort = port
+	profile.DBName = dbName
+	profile.DBUser = "airflow"
+	profile.DBPassword = integrationPassword
+	profile.FernetKey = fernetKey
+
+	return &integrationDB{profile: profile, db: db}
+}
+
+// seed inserts a connection, encrypting password/extra with the profile key when flagged.
+func (i *integrationDB) seed(t *testing.T, conn *models.Connection) {
+	t.Helper()
+
+	fernet, _ := services.NewFernet(i.profile.FernetKey)
+	password, extra := conn.Password, conn.Extra
+	if conn.IsEncrypted && password != "" {
+		password, _ = fernet.EncryptString(password)
+	}
+	if conn.IsExtraEncrypted && extra != "" {
+		extra, _ = fernet.EncryptString(extra)
+	}
+
+	_, err := i.db.Exec(`
+		INSERT INTO connection (conn_id, conn_type, description, host, schema, login, password, port, extra, is_encrypted, is_extra_encrypted)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, 0), NULLIF($9, ''), $10, $11)`,
+		conn.ID, conn.ConnType, conn.Description, conn.Host, conn.Schema, conn.Login,
+		password, conn.Port, extra, conn.IsEncrypted, conn.IsExtraEncrypted,
+	)
+	if err != nil {
+		t.Fatalf("failed to seed %s: %v", conn.ID, err)
+	}
+}
+
+// connections lists the connections with secrets decrypted by the profile key.
+func (i *integrationDB) connections(t *testing.T) map[string]*models.Connection {
+	t.Helper()
+
+	db, err := services.NewDatabase(context.Background(), i.profile)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	list, err := db.ListConnections(context.Background())
+	if err != nil {
+		t.Fatalf("failed to list connections: %v", err)
+	}
+
+	fernet, _ := services.NewFernet(i.profile.FernetKey)
+	result := make(map[string]*models.Connection)
+	for _, conn := range list {
+		if err := decryptConnection(conn, fernet); err != nil {
+			t.Fatalf("failed to decrypt %s: %v", conn.ID, err)
+		}
+		result[conn.ID] = conn
+	}
+	return result
+}
+
+func TestIntegration_ExportImportRoundTrip(t *testing.T) {
+	source := newIntegrationDB(t, "source")
+	target := newIntegrationDB(t, "target")
+
+	seeded := []*models.Connection{
+		{
+			ID: "postgres_main", ConnType: "postgres", Description: "Main DB",
+			Host: "db.internal", Schema: "warehouse", Login: "etl", Password: "p@ss",
+			Port: 5432, Extra: `{"sslmode": "require"}`, IsEncrypted: true, IsExtraEncrypted: true,
+		},
+		{ID: "http_minimal", ConnType: "http"},
+		{ID: "plain_password", ConnType: "ftp", Host: "ftp.internal", Password: "plain"},
+		// Keyless instances store extras in plaintext with is_extra_encrypted false
+		{ID: "plain_extra", ConnType: "http", Extra: `{"verify": false}`},
+	}
+	for _, conn := range seeded {
+		source.seed(t, conn)
+	}
+
+	m := New()
+	ctx := context.Background()
+	outputPath := filepath.Join(t.TempDir(), "export.csv")
+
+	exported, err := m.Export(ctx, models.ExportRequest{
+		SourceProfile: source.profile,
+		OutputPath:    outputPath,
+	})
+	if err != nil || !exported.Success {
+		t.Fatalf("Export failed: %v %s", err, exported.Error)
+	}
+	if exported.ConnectionCount != len(seeded) {
+		t.Fatalf("exported %d connections, want %d", exported.ConnectionCount, len(seeded))
+	}
+	if !sort.StringsAreSorted(exported.ExportedIDs) {
+		t.Errorf("exported IDs not sorted by conn_id: %v", exported.ExportedIDs)
+	}
+
+	imported, err := m.Import(ctx, models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         outputPath,
+		FileDecryptionKey: exported.FileEncryptionKey,
+		CollisionStrategy: models.CollisionStop,
+	})
+	if err != nil || !imported.Success {
+		t.Fatalf("Import failed: %v %s", err, imported.Error)
+	}
+	if imported.ImportedCount != len(seeded) {
+		t.Fatalf("imported %d connections, want %d", imported.ImportedCount, len(seeded))
+	}
+
+	got := target.connections(t)
+	for _, want := range seeded {
+		conn, ok := got[want.ID]
+		if !ok {
+			t.Errorf("%s missing from target", want.ID)
+			continue
+		}
+		if !reflect.DeepEqual(conn, want) {
+			t.Errorf("%s mismatch:\n got  %+v\n want %+v", want.ID, conn, want)
+		}
+	}
+
+	// Re-importing with skip must not touch existing rows
+	again, err := m.Import(ctx, models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         outputPath,
+		FileDecryptionKey: exported.FileEncryptionKey,
+		CollisionStrategy: models.CollisionSkip,
+	})
+	if err != nil || !again.Success {
+		t.Fatalf("second Import failed: %v %s", err, again.Error)
+	}
+	if again.SkippedCount != len(seeded) {
+		t.Errorf("skipped %d connections, want %d", again.SkippedCount, len(seeded))
+	}
+}
+
+func TestIntegration_NormalizeExtraJSON(t *testing.T) {
+	source := newIntegrationDB(t, "normsource")
+	target := newIntegrationDB(t, "normtarget")
+	source.seed(t, &models.Connection{ID: "pretty", ConnType: "http", Extra: "{\n  \"b\": 2,\n  \"a\": 1\n}", IsExtraEncrypted: true})
+	source.seed(t, &models.Connection{ID: "raw", ConnType: "http", Extra: "not json"})
+
+	m := New()
+	ctx := context.Background()
+	outputPath := filepath.Join(t.TempDir(), "export.csv")
+
+	exported, err := m.Export(ctx, models.ExportRequest{
+		SourceProfile:      source.profile,
+		OutputPath:         outputPath,
+		NormalizeExtraJSON: true,
+	})
+	if err != nil || !exported.Success {
+		t.Fatalf("Export failed: %v %s", err, exported.Error)
+	}
+
+	fileFernet, _ := services.NewFernet(exported.FileEncryptionKey)
+	records, err := services.ReadEncryptedCSV(outputPath, fileFernet)
+	if err != nil {
+		t.Fatalf("ReadEncryptedCSV failed: %v", err)
+	}
+	extras := make(map[string]string)
+	for _, r := range records {
+		extras[r.ConnID] = r.Extra
+	}
+	if extras["pretty"] != `{"a":1,"b":2}` || extras["raw"] != "not json" {
+		t.Errorf("unexpected exported extras: %v", extras)
+	}
+
+	// Importing normalizes a pretty extra from the file but leaves existing rows alone
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+	pretty := []*models.ExportRecord{{ConnID: "pretty", ConnType: "http", Extra: "{\n  \"b\": 2,\n  \"a\": 1\n}"}}
+	if err := services.WriteEncryptedCSV(inputPath, pretty, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+	target.seed(t, &models.Connection{ID: "existing", ConnType: "http", Extra: `{ "x" : 1 }`})
+	imported, err := m.Import(ctx, models.ImportRequest{
+		TargetProfile:      target.profile,
+		InputPath:          inputPath,
+		FileDecryptionKey:  exported.FileEncryptionKey,
+		CollisionStrategy:  models.CollisionStop,
+		NormalizeExtraJSON: true,
+	})
+	if err != nil || !imported.Success {
+		t.Fatalf("Import failed: %v %s", err, imported.Error)
+	}
+	if got := target.connections(t); got["pretty"].Extra != `{"a":1,"b":2}` || got["existing"].Extra != `{ "x" : 1 }` {
+		t.Errorf("unexpected imported extras: %q, %q", got["pretty"].Extra, got["existing"].Extra)
+	}
+}
+
+func TestIntegration_ImportContinueOnError(t *testing.T) {
+	target := newIntegrationDB(t, "continue")
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+
+	// conn_type is VARCHAR(500), so the second record fails to insert
+	records := []*models.ExportRecord{
+		{ConnID: "good_one", ConnType: "http"},
+		{ConnID: "bad_one", ConnType: strings.Repeat("x", 600)},
+		{ConnID: "good_two", ConnType: "ftp"},
+	}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	result, err := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		FileDecryptionKey: fileKey,
+		CollisionStrategy: models.CollisionStop,
+		ContinueOnError:   true,
+	})
+	if err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if result.Success {
+		t.Error("import with failures should not be successful")
+	}
+	if result.ImportedCount != 2 {
+		t.Errorf("imported %d connections, want 2", result.ImportedCount)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].ConnID != "bad_one" {
+		t.Errorf("unexpected failures: %+v", result.Failures)
+	}
+}
+
+func TestIntegration_ImportInferConnType(t *testing.T) {
+	target := newIntegrationDB(t, "infertype")
+
+	inputPath := filepath.Join(t.TempDir(), "messy.csv")
+	content := "conn_id,conn_type,host,port\n" +
+		"warehouse,,db.internal,5432\n" +
+		"bucket,,s3://my-bucket,\n" +
+		"mystery,,example.com,9999\n" +
+		"api,http,api.example.com,443\n"
+	if err := os.WriteFile(inputPath, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+
+	result, err := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		SourceFormat:      models.SourceFormatMappedCSV,
+		CollisionStrategy: models.CollisionStop,
+		InferConnType:     true,
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("Import failed: %v %s", err, result.Error)
+	}
+	if !reflect.DeepEqual(result.InvalidIDs, []string{"mystery"}) {
+		t.Errorf("InvalidIDs = %v, want [mystery]", result.InvalidIDs)
+	}
+
+	got := target.connections(t)
+	if len(got) != 3 || got["mystery"] != nil {
+		t.Fatalf("unexpected connections after import: %v", got)
+	}
+	for id, want := range map[string]string{"warehouse": "postgres", "bucket": "aws", "api": "http"} {
+		if got[id].ConnType != want {
+			t.Errorf("%s conn_type = %q, want %q", id, got[id].ConnType, want)
+		}
+	}
+}
+
+func TestIntegration_ImportCaseInsensitiveCollision(t *testing.T) {
+	target := newIntegrationDB(t, "casefold")
+	target.seed(t, &models.Connection{ID: "MyConn", ConnType: "http"})
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+	records := []*models.ExportRecord{{ConnID: "myconn", ConnType: "ftp"}}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	req := models.ImportRequest{
+		TargetProfile:            target.profile,
+		InputPath:                inputPath,
+		FileDecryptionKey:        fileKey,
+		CollisionStrategy:        models.CollisionStop,
+		CaseInsensitiveCollision: true,
+	}
+
+	stopped, _ := New().Import(context.Background(), req)
+	if stopped.Success {
+		t.Fatal("stop strategy should abort on a case variant")
+	}
+	if len(stopped.CaseCollisions) != 1 || stopped.CaseCollisions[0].ExistingID != "MyConn" {
+		t.Errorf("unexpected case collisions: %+v", stopped.CaseCollisions)
+	}
+
+	req.CollisionStrategy = models.CollisionOverwrite
+	replaced, _ := New().Import(context.Background(), req)
+	if !replaced.Success || replaced.OverwrittenCount != 1 {
+		t.Fatalf("overwrite failed: %+v", replaced)
+	}
+
+	got := target.connections(t)
+	if _, ok := got["MyConn"]; ok {
+		t.Error("MyConn should have been replaced")
+	}
+	if conn, ok := got["myconn"]; !ok || conn.ConnType != "ftp" {
+		t.Errorf("myconn not imported: %+v", conn)
+	}
+}
+
+func TestIntegration_ImportCaseVariantFailureKeepsExisting(t *testing.T) {
+	target := newIntegrationDB(t, "casefail")
+	target.seed(t, &models.Connection{ID: "MyConn", ConnType: "http", Host: "kept"})
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+	// The port does not fit the INTEGER column, so replacing the row fails
+	records := []*models.ExportRecord{{ConnID: "myconn", ConnType: "ftp", Port: 1 << 40}}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	result, _ := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:            target.profile,
+		InputPath:                inputPath,
+		FileDecryptionKey:        fileKey,
+		CollisionStrategy:        models.CollisionOverwrite,
+		CaseInsensitiveCollision: true,
+		ContinueOnError:          true,
+	})
+	if result.Success || len(result.Failures) != 1 {
+		t.Fatalf("replace should fail: %+v", result)
+	}
+
+	got := target.connections(t)
+	if conn, ok := got["MyConn"]; !ok || conn.Host != "kept" {
+		t.Errorf("existing connection lost: %+v", conn)
+	}
+	if _, ok := got["myconn"]; ok {
+		t.Error("myconn should not have been imported")
+	}
+}
+
+func TestIntegration_ImportPurgeBeforeImport(t *testing.T) {
+	target := newIntegrationDB(t, "purge")
+	target.seed(t, &models.Connection{ID: "dev_stale", ConnType: "http"})
+	target.seed(t, &models.Connection{ID: "dev_kept", ConnType: "http"})
+	target.seed(t, &models.Connection{ID: "other", ConnType: "http"})
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+	records := []*models.ExportRecord{{ConnID: "kept", ConnType: "ftp"}}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	result, _ := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		FileDecryptionKey: fileKey,
+		CollisionStrategy: models.CollisionStop,
+		ConnectionPrefix:  "dev_",
+		PurgeBeforeImport: true,
+	})
+	if !result.Success {
+		t.Fatalf("Import failed: %s", result.Error)
+	}
+	if result.PurgedCount != 2 {
+		t.Errorf("purged %d connections, want 2", result.PurgedCount)
+	}
+
+	got := target.connections(t)
+	if len(got) != 2 || got["dev_kept"] == nil || got["other"] == nil {
+		t.Errorf("unexpected connections after purge: %v", got)
+	}
+
+	// A failing record rolls back the purge too
+	bad := []*models.ExportRecord{{ConnID: "bad", ConnType: strings.Repeat("x", 600)}}
+	if err := services.WriteEncryptedCSV(inputPath, bad, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+	rolledBack, _ := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		FileDecryptionKey: fileKey,
+		CollisionStrategy: models.CollisionStop,
+		PurgeBeforeImport: true,
+	})
+	if rolledBack.Success {
+		t.Fatal("import with a failing record should not succeed")
+	}
+	if got := target.connections(t); len(got) != 2 {
+		t.Errorf("purge was not rolled back: %v", got)
+	}
+}
+
+func TestIntegration_UpsertAndInsertIfAbsent(t *testing.T) {
+	target := newIntegrationDB(t, "upsert")
+	target.seed(t, &models.Connection{ID: "existing", ConnType: "http", Host: "old"})
+
+	db, err := services.NewDatabase(context.Background(), target.profile)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	inserted, err := db.UpsertConnection(ctx, &models.Connection{ID: "existing", ConnType: "http", Host: "new"})
+	if err != nil || inserted {
+		t.Fatalf("UpsertConnection(existing) = %v, %v, want update", inserted, err)
+	}
+	inserted, err = db.UpsertConnection(ctx, &models.Connection{ID: "fresh", ConnType: "ftp"})
+	if err != nil || !inserted {
+		t.Fatalf("UpsertConnection(fresh) = %v, %v, want insert", inserted, err)
+	}
+
+	// An existing conn_id is left alone rather than failing with a duplicate key
+	inserted, err = db.InsertConnectionIfAbsent(ctx, &models.Connection{ID: "fresh", ConnType: "sftp"})
+	if err != nil || inserted {
+		t.Fatalf("InsertConnectionIfAbsent(fresh) = %v, %v, want skip", inserted, err)
+	}
+
+	got := target.connections(t)
+	if got["existing"].Host != "new" {
+		t.Errorf("existing host = %q, want new", got["existing"].Host)
+	}
+	if got["fresh"].ConnType != "ftp" {
+		t.Errorf("fresh conn_type = %q, want ftp", got["fresh"].ConnType)
+	}
+}
+
+func TestIntegration_NullableFieldsRoundTrip(t *testing.T) {
+	target := newIntegrationDB(t, "nullable")
+
+	db, err := services.NewDatabase(context.Background(), target.profile)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	// nulls lists the optional columns of a row that are SQL NULL
+	nulls := func(connID string) []string {
+		t.Helper()
+		var description, host, schema, login, password, port, extra bool
+		err := target.db.QueryRow(`
+			SELECT description IS NULL, host IS NULL, schema IS NULL, login IS NULL,
+				password IS NULL, port IS NULL, extra IS NULL
+			FROM connection WHERE conn_id = $1`, connID,
+		).Scan(&description, &host, &schema, &login, &password, &port, &extra)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", connID, err)
+		}
+		var columns []string
+		for _, c := range []struct {
+			name   string
+			isNull bool
+		}{
+			{"description", description}, {"host", host}, {"schema", schema}, {"login", login},
+			{"password", password}, {"port", port}, {"extra", extra},
+		} {
+			if c.isNull {
+				columns = append(columns, c.name)
+			}
+		}
+		return columns
+	}
+	allNull := []string{"description", "host", "schema", "login", "password", "port", "extra"}
+
+	// Empty optional fields are written as NULL by every write path
+	if err := db.InsertConnection(ctx, &models.Connection{ID: "inserted", ConnType: "generic"}); err != nil {
+		t.Fatalf("InsertConnection failed: %v", err)
+	}
+	if _, err := db.InsertConnectionBatch(ctx, []*models.Connection{{ID: "batched", ConnType: "generic"}}, false); err != nil {
+		t.Fatalf("InsertConnectionBatch failed: %v", err)
+	}
+	if _, err := db.UpsertConnection(ctx, &models.Connection{ID: "upserted", ConnType: "generic"}); err != nil {
+		t.Fatalf("UpsertConnection failed: %v", err)
+	}
+	for _, id := range []string{"inserted", "batched", "upserted"} {
+		if got := nulls(id); !reflect.DeepEqual(got, allNull) {
+			t.Errorf("%s NULL columns = %v, want %v", id, got, allNull)
+		}
+	}
+
+	// Clearing fields with an update turns them back into NULL
+	full := &models.Connection{
+		ID: "inserted", ConnType: "postgres", Description: "d", Host: "h", Schema: "s",
+		Login: "l", Password: "p", Port: 5432, Extra: `{"a": 1}`,
+	}
+	if err := db.UpdateConnection(ctx, full); err != nil {
+		t.Fatalf("UpdateConnection failed: %v", err)
+	}
+	if got := nulls("inserted"); got != nil {
+		t.Errorf("NULL columns after filling = %v, want none", got)
+	}
+	if err := db.UpdateConnection(ctx, &models.Connection{ID: "inserted", ConnType: "postgres"}); err != nil {
+		t.Fatalf("UpdateConnection failed: %v", err)
+	}
+	if got := nulls("inserted"); !reflect.DeepEqual(got, allNull) {
+		t.Errorf("NULL columns after clearing = %v, want %v", got, allNull)
+	}
+
+	// NULL and an empty string both read back as empty, and the text "NULL" stays text
+	if _, err := target.db.Exec(`INSERT INTO connection (conn_id, conn_type, schema, extra) VALUES ('literal', 'generic', 'NULL', '')`); err != nil {
+		t.Fatalf("failed to insert literal row: %v", err)
+	}
+	conn, err := db.GetConnection(ctx, "inserted")
+	if err != nil {
+		t.Fatalf("GetConnection failed: %v", err)
+	}
+	want := &models.Connection{ID: "inserted", ConnType: "postgres"}
+	if !reflect.DeepEqual(conn, want) {
+		t.Errorf("GetConnection(inserted) = %+v, want %+v", conn, want)
+	}
+	literal, err := db.GetConnection(ctx, "literal")
+	if err != nil {
+		t.Fatalf("GetConnection failed: %v", err)
+	}
+	if literal.Schema != "NULL" || literal.Extra != "" {
+		t.Errorf("literal schema = %q, extra = %q, want \"NULL\" and empty", literal.Schema, literal.Extra)
+	}
+
+	// An empty string written by another tool is left as it is in the table
+	wantNulls := []string{"description", "host", "login", "password", "port"}
+	if got := nulls("literal"); !reflect.DeepEqual(got, wantNulls) {
+		t.Errorf("literal NULL columns = %v, want %v", got, wantNulls)
+	}
+}
+
+func TestIntegration_ImportBatches(t *testing.T) {
+	target := newIntegrationDB(t, "batches")
+	target.seed(t, &models.Connection{ID: "existing", ConnType: "http", Host: "old"})
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+
+	// Five new connections over three batches, one existing and one repeated
+	records := []*models.ExportRecord{
+		{ConnID: "c1", ConnType: "http", Password: "pw1"},
+		{ConnID: "existing", ConnType: "http", Host: "new"},
+		{ConnID: "c2", ConnType: "ftp"},
+		{ConnID: "c3", ConnType: "http"},
+		{ConnID: "c1", ConnType: "http", Password: "again"},
+		{ConnID: "c4", ConnType: "ftp"},
+		{ConnID: "c5", ConnType: "ssh"},
+	}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	result, _ := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		FileDecryptionKey: fileKey,
+		CollisionStrategy: models.CollisionSkip,
+		BatchSize:         2,
+	})
+	if !result.Success {
+		t.Fatalf("Import failed: %s", result.Error)
+	}
+	if result.ImportedCount != 5 || result.SkippedCount != 2 {
+		t.Errorf("imported %d, skipped %d, want 5 and 2", result.ImportedCount, result.SkippedCount)
+	}
+
+	got := target.connections(t)
+	if len(got) != 6 {
+		t.Errorf("target has %d connections, want 6", len(got))
+	}
+	if got["existing"].Host != "old" {
+		t.Errorf("existing host = %q, want old", got["existing"].Host)
+	}
+	if got["c1"].Password != "pw1" {
+		t.Errorf("c1 password = %q, want the first occurrence", got["c1"].Password)
+	}
+}
+
+func TestIntegration_ImportBatchRollback(t *testing.T) {
+	target := newIntegrationDB(t, "batch_rollback")
+	target.seed(t, &models.Connection{ID: "kept", ConnType: "http"})
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+
+	// The failing batch is retried row by row inside the purge transaction
+	records := []*models.ExportRecord{
+		{ConnID: "good_one", ConnType: "http"},
+		{ConnID: "bad_one", ConnType: strings.Repeat("x", 600)},
+		{ConnID: "good_two", ConnType: "ftp"},
+	}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	result, _ := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		FileDecryptionKey: fileKey,
+		CollisionStrategy: models.CollisionStop,
+		PurgeBeforeImport: true,
+		BatchSize:         2,
+	})
+	if result.Success {
+		t.Fatal("import with a failing connection should not be successful")
+	}
+	if !strings.Contains(result.Error, "bad_one") || !strings.Contains(result.Error, "rolled back") {
+		t.Errorf("error %q should name bad_one and the rollback", result.Error)
+	}
+
+	got := target.connections(t)
+	if len(got) != 1 || got["kept"] == nil {
+		t.Errorf("target should be unchanged, got %d connections", len(got))
+	}
+}
+
+func TestIntegration_ImportOverwriteUpsert(t *testing.T) {
+	target := newIntegrationDB(t, "overwrite")
+	target.seed(t, &models.Connection{ID: "a", ConnType: "http", Host: "old"})
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+	records := []*models.ExportRecord{
+		{ConnID: "a", ConnType: "http", Host: "new"},
+		{ConnID: "b", ConnType: "ftp"},
+	}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	result, _ := New().Import(context.Background(), models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		FileDecryptionKey: fileKey,
+		CollisionStrategy: models.CollisionOverwrite,
+	})
+	if !result.Success {
+		t.Fatalf("Import failed: %s", result.Error)
+	}
+	if result.OverwrittenCount != 1 || result.ImportedCount != 1 {
+		t.Errorf("overwritten %d, imported %d, want 1 and 1", result.OverwrittenCount, result.ImportedCount)
+	}
+	if got := target.connections(t); got["a"].Host != "new" {
+		t.Errorf("a host = %q, want new", got["a"].Host)
+	}
+}
+
+func TestIntegration_PreviewImport(t *testing.T) {
+	target := newIntegrationDB(t, "preview")
+	target.seed(t, &models.Connection{ID: "dev_same", ConnType: "http", Host: "h", Password: "pw", IsEncrypted: true})
+	target.seed(t, &models.Connection{ID: "dev_changed", ConnType: "http", Host: "old"})
+
+	records := []*models.ExportRecord{
+		{ConnID: "same", ConnType: "http", Host: "h", Password: "pw", IsEncrypted: true},
+		{ConnID: "changed", ConnType: "http", Host: "new"},
+		{ConnID: "fresh", ConnType: "ftp"},
+	}
+
+	got, err := New().PreviewImport(context.Background(), target.profile, records, "dev_")
+	if err != nil {
+		t.Fatalf("PreviewImport failed: %v", err)
+	}
+
+	want := map[string]models.PreviewStatus{
+		"same":    models.PreviewIdentical,
+		"changed": models.PreviewExists,
+		"fresh":   models.PreviewNew,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PreviewImport() = %v, want %v", got, want)
+	}
+}
+
+func TestIntegration_ImportIsolationLevel(t *testing.T) {
+	target := newIntegrationDB(t, "isolation")
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	inputPath := filepath.Join(t.TempDir(), "import.csv")
+	records := []*models.ExportRecord{{ConnID: "a", ConnType: "http"}}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	req := models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		FileDecryptionKey: fileKey,
+		CollisionStrategy: models.CollisionOverwrite,
+		PurgeBeforeImport: true,
+		IsolationLevel:    "serializable",
+	}
+	if result, _ := New().Import(context.Background(), req); !result.Success {
+		t.Fatalf("Import failed: %s", result.Error)
+	}
+
+	req.IsolationLevel = "snapshot"
+	if result, _ := New().Import(context.Background(), req); result.Success {
+		t.Error("import with an unknown isolation level should fail")
+	}
+}
+
+func TestIntegration_DeleteConnectionsByPrefix(t *testing.T) {
+	target := newIntegrationDB(t, "bulkdelete")
+	for _, id := range []string{"test_a", "test_b", "testing", "prod"} {
+		target.seed(t, &models.Connection{ID: id, ConnType: "http"})
+	}
+
+	result, err := New().DeleteConnectionsByPrefix(context.Background(), target.profile, "test_", true)
+	if err != nil || !result.Success {
+		t.Fatalf("DeleteConnectionsByPrefix failed: %v %s", err, result.Error)
+	}
+	if result.DeletedCount != 2 {
+		t.Errorf("deleted %d connections, want 2: %v", result.DeletedCount, result.DeletedIDs)
+	}
+
+	// "_" is literal, so "testing" survives
+	got := target.connections(t)
+	if len(got) != 2 || got["testing"] == nil || got["prod"] == nil {
+		t.Errorf("unexpected connections after delete: %v", got)
+	}
+}
+
+func TestIntegration_ExistingConnectionIDsLargeList(t *testing.T) {
+	target := newIntegrationDB(t, "largelist")
+	target.seed(t, &models.Connection{ID: "conn_42", ConnType: "http"})
+	target.seed(t, &models.Connection{ID: "Conn_4999", ConnType: "http"})
+
+	ids := make([]string, 5000)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("conn_%d", i)
+	}
+
+	db, err := services.NewDatabase(context.Background(), target.profile)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	existing, err := db.GetExistingConnectionIDs(ctx, ids)
+	if err != nil {
+		t.Fatalf("GetExistingConnectionIDs failed: %v", err)
+	}
+	if !reflect.DeepEqual(existing, []string{"conn_42"}) {
+		t.Errorf("GetExistingConnectionIDs() = %v, want [conn_42]", existing)
+	}
+
+	matches, err := db.GetCaseInsensitiveMatches(ctx, ids)
+	if err != nil {
+		t.Fatalf("GetCaseInsensitiveMatches failed: %v", err)
+	}
+	if len(matches) != 2 || matches["conn_4999"] != "Conn_4999" {
+		t.Errorf("GetCaseInsensitiveMatches() = %v", matches)
+	}
+}
+
+func TestIntegration_ListConnectionMeta(t *testing.T) {
+	source := newIntegrationDB(t, "meta")
+	source.seed(t, &models.Connection{
+		ID: "warehouse", ConnType: "postgres", Description: "DWH",
+		Host: "db.internal", Port: 5432, Password: "secret", IsEncrypted: true,
+	})
+	source.seed(t, &models.Connection{ID: "api", ConnType: "http"})
+
+	m := New()
+	ctx := context.Background()
+
+	metas, err := m.ListConnectionMeta(ctx, source.profile)
+	if err != nil {
+		t.Fatalf("ListConnectionMeta failed: %v", err)
+	}
+	want := []models.ConnectionMeta{
+		{ID: "api", ConnType: "http"},
+		{ID: "warehouse", ConnType: "postgres", Host: "db.internal", Port: 5432, Description: "DWH", HasPassword: true, IsEncrypted: true},
+	}
+	if !reflect.DeepEqual(metas, want) {
+		t.Errorf("ListConnectionMeta() = %+v, want %+v", metas, want)
+	}
+
+	conn, err := m.GetConnection(ctx, source.profile, "warehouse")
+	if err != nil || conn == nil {
+		t.Fatalf("GetConnection failed: %v %v", conn, err)
+	}
+	if conn.Password == "" || !conn.IsEncrypted {
+		t.Errorf("GetConnection should return the stored connection: %+v", conn)
+	}
+
+	missing, err := m.GetConnection(ctx, source.profile, "nope")
+	if err != nil || missing != nil {
+		t.Errorf("GetConnection(missing) = %v, %v; want nil, nil", missing, err)
+	}
+}
+
+func TestIntegration_ListConnectionsFiltered(t *testing.T) {
+	source := newIntegrationDB(t, "filtered")
+	source.seed(t, &models.Connection{ID: "dwh", ConnType: "postgres", Host: "Old-DB.internal", Login: "etl"})
+	source.seed(t, &models.Connection{ID: "reports", ConnType: "postgres", Host: "old-db.internal", Login: "bi"})
+	source.seed(t, &models.Connection{ID: "api", ConnType: "http", Host: "api.internal", Login: "etl", Description: "Old DB proxy"})
+
+	tests := []struct {
+		name   string
+		filter models.ConnectionFilter
+		want   []string
+	}{
+		{"none", models.ConnectionFilter{}, []string{"api", "dwh", "reports"}},
+		{"host ignores case", models.ConnectionFilter{Host: "old-db.INTERNAL"}, []string{"dwh", "reports"}},
+		{"host and login", models.ConnectionFilter{Host: "old-db.internal", Login: "etl"}, []string{"dwh"}},
+		{"login and type", models.ConnectionFilter{Login: "etl", ConnType: "http"}, []string{"api"}},
+		{"search description", models.ConnectionFilter{Search: "old db"}, []string{"api"}},
+		{"no match", models.ConnectionFilter{Host: "new-db.internal"}, nil},
+	}
+
+	m := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connections, err := m.ListConnectionsFiltered(context.Background(), source.profile, tt.filter)
+			if err != nil {
+				t.Fatalf("ListConnectionsFiltered failed: %v", err)
+			}
+			var got []string
+			for _, c := range connections {
+				got = append(got, c.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListConnectionsFiltered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntegration_ImportResume(t *testing.T) {
+	target := newIntegrationDB(t, "resume")
+
+	fileKey, _ := services.GenerateKey()
+	fileFernet, _ := services.NewFernet(fileKey)
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "import.csv")
+
+	records := []*models.ExportRecord{
+		{ConnID: "first", ConnType: "http"},
+		{ConnID: "second", ConnType: "postgres"},
+		{ConnID: "third", ConnType: "ftp"},
+	}
+	if err := services.WriteEncryptedCSV(inputPath, records, fileFernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV failed: %v", err)
+	}
+
+	// Make "postgres" too long so the first run dies at the second record
+	if _, err := target.db.Exec("ALTER TABLE connection ALTER COLUMN conn_type TYPE VARCHAR(6)"); err != nil {
+		t.Fatalf("failed to shrink conn_type: %v", err)
+	}
+
+	req := models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         inputPath,
+		FileDecryptionKey: fileKey,
+		CollisionStrategy: models.CollisionStop,
+	}
+	m := New()
+
+	first, err := m.Import(context.Background(), req)
+	if err != nil || first.Success {
+		t.Fatalf("first import should fail: %v %+v", err, first)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, ".import-checkpoint-*.json"))
+	if len(matches) != 1 {
+		t.Fatalf("expected a checkpoint file, got %v", matches)
+	}
+
+	if _, err := target.db.Exec("ALTER TABLE connection ALTER COLUMN conn_type TYPE VARCHAR(500)"); err != nil {
+		t.Fatalf("failed to restore conn_type: %v", err)
+	}
+
+	// Without resume, the already imported connection collides
+	req.Resume = true
+	resumed, err := m.Import(context.Background(), req)
+	if err != nil || !resumed.Success {
+		t.Fatalf("resumed import failed: %v %s", err, resumed.Error)
+	}
+	if resumed.ResumedCount != 1 || resumed.ImportedCount != 2 {
+		t.Errorf("resumed %d and imported %d connections, want 1 and 2", resumed.ResumedCount, resumed.ImportedCount)
+	}
+	if got := target.connections(t); len(got) != 3 {
+		t.Errorf("target has %d connections, want 3", len(got))
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".import-checkpoint-*.json")); len(matches) != 0 {
+		t.Errorf("checkpoint should be removed after success: %v", matches)
+	}
+}
+
+func TestIntegration_ExportAll(t *testing.T) {
+	source := newIntegrationDB(t, "backup")
+	source.seed(t, &models.Connection{ID: "a", ConnType: "http"})
+	source.seed(t, &models.Connection{ID: "b", ConnType: "ftp"})
+
+	offline := source.profile.Clone()
+	offline.ID = models.NewProfile("offline").ID
+	offline.Name = "offline"
+	offline.DBPort = 1
+
+	dir := t.TempDir()
+	result, err := New().ExportAll(context.Background(), []*models.Profile{source.profile, offline}, dir)
+	if err != nil || !result.Success {
+		t.Fatalf("ExportAll failed: %v %s", err, result.Error)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].ProfileID != offline.ID {
+		t.Errorf("unexpected skipped profiles: %+v", result.Skipped)
+	}
+	if len(result.Manifest.Profiles) != 1 {
+		t.Fatalf("manifest has %d profiles, want 1", len(result.Manifest.Profiles))
+	}
+
+	entry := result.Manifest.Profiles[0]
+	fileFernet, _ := services.NewFernet(entry.FileEncryptionKey)
+	records, err := services.ReadEncryptedCSV(filepath.Join(dir, entry.File), fileFernet)
+	if err != nil {
+		t.Fatalf("backup file does not decrypt with the manifest key: %v", err)
+	}
+	if len(records) != 2 || entry.ConnectionCount != 2 {
+		t.Errorf("backup has %d records, manifest says %d, want 2", len(records), entry.ConnectionCount)
+	}
+}
+
+func TestIntegration_ScheduledExportRun(t *testing.T) {
+	source := newIntegrationDB(t, "scheduled")
+	source.seed(t, &models.Connection{ID: "api", ConnType: "http"})
+
+	m := New()
+	dir := t.TempDir()
+	old := filepath.Join(dir, backupRunPrefix(source.profile)+"-20000101T000000Z")
+	if err := os.Mkdir(old, 0700); err != nil {
+		t.Fatalf("failed to create old backup: %v", err)
+	}
+
+	status := &models.ScheduleStatus{}
+	m.runScheduledExport(context.Background(), source.profile, dir, BackupRotation{Keep: 1}, "", status)
+
+	if status.Runs != 1 || status.LastError != "" || status.LastBackup == "" {
+		t.Fatalf("unexpected status after run: %+v", status)
+	}
+	if _, err := os.Stat(filepath.Join(status.LastBackup, BackupManifestFile)); err != nil {
+		t.Errorf("run should write a manifest: %v", err)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("older backup should be rotated away")
+	}
+}
+
+func TestIntegration_ExportMaxConnections(t *testing.T) {
+	source := newIntegrationDB(t, "maxconns")
+	for _, id := range []string{"a", "b", "c"} {
+		source.seed(t, &models.Connection{ID: id, ConnType: "http"})
+	}
+
+	m := New()
+	ctx := context.Background()
+	req := models.ExportRequest{
+		SourceProfile:  source.profile,
+		OutputPath:     filepath.Join(t.TempDir(), "export.csv"),
+		MaxConnections: 2,
+	}
+
+	result, err := m.Export(ctx, req)
+	if err != nil || result.Success || !strings.Contains(result.Error, "exceeds the limit of 2") {
+		t.Fatalf("Export over the limit = %+v, %v; want a limit error", result, err)
+	}
+	if _, err := os.Stat(req.OutputPath); !os.IsNotExist(err) {
+		t.Error("no file should be written over the limit")
+	}
+
+	req.Force = true
+	result, err = m.Export(ctx, req)
+	if err != nil || !result.Success || result.ConnectionCount != 3 {
+		t.Errorf("forced Export = %+v, %v; want 3 connections", result, err)
+	}
+
+	req.Force = false
+	req.ConnectionIDs = []string{"a", "b"}
+	if result, _ := m.Export(ctx, req); !result.Success {
+		t.Errorf("Export within the limit failed: %s", result.Error)
+	}
+}
+
+func TestIntegration_ExportSplitByType(t *testing.T) {
+	source := newIntegrationDB(t, "split")
+	source.seed(t, &models.Connection{ID: "db_a", ConnType: "postgres"})
+	source.seed(t, &models.Connection{ID: "db_b", ConnType: "postgres"})
+	source.seed(t, &models.Connection{ID: "api", ConnType: "http"})
+
+	outputPath := filepath.Join(t.TempDir(), "export.csv")
+	result, _ := New().Export(context.Background(), models.ExportRequest{
+		SourceProfile: source.profile,
+		OutputPath:    outputPath,
+		SplitBy:       services.SplitType,
+		KeyPerFile:    true,
+	})
+	if !result.Success {
+		t.Fatalf("Export failed: %s", result.Error)
+	}
+	if len(result.Files) != 2 {
+		t.Fatalf("wrote %d files, want 2: %+v", len(result.Files), result.Files)
+	}
+	if result.Files[0].FileEncryptionKey == result.Files[1].FileEncryptionKey {
+		t.Error("KeyPerFile should give each file its own key")
+	}
+
+	for _, f := range result.Files {
+		fernet, _ := services.NewFernet(f.FileEncryptionKey)
+		records, err := services.ReadEncryptedCSV(f.Path, fernet)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", f.Path, err)
+		}
+		if len(records) != f.Count {
+			t.Errorf("%s has %d records, want %d", f.Path, len(records), f.Count)
+		}
+		for _, r := range records {
+			if r.ConnType != f.Group {
+				t.Errorf("%s holds %s of type %s", f.Path, r.ConnID, r.ConnType)
+			}
+		}
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("a split export should not write OutputPath itself")
+	}
+}
+
+func TestIntegration_ExportDryRun(t *testing.T) {
+	source := newIntegrationDB(t, "dryrun")
+	source.seed(t, &models.Connection{ID: "db", ConnType: "postgres"})
+	source.seed(t, &models.Connection{ID: "api_a", ConnType: "http"})
+	source.seed(t, &models.Connection{ID: "api_b", ConnType: "http"})
+
+	outputPath := filepath.Join(t.TempDir(), "export.csv")
+	result, _ := New().Export(context.Background(), models.ExportRequest{
+		SourceProfile:  source.profile,
+		OutputPath:     outputPath,
+		DryRun:         true,
+		MaxConnections: 1,
+	})
+	if !result.Success {
+		t.Fatalf("dry run failed: %s", result.Error)
+	}
+	if result.ConnectionCount != 3 || !reflect.DeepEqual(result.TypeCounts, map[string]int{"http": 2, "postgres": 1}) {
+		t.Errorf("dry run = %d connections, types %v", result.ConnectionCount, result.TypeCounts)
+	}
+	if result.FileEncryptionKey != "" {
+		t.Error("a dry run should not generate a key")
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Error("a dry run should not write a file")
+	}
+}
+
+func TestIntegration_ExportExpandHostURIs(t *testing.T) {
+	source := newIntegrationDB(t, "hosturi")
+	source.seed(t, &models.Connection{ID: "uri", ConnType: "postgres", Host: "postgres://etl:secret@db:5432/airflow"})
+	source.seed(t, &models.Connection{ID: "plain", ConnType: "postgres", Host: "db"})
+
+	export := func(expand bool) (*models.ExportResult, map[string]*models.ExportRecord) {
+		outputPath := filepath.Join(t.TempDir(), "export.csv")
+		result, _ := New().Export(context.Background(), models.ExportRequest{
+			SourceProfile:  source.profile,
+			OutputPath:     outputPath,
+			ExpandHostURIs: expand,
+		})
+		if !result.Success {
+			t.Fatalf("Export failed: %s", result.Error)
+		}
+		fileFernet, _ := services.NewFernet(result.FileEncryptionKey)
+		records, err := services.ReadEncryptedCSV(outputPath, fileFernet)
+		if err != nil {
+			t.Fatalf("ReadEncryptedCSV failed: %v", err)
+		}
+		byID := make(map[string]*models.ExportRecord)
+		for _, r := range records {
+			byID[r.ConnID] = r
+		}
+		return result, byID
+	}
+
+	// Rows are exported as stored unless asked
+	raw, records := export(false)
+	if len(raw.ExpandedIDs) != 0 || records["uri"].Host != "postgres://etl:secret@db:5432/airflow" || records["uri"].Password != "" {
+		t.Errorf("export without expansion changed the row: %+v, %+v", raw.ExpandedIDs, records["uri"])
+	}
+
+	expanded, records := export(true)
+	if !reflect.DeepEqual(expanded.ExpandedIDs, []string{"uri"}) {
+		t.Errorf("ExpandedIDs = %v, want [uri]", expanded.ExpandedIDs)
+	}
+	if r := records["uri"]; r.Host != "db" || r.Login != "etl" || r.Password != "secret" || r.Port != 5432 {
+		t.Errorf("expanded record = %+v", r)
+	}
+}
+
+func TestIntegration_ExportNotFoundIDs(t *testing.T) {
+	source := newIntegrationDB(t, "notfound")
+	source.seed(t, &models.Connection{ID: "db", ConnType: "postgres"})
+	source.seed(t, &models.Connection{ID: "api", ConnType: "http"})
+
+	result, _ := New().Export(context.Background(), models.ExportRequest{
+		SourceProfile: source.profile,
+		OutputPath:    filepath.Join(t.TempDir(), "export.csv"),
+		ConnectionIDs: []string{"gone", "db", "api", "missing", "gone"},
+	})
+	if !result.Success {
+		t.Fatalf("Export failed: %s", result.Error)
+	}
+	if result.ConnectionCount != 2 {
+		t.Errorf("exported %d connections, want 2", result.ConnectionCount)
+	}
+	if want := []string{"gone", "missing"}; !reflect.DeepEqual(result.NotFoundIDs, want) {
+		t.Errorf("NotFoundIDs = %v, want %v", result.NotFoundIDs, want)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "2 of 4 requested connections were not found") {
+		t.Errorf("Warnings = %v", result.Warnings)
+	}
+}
+
+func TestIntegration_ProvenanceKeepsExportedAt(t *testing.T) {
+	source := newIntegrationDB(t, "prov_source")
+	hop := newIntegrationDB(t, "prov_hop")
+	source.seed(t, &models.Connection{ID: "db", ConnType: "postgres", Host: "db.internal"})
+
+	m := New()
+	ctx := context.Background()
+	dir := t.TempDir()
+	provenanceDir := t.TempDir()
+
+	readExportedAt := func(path, key string) string {
+		t.Helper()
+		fernet, _ := services.NewFernet(key)
+		records, err := services.ReadEncryptedCSV(path, fernet)
+		if err != nil || len(records) != 1 {
+			t.Fatalf("ReadEncryptedCSV = %v, %v", records, err)
+		}
+		return records[0].ExportedAt
+	}
+
+	first, _ := m.Export(ctx, models.ExportRequest{SourceProfile: source.profile, OutputPath: filepath.Join(dir, "first.csv")})
+	if !first.Success {
+		t.Fatalf("Export failed: %s", first.Error)
+	}
+	// Make the original time distinguishable from a re-export
+	original := "2020-01-01T00:00:00Z"
+	fernet, _ := services.NewFernet(first.FileEncryptionKey)
+	records, _ := services.ReadEncryptedCSV(filepath.Join(dir, "first.csv"), fernet)
+	records[0].ExportedAt = original
+	services.WriteEncryptedCSV(filepath.Join(dir, "first.csv"), records, fernet, 0)
+
+	imported, _ := m.Import(ctx, models.ImportRequest{
+		TargetProfile:     hop.profile,
+		InputPath:         filepath.Join(dir, "first.csv"),
+		FileDecryptionKey: first.FileEncryptionKey,
+		CollisionStrategy: models.CollisionSkip,
+		ProvenanceDir:     provenanceDir,
+	})
+	if !imported.Success {
+		t.Fatalf("Import failed: %s", imported.Error)
+	}
+
+	kept, _ := m.Export(ctx, models.ExportRequest{SourceProfile: hop.profile, OutputPath: filepath.Join(dir, "kept.csv"), ProvenanceDir: provenanceDir})
+	if got := readExportedAt(filepath.Join(dir, "kept.csv"), kept.FileEncryptionKey); got != original {
+		t.Errorf("re-export with provenance: exported_at = %s, want %s", got, original)
+	}
+
+	fresh, _ := m.Export(ctx, models.ExportRequest{SourceProfile: hop.profile, OutputPath: filepath.Join(dir, "fresh.csv")})
+	if got := readExportedAt(filepath.Join(dir, "fresh.csv"), fresh.FileEncryptionKey); got == original {
+		t.Error("re-export without provenance should stamp a new exported_at")
+	}
+}
+
+func TestIntegration_ExportFilenameTemplate(t *testing.T) {
+	source := newIntegrationDB(t, "filename")
+	source.seed(t, &models.Connection{ID: "db", ConnType: "postgres"})
+	source.profile.Name = "prod eu"
+
+	dir := t.TempDir()
+	result, _ := New().Export(context.Background(), models.ExportRequest{
+		SourceProfile:    source.profile,
+		OutputPath:       filepath.Join(dir, "export.csv"),
+		FilenameTemplate: `{{.Profile}}-conns-{{.Count}}`,
+	})
+	if !result.Success {
+		t.Fatalf("Export failed: %s", result.Error)
+	}
+
+	want := filepath.Join(dir, "prod_eu-conns-1.csv")
+	if result.OutputPath != want {
+		t.Errorf("OutputPath = %q, want %q", result.OutputPath, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("templated file not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "export.csv")); !os.IsNotExist(err) {
+		t.Error("the untemplated path should not be written")
+	}
+}
+
+func TestIntegration_ExportImportPassphrase(t *testing.T) {
+	source := newIntegrationDB(t, "passphrase_source")
+	target := newIntegrationDB(t, "passphrase_target")
+	source.seed(t, &models.Connection{ID: "db", ConnType: "postgres", Password: "secret", IsEncrypted: true})
+
+	m := New()
+	ctx := context.Background()
+	outputPath := filepath.Join(t.TempDir(), "export.csv")
+
+	exported, _ := m.Export(ctx, models.ExportRequest{
+		SourceProfile:  source.profile,
+		OutputPath:     outputPath,
+		FilePassphrase: "hunter2",
+	})
+	if !exported.Success {
+		t.Fatalf("Export failed: %s", exported.Error)
+	}
+	if !exported.PassphraseProtected || exported.FileEncryptionKey != "" {
+		t.Errorf("passphrase export = protected %v, key %q", exported.PassphraseProtected, exported.FileEncryptionKey)
+	}
+
+	wrong, _ := m.Import(ctx, models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         outputPath,
+		FilePassphrase:    "hunter3",
+		CollisionStrategy: models.CollisionStop,
+	})
+	if wrong.Success {
+		t.Error("import with the wrong passphrase should fail")
+	}
+
+	imported, _ := m.Import(ctx, models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         outputPath,
+		FilePassphrase:    "hunter2",
+		CollisionStrategy: models.CollisionStop,
+	})
+	if !imported.Success || imported.ImportedCount != 1 {
+		t.Fatalf("Import failed: %+v", imported)
+	}
+	if got := target.connections(t)["db"]; got == nil || got.Password != "secret" {
+		t.Errorf("imported connection = %+v", got)
+	}
+}
+
+func TestIntegration_ImportMergeExtra(t *testing.T) {
+	source := newIntegrationDB(t, "merge_source")
+	target := newIntegrationDB(t, "merge_target")
+	source.seed(t, &models.Connection{ID: "api", ConnType: "http", Extra: `{"timeout": 30}`, IsExtraEncrypted: true})
+	source.seed(t, &models.Connection{ID: "new", ConnType: "http", Extra: `{"timeout": 5}`, IsExtraEncrypted: true})
+	target.seed(t, &models.Connection{ID: "api", ConnType: "http", Extra: `{"timeout": 10, "last_checked": "2024-01-01"}`, IsExtraEncrypted: true})
+
+	m := New()
+	ctx := context.Background()
+	outputPath := filepath.Join(t.TempDir(), "export.csv")
+	exported, _ := m.Export(ctx, models.ExportRequest{SourceProfile: source.profile, OutputPath: outputPath})
+	if !exported.Success {
+		t.Fatalf("Export failed: %s", exported.Error)
+	}
+
+	imported, _ := m.Import(ctx, models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         outputPath,
+		FileDecryptionKey: exported.FileEncryptionKey,
+		CollisionStrategy: models.CollisionOverwrite,
+		MergeExtra:        true,
+	})
+	if !imported.Success {
+		t.Fatalf("Import failed: %s", imported.Error)
+	}
+
+	got := target.connections(t)
+	if extra := got["api"].Extra; extra != `{"last_checked":"2024-01-01","timeout":30}` {
+		t.Errorf("merged extra = %s", extra)
+	}
+	if extra := got["new"].Extra; extra != `{"timeout": 5}` {
+		t.Errorf("extra of a new connection = %s", extra)
+	}
+}
+
+func TestIntegration_MissingSchemaColumn(t *testing.T) {
+	source := newIntegrationDB(t, "noschema_source")
+	target := newIntegrationDB(t, "noschema_target")
+	source.seed(t, &models.Connection{ID: "db", ConnType: "postgres", Host: "db.internal", Schema: "dwh", Password: "secret", IsEncrypted: true})
+	if _, err := source.db.Exec("ALTER TABLE connection DROP COLUMN schema"); err != nil {
+		t.Fatalf("failed to drop schema column: %v", err)
+	}
+
+	m := New()
+	ctx := context.Background()
+	outputPath := filepath.Join(t.TempDir(), "export.csv")
+	exported, _ := m.Export(ctx, models.ExportRequest{SourceProfile: source.profile, OutputPath: outputPath})
+	if !exported.Success {
+		t.Fatalf("Export failed: %s", exported.Error)
+	}
+
+	imported, _ := m.Import(ctx, models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         outputPath,
+		FileDecryptionKey: exported.FileEncryptionKey,
+		CollisionStrategy: models.CollisionSkip,
+	})
+	if !imported.Success {
+		t.Fatalf("Import failed: %s", imported.Error)
+	}
+
+	got := target.connections(t)["db"]
+	if got == nil || got.Schema != "" || got.Host != "db.internal" || got.Password != "secret" {
+		t.Errorf("imported connection = %+v", got)
+	}
+}
+
+func TestIntegration_PlanImportAppliesReviewedRecords(t *testing.T) {
+	target := newIntegrationDB(t, "plan")
+	target.seed(t, &models.Connection{ID: "existing", ConnType: "http", Host: "old.internal"})
+
+	m := New()
+	ctx := context.Background()
+	key, _ := services.GenerateKey()
+	fernet, _ := services.NewFernet(key)
+	path := filepath.Join(t.TempDir(), "plan.csv")
+	services.WriteEncryptedCSV(path, []*models.ExportRecord{
+		{ConnID: "existing", ConnType: "http", Host: "new.internal"},
+		{ConnID: "fresh", ConnType: "postgres", Host: "db.internal"},
+	}, fernet, 0)
+
+	req := models.ImportRequest{
+		TargetProfile:     target.profile,
+		InputPath:         path,
+		FileDecryptionKey: key,
+		CollisionStrategy: models.CollisionOverwrite,
+	}
+	plan, err := m.PlanImport(ctx, req)
+	if err != nil || !plan.Success {
+		t.Fatalf("PlanImport = %+v, %v", plan, err)
+	}
+	want := []models.ImportPlanEntry{
+		{ConnID: "existing", Status: models.PreviewExists, Action: models.PlanOverwrite},
+		{ConnID: "fresh", Status: models.PreviewNew, Action: models.PlanInsert},
+	}
+	if !reflect.DeepEqual(plan.Connections, want) {
+		t.Errorf("plan = %+v, want %+v", plan.Connections, want)
+	}
+	if len(target.connections(t)) != 1 {
+		t.Error("PlanImport must not write anything")
+	}
+
+	// The file changes after the review; the plan is applied as it was
+	services.WriteEncryptedCSV(path, []*models.ExportRecord{{ConnID: "other", ConnType: "http"}}, fernet, 0)
+	req.Records = plan.Records
+	result, _ := m.Import(ctx, req)
+	if !result.Success {
+		t.Fatalf("Import failed: %s", result.Error)
+	}
+
+	got := target.connections(t)
+	if got["fresh"] == nil || got["other"] != nil || got["existing"].Host != "new.internal" {
+		t.Errorf("connections after applying the plan = %v", got)
+	}
+}
+
+func TestIntegration_ScheduledExportWebhook(t *testing.T) {
+	source := newIntegrationDB(t, "webhook")
+	source.seed(t, &models.Connection{ID: "api", ConnType: "http", Password: "secret"})
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	status := &models.ScheduleStatus{}
+	New().runScheduledExport(context.Background(), source.profile, t.TempDir(), BackupRotation{}, server.URL, status)
+
+	var summary models.ScheduleSummary
+	if err := json.Unmarshal(body, &summary); err != nil {
+		t.Fatalf("webhook body %q: %v", body, err)
+	}
+	if !summary.Success || summary.Connections != 1 || summary.Directory != status.LastBackup || status.LastWebhookError != "" {
+		t.Errorf("summary = %+v, status = %+v", summary, status)
+	}
+	if bytes.Contains(body, []byte("secret")) || bytes.Contains(body, []byte("file_encryption_key")) {
+		t.Errorf("summary must not hold secrets: %s", body)
+	}
+}
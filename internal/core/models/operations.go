@@ -1,5 +1,13 @@
 package models
 
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
 // CollisionStrategy defines how to handle existing connections during import
 type CollisionStrategy string
 
@@ -14,6 +22,49 @@ const (
 	CollisionOverwrite CollisionStrategy = "overwrite"
 )
 
+// CollisionStrategies lists the known strategies in the order they are offered
+var CollisionStrategies = []CollisionStrategy{CollisionStop, CollisionSkip, CollisionOverwrite}
+
+// Valid reports whether c is one of the known strategies
+func (c CollisionStrategy) Valid() bool {
+	return slices.Contains(CollisionStrategies, c)
+}
+
+// PreviewStatus tells how an import record compares to the target database
+type PreviewStatus string
+
+const (
+	// PreviewNew is a connection the target does not have
+	PreviewNew PreviewStatus = "new"
+
+	// PreviewExists is a connection the target has with different values
+	PreviewExists PreviewStatus = "exists"
+
+	// PreviewIdentical is a connection the target already has with the same values
+	PreviewIdentical PreviewStatus = "identical"
+)
+
+// Import file formats
+const (
+	// SourceFormatEncrypted is this tool's encrypted CSV (the default)
+	SourceFormatEncrypted = "encrypted-csv"
+
+	// SourceFormatMappedCSV is a plain CSV from another tool, read with a column mapping
+	SourceFormatMappedCSV = "mapped-csv"
+)
+
+// Ways to split an export into several files (ExportRequest.SplitBy)
+const (
+	// SplitNone writes a single file, as an empty SplitBy does
+	SplitNone = "none"
+
+	// SplitType writes one file per conn_type
+	SplitType = "type"
+
+	// SplitPrefix writes one file per conn_id part before the first underscore
+	SplitPrefix = "prefix"
+)
+
 // ExportRequest contains parameters for an export operation
 type ExportRequest struct {
 	// Source profile to export from
@@ -28,6 +79,172 @@ type ExportRequest struct {
 	// Fernet key for encrypting the export file
 	// If empty, a new key will be generated
 	FileEncryptionKey string `json:"file_encryption_key,omitempty"`
+
+	// Passphrase to derive the file key from instead of a Fernet key. The salt is
+	// stored in the file header, so the import only needs the same passphrase.
+	// Cannot be combined with FileEncryptionKey; KeyPerFile is ignored
+	FilePassphrase string `json:"file_passphrase,omitempty"`
+
+	// Only export connections whose content changed since the last
+	// OnlyChanged export of this profile
+	OnlyChanged bool `json:"only_changed,omitempty"`
+
+	// Directory holding the export state file (.export-state.json)
+	// If empty, the directory of OutputPath is used
+	StateDir string `json:"state_dir,omitempty"`
+
+	// CSV column delimiter, e.g. '\t' or ';'
+	// If zero, a comma is used. Imports detect it from the header
+	Delimiter rune `json:"delimiter,omitempty"`
+
+	// Write extra JSON minified with sorted keys, so equal extras are byte-equal.
+	// Extras that are not valid JSON are left as they are
+	NormalizeExtraJSON bool `json:"normalize_extra_json,omitempty"`
+
+	// Fields written to the file (conn_type, description, host, schema, login,
+	// password, port, extra); conn_id is always written. If empty, all fields are.
+	// Leaving out password and extra gives a file without any secrets
+	Fields []string `json:"fields,omitempty"`
+
+	// Refuse to export more connections than this unless Force is set,
+	// guarding against exporting everything by accident. Zero means no limit
+	MaxConnections int  `json:"max_connections,omitempty"`
+	Force          bool `json:"force,omitempty"`
+
+	// Write one file per group instead of a single file: "type" groups by conn_type,
+	// "prefix" by the conn_id part before the first underscore. Each file is
+	// OutputPath with _<group> before the extension. Empty or SplitNone writes one file
+	SplitBy string `json:"split_by,omitempty"`
+
+	// Encrypt each split file with its own generated key instead of sharing one.
+	// Ignored when FileEncryptionKey is set
+	KeyPerFile bool `json:"key_per_file,omitempty"`
+
+	// List and filter the connections as an export would, without generating a key
+	// or writing any file. MaxConnections does not apply
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Name of the written file as a text/template with .Profile, .Timestamp and .Count,
+	// e.g. "{{.Profile}}-conns-{{.Timestamp.Format \"2006-01-02\"}}.csv". It replaces the
+	// base name of OutputPath and is made a safe file name. If empty, OutputPath is used
+	FilenameTemplate string `json:"filename_template,omitempty"`
+
+	// Upload the encrypted file to this S3-compatible object. With OutputPath the local
+	// file is kept as well; without it the file is written to a temporary directory
+	S3 *S3Object `json:"s3,omitempty"`
+
+	// Add the rows to OutputPath instead of replacing it, creating it when missing, so
+	// repeated exports build one archive. Importing it keeps the last row of each
+	// conn_id. An existing file needs its key or passphrase, and its delimiter
+	Append bool `json:"append,omitempty"`
+
+	// Directory of the provenance file written by imports (ImportRequest.ProvenanceDir).
+	// Connections recorded there for the source profile keep the exported_at of the
+	// file they were imported from instead of the time of this export
+	ProvenanceDir string `json:"provenance_dir,omitempty"`
+
+	// Split a connection URI with credentials stored in the host column, as some
+	// clients write it, into login, password, port, schema and extra. Columns that
+	// are set win; a password taken from the URI is plaintext. See ExpandedIDs
+	ExpandHostURIs bool `json:"expand_host_uris,omitempty"`
+}
+
+// s3URLPrefix starts an ImportRequest.InputPath naming an S3 object
+const s3URLPrefix = "s3://"
+
+// S3Object locates an object in an S3-compatible store such as MinIO. Objects are
+// addressed path-style, {endpoint}/{bucket}/{key}, which every such store accepts.
+type S3Object struct {
+	Endpoint  string `json:"endpoint"`         // e.g. https://minio.internal:9000
+	Region    string `json:"region,omitempty"` // us-east-1 if empty, required for AWS
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+
+	// Session token of temporary credentials, e.g. from AWS STS
+	SessionToken string `json:"session_token,omitempty"`
+}
+
+// Validate checks that the object can be addressed and signed for.
+func (o *S3Object) Validate() error {
+	u, err := url.Parse(o.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("S3 endpoint must be an http or https URL")
+	}
+	if o.Bucket == "" {
+		return fmt.Errorf("S3 bucket is required")
+	}
+	if strings.Trim(o.Key, "/") == "" {
+		return fmt.Errorf("S3 object key is required")
+	}
+	if o.AccessKey == "" || o.SecretKey == "" {
+		return fmt.Errorf("S3 access key and secret key are required")
+	}
+	// AWS rejects signatures for the wrong region, MinIO and most other stores accept any
+	host := strings.ToLower(u.Hostname())
+	if o.Region == "" && (strings.HasSuffix(host, ".amazonaws.com") || strings.HasSuffix(host, ".amazonaws.com.cn")) {
+		return fmt.Errorf("S3 region is required for AWS endpoints")
+	}
+	return nil
+}
+
+// Split reports whether the export is written to several files.
+func (r *ExportRequest) Split() bool {
+	return r.SplitBy != "" && r.SplitBy != SplitNone
+}
+
+// Validate checks the request before any database or file is touched. Checks that
+// need the services (key format, fields, split) are left to Migrator.Export.
+func (r *ExportRequest) Validate() error {
+	if r.SourceProfile == nil {
+		return fmt.Errorf("source profile is required")
+	}
+	if err := r.SourceProfile.Validate(); err != nil {
+		return err
+	}
+	if r.OutputPath == "" && !r.DryRun && r.S3 == nil {
+		return fmt.Errorf("output path is required")
+	}
+	if r.S3 != nil {
+		if err := r.S3.Validate(); err != nil {
+			return err
+		}
+		if r.Split() {
+			return fmt.Errorf("split exports cannot be uploaded to S3")
+		}
+		// The export state would otherwise land in the temporary directory
+		if r.OnlyChanged && r.OutputPath == "" && r.StateDir == "" {
+			return fmt.Errorf("state dir is required for only changed exports to S3 without an output path")
+		}
+	}
+	if r.Append {
+		if r.OutputPath == "" && !r.DryRun {
+			return fmt.Errorf("output path is required to append")
+		}
+		if r.Split() {
+			return fmt.Errorf("split exports cannot be appended")
+		}
+		// A templated name changes between runs, so nothing would accumulate
+		if r.FilenameTemplate != "" {
+			return fmt.Errorf("filename template cannot be combined with append")
+		}
+	}
+	if r.MaxConnections < 0 {
+		return fmt.Errorf("max connections must not be negative")
+	}
+	if r.FilePassphrase != "" && r.FileEncryptionKey != "" {
+		return fmt.Errorf("use either a file encryption key or a passphrase, not both")
+	}
+	return nil
+}
+
+// ExportFile is one of the files written by a split export
+type ExportFile struct {
+	Group             string `json:"group"`
+	Path              string `json:"path"`
+	Count             int    `json:"count"`
+	FileEncryptionKey string `json:"file_encryption_key"`
 }
 
 // ExportResult contains the result of an export operation
@@ -36,9 +253,33 @@ type ExportResult struct {
 	OutputPath        string   `json:"output_path"`
 	ConnectionCount   int      `json:"connection_count"`
 	ExportedIDs       []string `json:"exported_ids"`
-	FileEncryptionKey string   `json:"file_encryption_key"` // The key used (generated or provided)
+	UnchangedCount    int      `json:"unchanged_count,omitempty"` // Skipped by OnlyChanged
+	FileEncryptionKey string   `json:"file_encryption_key"`       // The key used (generated or provided), empty on DryRun
 	Error             string   `json:"error,omitempty"`
 	DownloadURL       string   `json:"download_url,omitempty"`
+	DownloadExpires   string   `json:"download_expires,omitempty"` // RFC 3339; set when DownloadURL can be reused until then
+
+	// Connections whose password is stored without encryption in the source
+	PlaintextPasswordIDs []string `json:"plaintext_password_ids,omitempty"`
+	Warnings             []string `json:"warnings,omitempty"`
+
+	// ExportRequest.ConnectionIDs missing from the source, in the requested order
+	NotFoundIDs []string `json:"not_found_ids,omitempty"`
+
+	// The file key is derived from FilePassphrase, FileEncryptionKey is empty then
+	PassphraseProtected bool `json:"passphrase_protected,omitempty"`
+
+	// Files written by a split export (SplitBy), nothing is written to OutputPath then
+	Files []ExportFile `json:"files,omitempty"`
+
+	// Connections whose host column held a URI split by ExpandHostURIs
+	ExpandedIDs []string `json:"expanded_ids,omitempty"`
+
+	// URL of the object uploaded to ExportRequest.S3
+	ObjectURL string `json:"object_url,omitempty"`
+
+	// Number of exported connections per conn_type
+	TypeCounts map[string]int `json:"type_counts,omitempty"`
 }
 
 // ImportRequest contains parameters for an import operation
@@ -46,12 +287,25 @@ type ImportRequest struct {
 	// Target profile to import into
 	TargetProfile *Profile `json:"target_profile"`
 
-	// Input file path
+	// Input file path, or an S3 object as s3://bucket/key
 	InputPath string `json:"input_path"`
 
+	// Endpoint and credentials for an s3:// InputPath, which sets its Bucket and Key
+	S3 *S3Object `json:"s3,omitempty"`
+
 	// Fernet key for decrypting the import file
 	FileDecryptionKey string `json:"file_decryption_key"`
 
+	// Passphrase the file was exported with, used instead of FileDecryptionKey
+	FilePassphrase string `json:"file_passphrase,omitempty"`
+
+	// Format of the input file, SourceFormatEncrypted if empty
+	SourceFormat string `json:"source_format,omitempty"`
+
+	// Column header -> field (conn_id, conn_type, host, ...) for SourceFormatMappedCSV
+	// If empty, the mapping is detected from common header names
+	ColumnMapping map[string]string `json:"column_mapping,omitempty"`
+
 	// How to handle existing connections
 	CollisionStrategy CollisionStrategy `json:"collision_strategy"`
 
@@ -60,18 +314,316 @@ type ImportRequest struct {
 
 	// Specific connections to import (if empty, imports all)
 	ConnectionIDs []string `json:"connection_ids,omitempty"`
+
+	// Number of connections in the file when it was previewed. If set, the import
+	// fails before writing anything when the file holds another number. Zero skips the check
+	ExpectedCount int `json:"expected_count,omitempty"`
+
+	// Keep importing when a connection fails, collecting failures in the result
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+
+	// Treat conn_ids differing only in case as collisions (e.g. MyConn and myconn)
+	CaseInsensitiveCollision bool `json:"case_insensitive_collision,omitempty"`
+
+	// Delete existing connections before importing, in the same transaction,
+	// so the target mirrors the file. With ConnectionPrefix only connections
+	// starting with the prefix are deleted.
+	PurgeBeforeImport bool `json:"purge_before_import,omitempty"`
+
+	// Must be true for API imports with PurgeBeforeImport, guarding scripts against
+	// deleting data by accident. The TUI and web UI ask interactively
+	Confirm bool `json:"confirm,omitempty"`
+
+	// Values to replace in each connection's extra JSON, keyed by top-level key
+	// or dot-separated path (e.g. "project" or "keyfile_dict.project_id").
+	// Only existing keys are replaced; non-JSON extras are left as they are
+	ExtraRewrite map[string]string `json:"extra_rewrite,omitempty"`
+
+	// With CollisionOverwrite, deep-merge the imported extra JSON into the existing
+	// extra of the target instead of replacing it, keeping keys only the target has.
+	// Extras that are not JSON objects are replaced as usual
+	MergeExtra bool `json:"merge_extra,omitempty"`
+
+	// Guess a missing conn_type from the host, extra and port (e.g. port 5432 is postgres).
+	// Connections whose type cannot be guessed are left out and listed in InvalidIDs
+	InferConnType bool `json:"infer_conn_type,omitempty"`
+
+	// Store extra JSON minified with sorted keys, so equal extras are byte-equal.
+	// Extras that are not valid JSON are left as they are
+	NormalizeExtraJSON bool `json:"normalize_extra_json,omitempty"`
+
+	// Skip connections processed by a previous, failed run of the same import.
+	// Imports outside a transaction keep a checkpoint file that is removed on success
+	Resume bool `json:"resume,omitempty"`
+
+	// Directory holding the checkpoint file (.import-checkpoint-<hash>.json)
+	// If empty, the directory of InputPath is used, the system temp directory for S3
+	CheckpointDir string `json:"checkpoint_dir,omitempty"`
+
+	// Directory of the provenance file (.provenance.json), which records the exported_at
+	// of each imported connection for the target profile so a later export with the
+	// same ExportRequest.ProvenanceDir keeps it. If empty, nothing is recorded
+	ProvenanceDir string `json:"provenance_dir,omitempty"`
+
+	// Isolation level of the import transaction, so only with PurgeBeforeImport:
+	// read_committed, repeatable_read or serializable. If empty, read_committed is used
+	IsolationLevel string `json:"isolation_level,omitempty"`
+
+	// New connections are inserted this many per statement, up to MaxImportBatchSize.
+	// If zero, DefaultImportBatchSize is used; 1 inserts them one at a time
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// Connections to import instead of reading InputPath, e.g. ImportPlan.Records so
+	// the applied import is the reviewed one even if the file changed since
+	Records []*ExportRecord `json:"-"`
+}
+
+const (
+	// DefaultImportBatchSize is the ImportRequest.BatchSize used when none is set
+	DefaultImportBatchSize = 500
+
+	// MaxImportBatchSize keeps a batch well below the PostgreSQL limit of 65535
+	// parameters per statement
+	MaxImportBatchSize = 1000
+)
+
+// Validate checks the request before any database or file is touched. An empty
+// CollisionStrategy is an error rather than an implicit insert-only import.
+func (r *ImportRequest) Validate() error {
+	if r.TargetProfile == nil {
+		return fmt.Errorf("target profile is required")
+	}
+	if err := r.TargetProfile.Validate(); err != nil {
+		return err
+	}
+	if r.InputPath == "" && r.Records == nil {
+		return fmt.Errorf("input path is required")
+	}
+	if _, err := r.S3Source(); err != nil {
+		return err
+	}
+
+	switch r.SourceFormat {
+	case "", SourceFormatEncrypted:
+		if r.FileDecryptionKey == "" && r.FilePassphrase == "" && r.Records == nil {
+			return fmt.Errorf("file decryption key or passphrase is required")
+		}
+	case SourceFormatMappedCSV:
+	default:
+		return fmt.Errorf("unknown source format: %s", r.SourceFormat)
+	}
+
+	if r.CollisionStrategy == "" {
+		return fmt.Errorf("collision strategy is required (%s, %s or %s)", CollisionStop, CollisionSkip, CollisionOverwrite)
+	}
+	if !r.CollisionStrategy.Valid() {
+		return fmt.Errorf("unknown collision strategy %q, expected %s, %s or %s", r.CollisionStrategy, CollisionStop, CollisionSkip, CollisionOverwrite)
+	}
+
+	if r.MergeExtra && r.CollisionStrategy != CollisionOverwrite {
+		return fmt.Errorf("merge extra only applies to the %s strategy", CollisionOverwrite)
+	}
+	if r.Resume && r.PurgeBeforeImport {
+		return fmt.Errorf("resume cannot be combined with purge before import")
+	}
+	if r.Resume && r.Records != nil {
+		return fmt.Errorf("resume cannot be combined with planned records")
+	}
+	// Only a purging import runs in a transaction
+	if r.IsolationLevel != "" && !r.PurgeBeforeImport {
+		return fmt.Errorf("isolation level only applies with purge before import")
+	}
+	if r.ExpectedCount < 0 {
+		return fmt.Errorf("expected count must not be negative")
+	}
+	if r.BatchSize < 0 || r.BatchSize > MaxImportBatchSize {
+		return fmt.Errorf("batch size must be between 0 and %d (0 uses the default)", MaxImportBatchSize)
+	}
+	return nil
+}
+
+// S3Source returns the object named by an s3://bucket/key InputPath, with the endpoint
+// and credentials of S3, or nil when InputPath is a local file.
+func (r *ImportRequest) S3Source() (*S3Object, error) {
+	if !strings.HasPrefix(r.InputPath, s3URLPrefix) {
+		return nil, nil
+	}
+	if r.S3 == nil {
+		return nil, fmt.Errorf("S3 endpoint and credentials are required to import %s", r.InputPath)
+	}
+	u, err := url.Parse(r.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 URL: %w", err)
+	}
+
+	obj := *r.S3
+	obj.Bucket = u.Host
+	obj.Key = strings.TrimPrefix(u.Path, "/")
+	if err := obj.Validate(); err != nil {
+		return nil, err
+	}
+	return &obj, nil
+}
+
+// CaseCollision records an imported conn_id that matches an existing one only when ignoring case
+type CaseCollision struct {
+	ConnID     string `json:"conn_id"`
+	ExistingID string `json:"existing_id"`
+}
+
+// ImportFailure records a connection that could not be imported
+type ImportFailure struct {
+	ConnID string `json:"conn_id"`
+	Error  string `json:"error"`
 }
 
 // ImportResult contains the result of an import operation
 type ImportResult struct {
-	Success          bool     `json:"success"`
-	ImportedCount    int      `json:"imported_count"`
-	SkippedCount     int      `json:"skipped_count"`
-	OverwrittenCount int      `json:"overwritten_count"`
-	ImportedIDs      []string `json:"imported_ids"`
-	SkippedIDs       []string `json:"skipped_ids,omitempty"`
-	OverwrittenIDs   []string `json:"overwritten_ids,omitempty"`
-	Error            string   `json:"error,omitempty"`
+	Success          bool            `json:"success"`
+	ImportedCount    int             `json:"imported_count"`
+	SkippedCount     int             `json:"skipped_count"`
+	OverwrittenCount int             `json:"overwritten_count"`
+	PurgedCount      int             `json:"purged_count,omitempty"`  // Only with PurgeBeforeImport
+	ResumedCount     int             `json:"resumed_count,omitempty"` // Already processed, only with Resume
+	ImportedIDs      []string        `json:"imported_ids"`
+	SkippedIDs       []string        `json:"skipped_ids,omitempty"`
+	OverwrittenIDs   []string        `json:"overwritten_ids,omitempty"`
+	Failures         []ImportFailure `json:"failures,omitempty"`        // Only with ContinueOnError
+	CaseCollisions   []CaseCollision `json:"case_collisions,omitempty"` // Only with CaseInsensitiveCollision
+	InvalidIDs       []string        `json:"invalid_ids,omitempty"`     // No conn_type could be inferred, only with InferConnType
+	Error            string          `json:"error,omitempty"`
+}
+
+// Planned import actions, see ImportPlanEntry
+const (
+	PlanInsert    = "insert"    // A new connection is inserted
+	PlanSkip      = "skip"      // The existing connection is kept
+	PlanOverwrite = "overwrite" // The existing connection is replaced
+	PlanConflict  = "conflict"  // The connection exists, so the stop strategy fails the import
+)
+
+// ImportPlanEntry is what an import would do with one connection
+type ImportPlanEntry struct {
+	ConnID string        `json:"conn_id"` // In the target, with the prefix
+	Status PreviewStatus `json:"status"`
+	Action string        `json:"action"`
+}
+
+// ImportPlan is what an import would do, computed without writing anything. The API
+// keeps it under PlanID until ExpiresAt so it can be applied as reviewed.
+type ImportPlan struct {
+	Success     bool              `json:"success"`
+	PlanID      string            `json:"plan_id,omitempty"`
+	ExpiresAt   time.Time         `json:"expires_at,omitzero"`
+	Total       int               `json:"total"` // Connections in the file
+	PurgeFirst  bool              `json:"purge_first,omitempty"`
+	Connections []ImportPlanEntry `json:"connections"`
+	Error       string            `json:"error,omitempty"`
+
+	// Records are the connections read from the file, imported as they are on apply
+	Records []*ExportRecord `json:"-"`
+}
+
+// ReencryptRequest contains parameters for re-encrypting an export file under another key
+type ReencryptRequest struct {
+	InputPath  string `json:"input_path"`
+	InputKey   string `json:"input_key"`
+	OutputPath string `json:"output_path"`
+	OutputKey  string `json:"output_key,omitempty"` // If empty, a new key will be generated
+
+	// Passphrase the input file was exported with, used instead of InputKey
+	InputPassphrase string `json:"input_passphrase,omitempty"`
+
+	// Passphrase to protect the output with instead of OutputKey, with a new salt
+	OutputPassphrase string `json:"output_passphrase,omitempty"`
+}
+
+// ReencryptResult contains the result of a re-encryption
+type ReencryptResult struct {
+	Success           bool   `json:"success"`
+	OutputPath        string `json:"output_path"`
+	ConnectionCount   int    `json:"connection_count"`
+	FileEncryptionKey string `json:"file_encryption_key"` // The key used (generated or provided)
+	Error             string `json:"error,omitempty"`
+
+	// The output is protected by OutputPassphrase, FileEncryptionKey is empty
+	PassphraseProtected bool `json:"passphrase_protected,omitempty"`
+}
+
+// BackupEntry describes one profile's file in a backup
+type BackupEntry struct {
+	ProfileID         string `json:"profile_id"`
+	ProfileName       string `json:"profile_name"`
+	File              string `json:"file"` // Relative to the backup directory
+	FileEncryptionKey string `json:"file_encryption_key"`
+	ConnectionCount   int    `json:"connection_count"`
+
+	Warnings []string `json:"warnings,omitempty"` // Those of the export, see ExportResult.Warnings
+}
+
+// BackupFailure records a profile left out of a backup
+type BackupFailure struct {
+	ProfileID   string `json:"profile_id"`
+	ProfileName string `json:"profile_name"`
+	Error       string `json:"error"`
+}
+
+// BackupManifest maps every backed up profile to its file and key
+type BackupManifest struct {
+	CreatedAt string        `json:"created_at"` // ISO 8601 timestamp
+	Profiles  []BackupEntry `json:"profiles"`
+}
+
+// ExportAllResult contains the result of backing up every profile
+type ExportAllResult struct {
+	Success      bool            `json:"success"`
+	Directory    string          `json:"directory"`
+	ManifestPath string          `json:"manifest_path"`
+	Manifest     BackupManifest  `json:"manifest"`
+	Skipped      []BackupFailure `json:"skipped,omitempty"` // Unreachable or failed profiles
+	Error        string          `json:"error,omitempty"`
+}
+
+// ExportSchedule configures a recurring backup of one profile, as read from schedules.json
+type ExportSchedule struct {
+	ProfileID string `json:"profile_id"`
+	Interval  string `json:"interval"`          // Go duration, e.g. "24h"
+	Dir       string `json:"dir,omitempty"`     // Relative to the config directory, "backups" if empty
+	Keep      int    `json:"keep,omitempty"`    // Backups kept per profile, all if zero
+	MaxAge    string `json:"max_age,omitempty"` // Backups older than this are removed, e.g. "720h"
+	Webhook   string `json:"webhook,omitempty"` // URL receiving a ScheduleSummary after each run
+}
+
+// ScheduleStatus reports the state of a scheduled export
+type ScheduleStatus struct {
+	ProfileID   string `json:"profile_id"`
+	ProfileName string `json:"profile_name"`
+	Interval    string `json:"interval"`
+	Dir         string `json:"dir"`
+	Runs        int    `json:"runs"`
+	LastRun     string `json:"last_run,omitempty"`    // ISO 8601 timestamp
+	NextRun     string `json:"next_run"`              // ISO 8601 timestamp
+	LastBackup  string `json:"last_backup,omitempty"` // Directory of the last successful run
+	LastError   string `json:"last_error,omitempty"`
+
+	LastConnections  int    `json:"last_connections,omitempty"`   // Connections in the last successful run
+	LastWebhookError string `json:"last_webhook_error,omitempty"` // Why the last summary was not delivered
+}
+
+// ScheduleSummary is posted to the webhook of a schedule after each run. It holds
+// counts and paths, never a key or a credential
+type ScheduleSummary struct {
+	Text                string   `json:"text"` // One line, shown as the message by Slack-compatible webhooks
+	ProfileID           string   `json:"profile_id"`
+	ProfileName         string   `json:"profile_name"`
+	Success             bool     `json:"success"`
+	RunAt               string   `json:"run_at"` // ISO 8601 timestamp
+	Directory           string   `json:"directory,omitempty"`
+	Connections         int      `json:"connections"`
+	PreviousConnections int      `json:"previous_connections,omitempty"` // In the last successful run, if any
+	RemovedBackups      int      `json:"removed_backups,omitempty"`
+	Warnings            []string `json:"warnings,omitempty"`
+	Error               string   `json:"error,omitempty"`
 }
 
 // TestConnectionRequest contains parameters for testing a database connection
@@ -96,6 +648,13 @@ type ValidateFernetKeyRequest struct {
 type ValidateFernetKeyResult struct {
 	Valid   bool   `json:"valid"`
 	Message string `json:"message"`
+
+	// How the key is encoded, see services.InspectKey
+	Encoding          string `json:"encoding,omitempty"`
+	Length            int    `json:"length,omitempty"`
+	URLSafe           bool   `json:"url_safe"`
+	AirflowCompatible bool   `json:"airflow_compatible"`
+	Warning           string `json:"warning,omitempty"`
 }
 
 // ListConnectionsRequest contains parameters for listing connections
@@ -107,6 +666,19 @@ type ListConnectionsRequest struct {
 
 	// Optional search term (searches in ID and description)
 	Search string `json:"search,omitempty"`
+
+	// Optional exact host and login filters, e.g. to find every connection to a
+	// decommissioned database. The host is compared ignoring case
+	Host  string `json:"host,omitempty"`
+	Login string `json:"login,omitempty"`
+
+	// Split connection URIs stored in the host column, see ExportRequest.ExpandHostURIs
+	ExpandHostURIs bool `json:"expand_host_uris,omitempty"`
+}
+
+// Filter returns the filters of the request. Set filters are combined with AND.
+func (r *ListConnectionsRequest) Filter() ConnectionFilter {
+	return ConnectionFilter{ConnType: r.ConnType, Search: r.Search, Host: r.Host, Login: r.Login}
 }
 
 // ListConnectionsResult contains the list of connections
@@ -115,6 +687,37 @@ type ListConnectionsResult struct {
 	Connections []*Connection `json:"connections"`
 	Count       int           `json:"count"`
 	Error       string        `json:"error,omitempty"`
+
+	// Connections whose host column held a URI split by ExpandHostURIs
+	ExpandedIDs []string `json:"expanded_ids,omitempty"`
+}
+
+// DeleteProfileRequest contains the options of an API profile delete
+type DeleteProfileRequest struct {
+	// Profile name, required when deletes must be confirmed by name
+	Confirm string `json:"confirm,omitempty"`
+
+	// Delete without the name confirmation, for scripts that only know the ID
+	Force bool `json:"force,omitempty"`
+}
+
+// DeleteConnectionsRequest contains parameters for deleting connections by prefix
+type DeleteConnectionsRequest struct {
+	Profile *Profile `json:"profile"`
+
+	// Delete every connection whose ID starts with this prefix (required)
+	Prefix string `json:"prefix"`
+
+	// Must be true, guards against accidental deletes
+	Confirm bool `json:"confirm"`
+}
+
+// DeleteConnectionsResult contains the result of a bulk delete
+type DeleteConnectionsResult struct {
+	Success      bool     `json:"success"`
+	DeletedCount int      `json:"deleted_count"`
+	DeletedIDs   []string `json:"deleted_ids"`
+	Error        string   `json:"error,omitempty"`
 }
 
 // GenerateFernetKeyResult contains a newly generated Fernet key
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func validTestProfile() *Profile {
+	return &Profile{
+		ID:        "1",
+		Name:      "Dev",
+		DBHost:    "localhost",
+		DBPort:    5432,
+		DBName:    "airflow",
+		DBUser:    "airflow",
+		FernetKey: "test-key",
+	}
+}
+
+func validTestS3Object() *S3Object {
+	return &S3Object{Endpoint: "https://minio:9000", Bucket: "backups", Key: "airflow.csv", AccessKey: "a", SecretKey: "s"}
+}
+
+func TestExportRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ExportRequest
+		wantErr string
+	}{
+		{"valid", ExportRequest{SourceProfile: validTestProfile(), OutputPath: "out.csv"}, ""},
+		{"dry run without path", ExportRequest{SourceProfile: validTestProfile(), DryRun: true}, ""},
+		{"missing profile", ExportRequest{OutputPath: "out.csv"}, "source profile is required"},
+		{"missing path", ExportRequest{SourceProfile: validTestProfile()}, "output path is required"},
+		{"key and passphrase", ExportRequest{SourceProfile: validTestProfile(), OutputPath: "out.csv", FileEncryptionKey: "k", FilePassphrase: "p"}, "not both"},
+		{"negative max", ExportRequest{SourceProfile: validTestProfile(), OutputPath: "out.csv", MaxConnections: -1}, "max connections"},
+		{"s3 without path", ExportRequest{SourceProfile: validTestProfile(), S3: validTestS3Object()}, ""},
+		{"s3 bad endpoint", ExportRequest{SourceProfile: validTestProfile(), S3: &S3Object{Endpoint: "minio:9000", Bucket: "b", Key: "k", AccessKey: "a", SecretKey: "s"}}, "S3 endpoint"},
+		{"s3 missing key", ExportRequest{SourceProfile: validTestProfile(), S3: &S3Object{Endpoint: "http://minio:9000", Bucket: "b", Key: "/", AccessKey: "a", SecretKey: "s"}}, "object key is required"},
+		{"s3 aws without region", ExportRequest{SourceProfile: validTestProfile(), S3: &S3Object{Endpoint: "https://s3.amazonaws.com", Bucket: "b", Key: "k", AccessKey: "a", SecretKey: "s"}}, "region is required"},
+		{"s3 aws with region", ExportRequest{SourceProfile: validTestProfile(), S3: &S3Object{Endpoint: "https://s3.eu-west-1.amazonaws.com", Region: "eu-west-1", Bucket: "b", Key: "k", AccessKey: "a", SecretKey: "s"}}, ""},
+		{"s3 split", ExportRequest{SourceProfile: validTestProfile(), S3: validTestS3Object(), SplitBy: "type"}, "split exports"},
+		{"append", ExportRequest{SourceProfile: validTestProfile(), OutputPath: "out.csv", Append: true}, ""},
+		{"append to s3 only", ExportRequest{SourceProfile: validTestProfile(), S3: validTestS3Object(), Append: true}, "output path is required to append"},
+		{"append split", ExportRequest{SourceProfile: validTestProfile(), OutputPath: "out.csv", Append: true, SplitBy: "type"}, "cannot be appended"},
+		{"append template", ExportRequest{SourceProfile: validTestProfile(), OutputPath: "out.csv", Append: true, FilenameTemplate: "x.csv"}, "filename template"},
+		{"s3 only changed", ExportRequest{SourceProfile: validTestProfile(), S3: validTestS3Object(), OnlyChanged: true}, "state dir is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestImportRequest_Validate(t *testing.T) {
+	valid := func() ImportRequest {
+		return ImportRequest{
+			TargetProfile:     validTestProfile(),
+			InputPath:         "in.csv",
+			FileDecryptionKey: "key",
+			CollisionStrategy: CollisionSkip,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *ImportRequest)
+		wantErr string
+	}{
+		{"valid", func(r *ImportRequest) {}, ""},
+		{"passphrase without key", func(r *ImportRequest) { r.FileDecryptionKey = ""; r.FilePassphrase = "hunter2" }, ""},
+		{"mapped csv without key", func(r *ImportRequest) { r.SourceFormat = SourceFormatMappedCSV; r.FileDecryptionKey = "" }, ""},
+		{"missing profile", func(r *ImportRequest) { r.TargetProfile = nil }, "target profile is required"},
+		{"missing path", func(r *ImportRequest) { r.InputPath = "" }, "input path is required"},
+		{"missing key", func(r *ImportRequest) { r.FileDecryptionKey = "" }, "file decryption key or passphrase is required"},
+		{"unknown format", func(r *ImportRequest) { r.SourceFormat = "xlsx" }, "unknown source format: xlsx"},
+		{"empty strategy", func(r *ImportRequest) { r.CollisionStrategy = "" }, "collision strategy is required"},
+		{"unknown strategy", func(r *ImportRequest) { r.CollisionStrategy = "merge" }, `unknown collision strategy "merge"`},
+		{"resume with purge", func(r *ImportRequest) { r.Resume = true; r.PurgeBeforeImport = true }, "resume cannot be combined"},
+		{"isolation without purge", func(r *ImportRequest) { r.IsolationLevel = "serializable" }, "isolation level only applies"},
+		{"isolation with purge", func(r *ImportRequest) { r.IsolationLevel = "serializable"; r.PurgeBeforeImport = true }, ""},
+		{"batch too large", func(r *ImportRequest) { r.BatchSize = MaxImportBatchSize + 1 }, "batch size"},
+		{"negative expected count", func(r *ImportRequest) { r.ExpectedCount = -1 }, "expected count"},
+		{"s3 source", func(r *ImportRequest) { r.InputPath = "s3://backups/airflow.csv"; r.S3 = validTestS3Object() }, ""},
+		{"s3 without config", func(r *ImportRequest) { r.InputPath = "s3://backups/airflow.csv" }, "S3 endpoint and credentials are required"},
+		{"s3 without key", func(r *ImportRequest) { r.InputPath = "s3://backups/"; r.S3 = validTestS3Object() }, "object key is required"},
+		{"planned records", func(r *ImportRequest) { r.InputPath = ""; r.FileDecryptionKey = ""; r.Records = []*ExportRecord{} }, ""},
+		{"resume planned records", func(r *ImportRequest) { r.Records = []*ExportRecord{}; r.Resume = true }, "planned records"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.modify(&req)
+			err := req.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCollisionStrategy_Valid(t *testing.T) {
+	for _, c := range CollisionStrategies {
+		if !c.Valid() {
+			t.Errorf("%q.Valid() = false, want true", c)
+		}
+	}
+	for _, c := range []CollisionStrategy{"", "ovewrite", "Skip"} {
+		if c.Valid() {
+			t.Errorf("%q.Valid() = true, want false", c)
+		}
+	}
+}
+
+func TestImportRequest_S3Source(t *testing.T) {
+	r := ImportRequest{InputPath: "s3://backups/nightly/airflow.csv", S3: &S3Object{Endpoint: "http://minio:9000", AccessKey: "a", SecretKey: "s"}}
+	obj, err := r.S3Source()
+	if err != nil {
+		t.Fatalf("S3Source() failed: %v", err)
+	}
+	if obj.Bucket != "backups" || obj.Key != "nightly/airflow.csv" || obj.Endpoint != "http://minio:9000" {
+		t.Errorf("S3Source() = %+v", obj)
+	}
+	if r.S3.Bucket != "" {
+		t.Error("S3Source() must not modify the request")
+	}
+
+	r.InputPath = "airflow.csv"
+	if obj, err := r.S3Source(); obj != nil || err != nil {
+		t.Errorf("local file: S3Source() = %+v, %v", obj, err)
+	}
+}
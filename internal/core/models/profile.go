@@ -2,7 +2,11 @@ package models
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Profile represents a saved connection profile for an Airflow instance.
@@ -50,10 +54,10 @@ func NewProfile(name string) *Profile {
 	}
 }
 
-// generateID creates a simple unique ID
-// In production, use github.com/google/uuid
+// generateID creates a random (v4) UUID.
+// Profiles saved with older timestamp IDs keep working, IDs are opaque strings.
 func generateID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	return uuid.NewString()
 }
 
 // Validate checks if the profile has all required fields
@@ -82,6 +86,26 @@ func (p *Profile) Validate() error {
 	return nil
 }
 
+// ParsePort parses a database port from user input, accepting only 1-65535.
+func ParsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("port must be a number between 1 and 65535")
+	}
+	return port, nil
+}
+
+// ValidateHost checks that host is a bare hostname or IP address.
+func ValidateHost(host string) error {
+	if strings.TrimSpace(host) == "" {
+		return fmt.Errorf("database host is required")
+	}
+	if strings.ContainsAny(host, " \t\n/") {
+		return fmt.Errorf("database host must be a hostname or IP address, without scheme or path")
+	}
+	return nil
+}
+
 // ConnectionString returns a PostgreSQL connection string
 func (p *Profile) ConnectionString() string {
 	return fmt.Sprintf(
@@ -129,13 +153,17 @@ func (p *Profile) Touch() {
 type ProfileSecretKeys struct {
 	Password  string
 	FernetKey string
+
+	// Connection IDs of the last TUI export from the profile, a JSON array
+	LastExportSelection string
 }
 
 // GetSecretKeys returns the SecretStore keys for this profile's secrets
 func (p *Profile) GetSecretKeys() ProfileSecretKeys {
 	return ProfileSecretKeys{
-		Password:  fmt.Sprintf("profile:%s:password", p.ID),
-		FernetKey: fmt.Sprintf("profile:%s:fernet", p.ID),
+		Password:            fmt.Sprintf("profile:%s:password", p.ID),
+		FernetKey:           fmt.Sprintf("profile:%s:fernet", p.ID),
+		LastExportSelection: fmt.Sprintf("profile:%s:last_export_selection", p.ID),
 	}
 }
 
@@ -163,3 +191,50 @@ func (p *Profile) Summary() ProfileSummary {
 		UpdatedAt: p.UpdatedAt,
 	}
 }
+
+// Matches reports whether the name, host or database name contains query, ignoring
+// case. An empty query matches every profile.
+func (s ProfileSummary) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+	for _, field := range []string{s.Name, s.DBHost, s.DBName} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileDetails is a ProfileSummary with the remaining non-secret settings.
+// Secrets are never included, only whether they are set.
+type ProfileDetails struct {
+	ProfileSummary
+	DBUser           string `json:"db_user"`
+	DBSSLMode        string `json:"db_ssl_mode"`
+	ConnectionPrefix string `json:"connection_prefix,omitempty"`
+	HasPassword      bool   `json:"has_password"`
+	HasFernetKey     bool   `json:"has_fernet_key"`
+}
+
+// Details returns the ProfileDetails of a profile (safe for display/logging)
+func (p *Profile) Details() ProfileDetails {
+	return ProfileDetails{
+		ProfileSummary:   p.Summary(),
+		DBUser:           p.DBUser,
+		DBSSLMode:        p.DBSSLMode,
+		ConnectionPrefix: p.ConnectionPrefix,
+		HasPassword:      p.DBPassword != "",
+		HasFernetKey:     p.FernetKey != "",
+	}
+}
+
+// ProfileStats is a ProfileSummary enriched with live information from the database
+type ProfileStats struct {
+	ProfileSummary
+	ConnectionCount int       `json:"connection_count"`
+	Reachable       bool      `json:"reachable"`
+	LastTested      time.Time `json:"last_tested"`
+	Error           string    `json:"error,omitempty"`
+}
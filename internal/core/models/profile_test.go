@@ -1,7 +1,11 @@
 package models
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
+
+	"github.com/google/uuid"
 )
 
 func TestProfile_Validate(t *testing.T) {
@@ -136,6 +140,52 @@ func TestProfile_GetSecretKeys(t *testing.T) {
 	if keys.FernetKey != "profile:profile-123:fernet" {
 		t.Errorf("FernetKey key: got %q", keys.FernetKey)
 	}
+	if keys.LastExportSelection != "profile:profile-123:last_export_selection" {
+		t.Errorf("LastExportSelection key: got %q", keys.LastExportSelection)
+	}
+}
+
+func TestProfile_Details(t *testing.T) {
+	p := NewProfile("Prod")
+	p.DBHost = "db.internal"
+	p.DBUser = "airflow"
+	p.DBSSLMode = "require"
+	p.DBPassword = "secret"
+
+	d := p.Details()
+	if d.ID != p.ID || d.DBHost != "db.internal" || d.DBUser != "airflow" || d.DBSSLMode != "require" {
+		t.Errorf("Details() = %+v", d)
+	}
+	if !d.HasPassword || d.HasFernetKey {
+		t.Errorf("HasPassword/HasFernetKey: got %v/%v, want true/false", d.HasPassword, d.HasFernetKey)
+	}
+
+	data, _ := json.Marshal(d)
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("Details() must not include secrets: %s", data)
+	}
+}
+
+func TestProfileSummary_Matches(t *testing.T) {
+	s := ProfileSummary{Name: "Staging EU", DBHost: "pg.eu.internal", DBName: "airflow_stg"}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"staging", true},
+		{"  EU ", true},
+		{"pg.eu", true},
+		{"_stg", true},
+		{"prod", false},
+	}
+
+	for _, tt := range tests {
+		if got := s.Matches(tt.query); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
 }
 
 func TestNewProfile(t *testing.T) {
@@ -154,3 +204,58 @@ func TestNewProfile(t *testing.T) {
 		t.Errorf("DBSSLMode: got %q, want %q", p.DBSSLMode, DefaultDBSSLMode)
 	}
 }
+
+func TestNewProfile_UniqueIDs(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := NewProfile("Loop").ID
+		if _, err := uuid.Parse(id); err != nil {
+			t.Fatalf("ID %q is not a UUID: %v", id, err)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID %q after %d profiles", id, i)
+		}
+		seen[id] = true
+	}
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"5432", 5432, false},
+		{" 6543 ", 6543, false},
+		{"1", 1, false},
+		{"65535", 65535, false},
+		{"0", 0, true},
+		{"65536", 0, true},
+		{"-1", 0, true},
+		{"54x2", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePort(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePort(%q) = %d, %v; want %d, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateHost(t *testing.T) {
+	valid := []string{"localhost", "db.internal", "10.0.0.5", "::1"}
+	for _, host := range valid {
+		if err := ValidateHost(host); err != nil {
+			t.Errorf("ValidateHost(%q) error: %v", host, err)
+		}
+	}
+
+	invalid := []string{"", "   ", "postgres://db", "db.internal/airflow", "db internal"}
+	for _, host := range invalid {
+		if err := ValidateHost(host); err == nil {
+			t.Errorf("ValidateHost(%q) should fail", host)
+		}
+	}
+}
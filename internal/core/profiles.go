@@ -0,0 +1,111 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+	"github.com/google/uuid"
+)
+
+// MigrateProfileIDs moves profiles stored under legacy (timestamp) IDs to UUIDs.
+// Every profile:<id>:* key is renamed and the ID inside the metadata is rewritten,
+// all in a single store transaction. It returns the mapping from old to new IDs,
+// and leaves the store untouched when there is nothing to migrate.
+func (m *Migrator) MigrateProfileIDs(store *secrets.Store) (map[string]string, error) {
+	legacy := make(map[string][]string) // old ID -> its keys
+	for _, key := range store.List() {
+		parts := strings.SplitN(key, ":", 3)
+		if len(parts) != 3 || parts[0] != "profile" {
+			continue
+		}
+		if _, err := uuid.Parse(parts[1]); err != nil {
+			legacy[parts[1]] = append(legacy[parts[1]], key)
+		}
+	}
+	if len(legacy) == 0 {
+		return nil, nil
+	}
+
+	migrated := make(map[string]string)
+	err := store.Transaction(func(txn *secrets.Txn) error {
+		for oldID, keys := range legacy {
+			newID := uuid.NewString()
+
+			for _, oldKey := range keys {
+				value, err := txn.Get(oldKey)
+				if err != nil {
+					continue
+				}
+				txn.Set("profile:"+newID+":"+strings.TrimPrefix(oldKey, "profile:"+oldID+":"), value)
+				txn.Delete(oldKey)
+			}
+
+			metaKey := fmt.Sprintf("profile:%s:meta", newID)
+			if metaJSON, err := txn.Get(metaKey); err == nil {
+				var meta map[string]any
+				if err := json.Unmarshal([]byte(metaJSON), &meta); err != nil {
+					return fmt.Errorf("failed to parse metadata of profile %s: %w", oldID, err)
+				}
+				meta["id"] = newID
+				updated, _ := json.Marshal(meta)
+				txn.Set(metaKey, string(updated))
+			}
+
+			migrated[oldID] = newID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return migrated, nil
+}
+
+// FindOrphanedSecrets returns the profile:<id>:* keys whose profile has no :meta entry,
+// e.g. left behind by a save that failed halfway. Such profiles are invisible in the UIs.
+func (m *Migrator) FindOrphanedSecrets(store *secrets.Store) []string {
+	var profileKeys []string
+	hasMeta := make(map[string]bool)
+	for _, key := range store.List() {
+		parts := strings.Split(key, ":")
+		if len(parts) != 3 || parts[0] != "profile" {
+			continue
+		}
+		if parts[2] == "meta" {
+			hasMeta[parts[1]] = true
+			continue
+		}
+		profileKeys = append(profileKeys, key)
+	}
+
+	var orphans []string
+	for _, key := range profileKeys {
+		if !hasMeta[strings.Split(key, ":")[1]] {
+			orphans = append(orphans, key)
+		}
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+// CleanOrphanedSecrets deletes the keys reported by FindOrphanedSecrets in a single
+// store transaction and returns them.
+func (m *Migrator) CleanOrphanedSecrets(store *secrets.Store) ([]string, error) {
+	orphans := m.FindOrphanedSecrets(store)
+	err := store.Transaction(func(txn *secrets.Txn) error {
+		for _, key := range orphans {
+			if err := txn.Delete(key); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphans, nil
+}
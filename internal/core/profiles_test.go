@@ -0,0 +1,102 @@
+package core
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+	"github.com/google/uuid"
+)
+
+func TestMigrator_MigrateProfileIDs(t *testing.T) {
+	store, err := secrets.New(t.TempDir(), "password")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	store.Set("profile:1700000000000000000:meta", `{"id":"1700000000000000000","name":"Dev"}`)
+	store.Set("profile:1700000000000000000:password", "pw")
+	store.Set("profile:1700000000000000000:fernet", "fk")
+	store.Set("profile:1700000000000000000:last_export_selection", `["a"]`)
+
+	current := uuid.NewString()
+	store.Set("profile:"+current+":meta", `{"id":"`+current+`","name":"Prod"}`)
+	store.Set("unrelated", "value")
+
+	migrated, err := New().MigrateProfileIDs(store)
+	if err != nil {
+		t.Fatalf("MigrateProfileIDs() failed: %v", err)
+	}
+	if len(migrated) != 1 {
+		t.Fatalf("migrated %d profiles, want 1", len(migrated))
+	}
+
+	newID := migrated["1700000000000000000"]
+	if _, err := uuid.Parse(newID); err != nil {
+		t.Fatalf("new ID %q is not a UUID", newID)
+	}
+
+	if pw, _ := store.Get("profile:" + newID + ":password"); pw != "pw" {
+		t.Errorf("password not moved: got %q", pw)
+	}
+	if sel, _ := store.Get("profile:" + newID + ":last_export_selection"); sel != `["a"]` {
+		t.Errorf("last export selection not moved: got %q", sel)
+	}
+	if store.Has("profile:1700000000000000000:fernet") {
+		t.Error("old keys should be removed")
+	}
+
+	metaJSON, _ := store.Get("profile:" + newID + ":meta")
+	var meta map[string]any
+	json.Unmarshal([]byte(metaJSON), &meta)
+	if meta["id"] != newID || meta["name"] != "Dev" {
+		t.Errorf("metadata not rewritten: %s", metaJSON)
+	}
+
+	if !store.Has("profile:"+current+":meta") || !store.Has("unrelated") {
+		t.Error("UUID profiles and other keys should be untouched")
+	}
+
+	again, err := New().MigrateProfileIDs(store)
+	if err != nil || len(again) != 0 {
+		t.Errorf("second MigrateProfileIDs() = %v, %v, want nothing to migrate", again, err)
+	}
+}
+
+func TestMigrator_FindOrphanedSecrets(t *testing.T) {
+	store, err := secrets.New(t.TempDir(), "password")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	store.Set("profile:kept:meta", `{"id":"kept"}`)
+	store.Set("profile:kept:password", "pw")
+	store.Set("profile:broken:password", "pw")
+	store.Set("profile:broken:fernet", "fk")
+	store.Set("unrelated", "value")
+
+	m := New()
+	orphans := m.FindOrphanedSecrets(store)
+	want := []string{"profile:broken:fernet", "profile:broken:password"}
+	if !reflect.DeepEqual(orphans, want) {
+		t.Fatalf("FindOrphanedSecrets() = %v, want %v", orphans, want)
+	}
+
+	cleaned, err := m.CleanOrphanedSecrets(store)
+	if err != nil {
+		t.Fatalf("CleanOrphanedSecrets() failed: %v", err)
+	}
+	if !reflect.DeepEqual(cleaned, want) {
+		t.Errorf("cleaned %v, want %v", cleaned, want)
+	}
+	if store.Has("profile:broken:password") {
+		t.Error("orphaned key should be deleted")
+	}
+	if !store.Has("profile:kept:password") || !store.Has("unrelated") {
+		t.Error("keys of existing profiles and other keys should be untouched")
+	}
+	if orphans := m.FindOrphanedSecrets(store); len(orphans) != 0 {
+		t.Errorf("orphans left after cleaning: %v", orphans)
+	}
+}
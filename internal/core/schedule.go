@@ -0,0 +1,332 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/core/services"
+)
+
+const (
+	// ScheduleFile is the file in the config directory listing scheduled exports.
+	ScheduleFile = "schedules.json"
+
+	// defaultScheduleDir is where scheduled backups go when a schedule has no dir.
+	defaultScheduleDir = "backups"
+
+	// backupRunTimeFormat stamps each scheduled backup directory; it sorts by time.
+	backupRunTimeFormat = "20060102T150405Z"
+)
+
+// BackupRotation limits the scheduled backups kept per profile. Zero values keep everything.
+type BackupRotation struct {
+	Keep   int           // Newest backups kept
+	MaxAge time.Duration // Older backups are removed
+}
+
+// Schedule is a parsed entry of the schedule file.
+type Schedule struct {
+	ProfileID string
+	Interval  time.Duration
+	Dir       string // Absolute, or relative to the working directory
+	Rotation  BackupRotation
+	Webhook   string // URL receiving a models.ScheduleSummary after each run, if set
+}
+
+// LoadSchedules reads the schedule file of configDir. A missing file means no schedules.
+// Relative directories are resolved against configDir.
+func LoadSchedules(configDir string) ([]Schedule, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, ScheduleFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ScheduleFile, err)
+	}
+
+	var file struct {
+		Schedules []models.ExportSchedule `json:"schedules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ScheduleFile, err)
+	}
+
+	schedules := make([]Schedule, 0, len(file.Schedules))
+	for i, entry := range file.Schedules {
+		if entry.ProfileID == "" {
+			return nil, fmt.Errorf("schedule %d: profile_id is required", i+1)
+		}
+		interval, err := time.ParseDuration(entry.Interval)
+		if err != nil || interval < time.Minute {
+			return nil, fmt.Errorf("schedule %d: interval must be a duration of at least 1m, got %q", i+1, entry.Interval)
+		}
+		if entry.Keep < 0 {
+			return nil, fmt.Errorf("schedule %d: keep must not be negative", i+1)
+		}
+		var maxAge time.Duration
+		if entry.MaxAge != "" {
+			if maxAge, err = time.ParseDuration(entry.MaxAge); err != nil || maxAge <= 0 {
+				return nil, fmt.Errorf("schedule %d: invalid max_age %q", i+1, entry.MaxAge)
+			}
+		}
+
+		if entry.Webhook != "" {
+			if err := services.ValidateWebhookURL(entry.Webhook); err != nil {
+				return nil, fmt.Errorf("schedule %d: %v", i+1, err)
+			}
+		}
+
+		dir := entry.Dir
+		if dir == "" {
+			dir = defaultScheduleDir
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(configDir, dir)
+		}
+
+		schedules = append(schedules, Schedule{
+			ProfileID: entry.ProfileID,
+			Interval:  interval,
+			Dir:       dir,
+			Rotation:  BackupRotation{Keep: entry.Keep, MaxAge: maxAge},
+			Webhook:   entry.Webhook,
+		})
+	}
+	return schedules, nil
+}
+
+// scheduleRegistry holds the status of every scheduled export.
+type scheduleRegistry struct {
+	mu       sync.Mutex
+	statuses []*models.ScheduleStatus
+}
+
+func (r *scheduleRegistry) add(status *models.ScheduleStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, status)
+}
+
+// update runs fn on a status while holding the lock.
+func (r *scheduleRegistry) update(status *models.ScheduleStatus, fn func(*models.ScheduleStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fn(status)
+}
+
+// ProfileLoader returns the current, decrypted profile of a scheduled export. An
+// error skips the run, e.g. secrets.ErrLocked while the store is locked.
+type ProfileLoader func() (*models.Profile, error)
+
+// ScheduleExport backs up a profile every interval until ctx is cancelled. The
+// profile is loaded now and again before each run, so edits are picked up and no
+// decrypted copy is kept between runs. Each run is an ExportAll of the profile into
+// its own timestamped directory under dir, with its manifest, after which older
+// runs are removed according to rotation. With a webhook, a summary of each run is
+// posted to it.
+func (m *Migrator) ScheduleExport(ctx context.Context, load ProfileLoader, interval time.Duration, dir string, rotation BackupRotation, webhook string) error {
+	profile, err := load()
+	if err != nil {
+		return err
+	}
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	status := &models.ScheduleStatus{
+		ProfileID:   profile.ID,
+		ProfileName: profile.Name,
+		Interval:    interval.String(),
+		Dir:         dir,
+		NextRun:     time.Now().Add(interval).UTC().Format(time.RFC3339),
+	}
+	m.schedules.add(status)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if profile, err := load(); err != nil {
+					log.Printf("scheduled export of %s skipped: %v", status.ProfileName, err)
+					m.schedules.update(status, func(s *models.ScheduleStatus) {
+						s.LastError = "skipped: " + err.Error()
+					})
+				} else {
+					m.runScheduledExport(ctx, profile, dir, rotation, webhook, status)
+				}
+				m.schedules.update(status, func(s *models.ScheduleStatus) {
+					s.NextRun = time.Now().Add(interval).UTC().Format(time.RFC3339)
+				})
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Schedules returns the status of every scheduled export, ordered by profile name.
+func (m *Migrator) Schedules() []models.ScheduleStatus {
+	m.schedules.mu.Lock()
+	defer m.schedules.mu.Unlock()
+
+	statuses := make([]models.ScheduleStatus, len(m.schedules.statuses))
+	for i, s := range m.schedules.statuses {
+		statuses[i] = *s
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].ProfileName < statuses[j].ProfileName
+	})
+	return statuses
+}
+
+// runScheduledExport runs one scheduled backup of profile, rotates older ones and
+// posts a summary to webhook when it is set.
+func (m *Migrator) runScheduledExport(ctx context.Context, profile *models.Profile, dir string, rotation BackupRotation, webhook string, status *models.ScheduleStatus) {
+	now := time.Now().UTC()
+	prefix := backupRunPrefix(profile)
+	runDir := filepath.Join(dir, prefix+"-"+now.Format(backupRunTimeFormat))
+
+	result, _ := m.ExportAll(ctx, []*models.Profile{profile}, runDir)
+	runErr := result.Error
+	if runErr == "" && len(result.Skipped) > 0 {
+		runErr = result.Skipped[0].Error
+	}
+
+	summary := models.ScheduleSummary{
+		ProfileID:   profile.ID,
+		ProfileName: profile.Name,
+		Success:     runErr == "",
+		RunAt:       now.Format(time.RFC3339),
+		Error:       runErr,
+	}
+	for _, entry := range result.Manifest.Profiles {
+		summary.Connections += entry.ConnectionCount
+		summary.Warnings = append(summary.Warnings, entry.Warnings...)
+	}
+
+	m.schedules.update(status, func(s *models.ScheduleStatus) {
+		s.Runs++
+		s.LastRun = now.Format(time.RFC3339)
+		s.LastError = runErr
+		if runErr == "" {
+			summary.PreviousConnections = s.LastConnections
+			s.LastBackup = runDir
+			s.LastConnections = summary.Connections
+		}
+	})
+
+	if runErr != "" {
+		// A failed run must not count as a backup when rotating
+		os.RemoveAll(runDir)
+		log.Printf("scheduled export of %s failed: %s", profile.Name, runErr)
+	} else {
+		summary.Directory = runDir
+		log.Printf("scheduled export of %s written to %s", profile.Name, runDir)
+
+		removed, err := rotateBackups(dir, prefix, rotation, now)
+		if err != nil {
+			log.Printf("failed to rotate backups of %s: %v", profile.Name, err)
+		}
+		for _, path := range removed {
+			log.Printf("removed old backup %s", path)
+		}
+		summary.RemovedBackups = len(removed)
+	}
+
+	if webhook == "" {
+		return
+	}
+	summary.Text = summaryText(summary)
+	err := m.NotifyWebhook(ctx, webhook, summary)
+	if err != nil {
+		log.Printf("scheduled export of %s: %v", profile.Name, err)
+	}
+	m.schedules.update(status, func(s *models.ScheduleStatus) {
+		s.LastWebhookError = ""
+		if err != nil {
+			s.LastWebhookError = err.Error()
+		}
+	})
+}
+
+// summaryText is the one-line message of a scheduled run summary.
+func summaryText(s models.ScheduleSummary) string {
+	if !s.Success {
+		return fmt.Sprintf("Scheduled export of %s failed: %s", s.ProfileName, s.Error)
+	}
+	text := fmt.Sprintf("Scheduled export of %s: %d connections", s.ProfileName, s.Connections)
+	if change := s.Connections - s.PreviousConnections; s.PreviousConnections > 0 && change != 0 {
+		text += fmt.Sprintf(" (%+d since the last backup)", change)
+	}
+	if len(s.Warnings) > 0 {
+		text += fmt.Sprintf(", %d warnings", len(s.Warnings))
+	}
+	return text
+}
+
+// backupRunPrefix names the scheduled backup directories of a profile.
+func backupRunPrefix(p *models.Profile) string {
+	return strings.TrimSuffix(backupFileName(p), ".csv")
+}
+
+// rotateBackups removes the backup directories of one profile under dir that are
+// beyond rotation.Keep or older than rotation.MaxAge, and returns their paths.
+func rotateBackups(dir, prefix string, rotation BackupRotation, now time.Time) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type backup struct {
+		path    string
+		created time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), prefix+"-")
+		if !entry.IsDir() || !ok {
+			continue
+		}
+		created, err := time.Parse(backupRunTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, entry.Name()), created: created})
+	}
+
+	// Newest first
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].created.After(backups[j].created)
+	})
+
+	var removed []string
+	for i, b := range backups {
+		tooMany := rotation.Keep > 0 && i >= rotation.Keep
+		tooOld := rotation.MaxAge > 0 && now.Sub(b.created) > rotation.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.RemoveAll(b.path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, b.path)
+	}
+	return removed, nil
+}
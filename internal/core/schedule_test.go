@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+func TestLoadSchedules(t *testing.T) {
+	dir := t.TempDir()
+
+	schedules, err := LoadSchedules(dir)
+	if err != nil || schedules != nil {
+		t.Fatalf("LoadSchedules(no file) = %v, %v; want nil, nil", schedules, err)
+	}
+
+	content := `{"schedules": [
+		{"profile_id": "prod", "interval": "24h", "keep": 7, "max_age": "720h"},
+		{"profile_id": "dev", "interval": "1h", "dir": "/var/backups/airflow", "webhook": "https://hooks.example.com/T1"}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, ScheduleFile), []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write schedule file: %v", err)
+	}
+
+	schedules, err = LoadSchedules(dir)
+	if err != nil {
+		t.Fatalf("LoadSchedules() failed: %v", err)
+	}
+	want := []Schedule{
+		{ProfileID: "prod", Interval: 24 * time.Hour, Dir: filepath.Join(dir, "backups"), Rotation: BackupRotation{Keep: 7, MaxAge: 720 * time.Hour}},
+		{ProfileID: "dev", Interval: time.Hour, Dir: "/var/backups/airflow", Webhook: "https://hooks.example.com/T1"},
+	}
+	if !reflect.DeepEqual(schedules, want) {
+		t.Errorf("LoadSchedules() = %+v, want %+v", schedules, want)
+	}
+}
+
+func TestLoadSchedules_Invalid(t *testing.T) {
+	for _, content := range []string{
+		`not json`,
+		`{"schedules": [{"interval": "1h"}]}`,
+		`{"schedules": [{"profile_id": "p", "interval": "daily"}]}`,
+		`{"schedules": [{"profile_id": "p", "interval": "10s"}]}`,
+		`{"schedules": [{"profile_id": "p", "interval": "1h", "keep": -1}]}`,
+		`{"schedules": [{"profile_id": "p", "interval": "1h", "max_age": "forever"}]}`,
+		`{"schedules": [{"profile_id": "p", "interval": "1h", "webhook": "hooks.example.com/T1"}]}`,
+	} {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, ScheduleFile), []byte(content), 0600)
+		if _, err := LoadSchedules(dir); err == nil {
+			t.Errorf("LoadSchedules(%s) should fail", content)
+		}
+	}
+}
+
+func TestRotateBackups(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC)
+	for _, name := range []string{
+		"prod-0f8fad5b-20240310T020000Z",
+		"prod-0f8fad5b-20240309T020000Z",
+		"prod-0f8fad5b-20240308T020000Z",
+		"prod-0f8fad5b-20240201T020000Z",
+		"dev-7c9e6679-20240101T020000Z", // another profile
+		"prod-0f8fad5b-notes",           // not a backup
+	} {
+		os.Mkdir(filepath.Join(dir, name), 0700)
+	}
+
+	removed, err := rotateBackups(dir, "prod-0f8fad5b", BackupRotation{Keep: 3, MaxAge: 36 * time.Hour}, now)
+	if err != nil {
+		t.Fatalf("rotateBackups() failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(dir, "prod-0f8fad5b-20240308T020000Z"), // beyond max age
+		filepath.Join(dir, "prod-0f8fad5b-20240201T020000Z"), // beyond keep and max age
+	}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("rotateBackups() removed %v, want %v", removed, want)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 4 {
+		t.Errorf("%d entries left, want 4", len(entries))
+	}
+
+	// Zero rotation keeps everything
+	if removed, _ := rotateBackups(dir, "prod-0f8fad5b", BackupRotation{}, now.Add(1000*time.Hour)); len(removed) != 0 {
+		t.Errorf("rotateBackups() without limits removed %v", removed)
+	}
+}
+
+func TestMigrator_ScheduleExport(t *testing.T) {
+	m := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	profile := &models.Profile{ID: "p1", Name: "Prod", DBHost: "db", DBPort: 5432, DBName: "airflow", DBUser: "airflow", FernetKey: "key"}
+	load := func() (*models.Profile, error) { return profile, nil }
+	if err := m.ScheduleExport(ctx, load, 0, t.TempDir(), BackupRotation{}, ""); err == nil {
+		t.Error("ScheduleExport() should reject a zero interval")
+	}
+	invalid := func() (*models.Profile, error) { return &models.Profile{}, nil }
+	if err := m.ScheduleExport(ctx, invalid, time.Hour, t.TempDir(), BackupRotation{}, ""); err == nil {
+		t.Error("ScheduleExport() should reject an invalid profile")
+	}
+	locked := func() (*models.Profile, error) { return nil, secrets.ErrLocked }
+	if err := m.ScheduleExport(ctx, locked, time.Hour, t.TempDir(), BackupRotation{}, ""); !errors.Is(err, secrets.ErrLocked) {
+		t.Errorf("ScheduleExport() = %v, want the load error", err)
+	}
+
+	if err := m.ScheduleExport(ctx, load, time.Hour, "/backups", BackupRotation{}, ""); err != nil {
+		t.Fatalf("ScheduleExport() failed: %v", err)
+	}
+	statuses := m.Schedules()
+	if len(statuses) != 1 || statuses[0].ProfileName != "Prod" || statuses[0].Interval != "1h0m0s" || statuses[0].Runs != 0 {
+		t.Errorf("Schedules() = %+v", statuses)
+	}
+}
+
+func TestMigrator_NotifyWebhook(t *testing.T) {
+	defer func(d time.Duration) { webhookRetryDelay = d }(webhookRetryDelay)
+	webhookRetryDelay = time.Millisecond
+
+	var calls int
+	var got models.ScheduleSummary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	summary := models.ScheduleSummary{ProfileName: "Prod", Success: true, Connections: 42}
+	if err := New().NotifyWebhook(context.Background(), server.URL, summary); err != nil {
+		t.Fatalf("NotifyWebhook() failed: %v", err)
+	}
+	if calls != 2 || got.Connections != 42 {
+		t.Errorf("NotifyWebhook() made %d calls and delivered %+v, want a retry delivering the summary", calls, got)
+	}
+
+	// A rejected summary is not sent again
+	calls = 0
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer rejecting.Close()
+	if err := New().NotifyWebhook(context.Background(), rejecting.URL, summary); err == nil || calls != 1 {
+		t.Errorf("NotifyWebhook() on 400 = %v after %d calls, want an error after 1", err, calls)
+	}
+}
+
+func TestSummaryText(t *testing.T) {
+	tests := []struct {
+		summary models.ScheduleSummary
+		want    string
+	}{
+		{models.ScheduleSummary{ProfileName: "Prod", Success: true, Connections: 42}, "Scheduled export of Prod: 42 connections"},
+		{models.ScheduleSummary{ProfileName: "Prod", Success: true, Connections: 42, PreviousConnections: 45}, "Scheduled export of Prod: 42 connections (-3 since the last backup)"},
+		{models.ScheduleSummary{ProfileName: "Prod", Success: true, Connections: 2, Warnings: []string{"a", "b"}}, "Scheduled export of Prod: 2 connections, 2 warnings"},
+		{models.ScheduleSummary{ProfileName: "Prod", Error: "unreachable"}, "Scheduled export of Prod failed: unreachable"},
+	}
+	for _, tt := range tests {
+		if got := summaryText(tt.summary); got != tt.want {
+			t.Errorf("summaryText() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ImportCheckpoint records the conn_ids already processed by an import, so a failed
+// import can resume where it stopped.
+type ImportCheckpoint struct {
+	Processed []string `json:"processed"`
+
+	path string
+	done map[string]bool
+}
+
+// ImportCheckpointKey identifies an import by the input file content, the target
+// profile and the conn_id prefix. A changed file or target starts over.
+func ImportCheckpointKey(inputPath, profileID, prefix string) (string, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	h := sha256.New()
+	h.Write(data)
+	h.Write([]byte{0})
+	h.Write([]byte(profileID))
+	h.Write([]byte{0})
+	h.Write([]byte(prefix))
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// NewImportCheckpoint returns an empty checkpoint stored in dir under key.
+func NewImportCheckpoint(dir, key string) *ImportCheckpoint {
+	return &ImportCheckpoint{
+		path: filepath.Join(dir, ".import-checkpoint-"+key+".json"),
+		done: make(map[string]bool),
+	}
+}
+
+// LoadImportCheckpoint reads the checkpoint for key from dir. A missing file yields
+// an empty checkpoint.
+func LoadImportCheckpoint(dir, key string) (*ImportCheckpoint, error) {
+	cp := NewImportCheckpoint(dir, key)
+
+	data, err := os.ReadFile(cp.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import checkpoint: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("failed to parse import checkpoint: %w", err)
+	}
+	for _, id := range cp.Processed {
+		cp.done[id] = true
+	}
+
+	return cp, nil
+}
+
+// Path returns the checkpoint file path.
+func (c *ImportCheckpoint) Path() string {
+	return c.path
+}
+
+// Done reports whether connID was processed.
+func (c *ImportCheckpoint) Done(connID string) bool {
+	return c.done[connID]
+}
+
+// Mark records connID as processed.
+func (c *ImportCheckpoint) Mark(connID string) {
+	if c.done[connID] {
+		return
+	}
+	c.done[connID] = true
+	c.Processed = append(c.Processed, connID)
+}
+
+// Save writes the checkpoint file.
+func (c *ImportCheckpoint) Save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal import checkpoint: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write import checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the checkpoint file, if any.
+func (c *ImportCheckpoint) Remove() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove import checkpoint: %w", err)
+	}
+	return nil
+}
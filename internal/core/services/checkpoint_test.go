@@ -0,0 +1,76 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportCheckpoint_SaveLoadRemove(t *testing.T) {
+	dir := t.TempDir()
+
+	empty, err := LoadImportCheckpoint(dir, "abc")
+	if err != nil {
+		t.Fatalf("LoadImportCheckpoint failed: %v", err)
+	}
+	if empty.Done("a") {
+		t.Error("a missing checkpoint should be empty")
+	}
+
+	cp := NewImportCheckpoint(dir, "abc")
+	cp.Mark("a")
+	cp.Mark("b")
+	cp.Mark("a")
+	if err := cp.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if cp.Path() != filepath.Join(dir, ".import-checkpoint-abc.json") {
+		t.Errorf("unexpected path %s", cp.Path())
+	}
+
+	reloaded, err := LoadImportCheckpoint(dir, "abc")
+	if err != nil {
+		t.Fatalf("LoadImportCheckpoint failed: %v", err)
+	}
+	if !reloaded.Done("a") || !reloaded.Done("b") || reloaded.Done("c") {
+		t.Errorf("unexpected processed IDs: %v", reloaded.Processed)
+	}
+	if len(reloaded.Processed) != 2 {
+		t.Errorf("duplicates recorded: %v", reloaded.Processed)
+	}
+
+	if err := reloaded.Remove(); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(cp.Path()); !os.IsNotExist(err) {
+		t.Error("checkpoint file should be removed")
+	}
+	if err := reloaded.Remove(); err != nil {
+		t.Errorf("removing a missing checkpoint should not fail: %v", err)
+	}
+}
+
+func TestImportCheckpointKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.csv")
+	os.WriteFile(path, []byte("conn_id,encrypted_data\n"), 0600)
+
+	key, err := ImportCheckpointKey(path, "p1", "")
+	if err != nil {
+		t.Fatalf("ImportCheckpointKey failed: %v", err)
+	}
+	if again, _ := ImportCheckpointKey(path, "p1", ""); again != key {
+		t.Error("key should be stable")
+	}
+	if other, _ := ImportCheckpointKey(path, "p2", ""); other == key {
+		t.Error("key should depend on the profile")
+	}
+	if other, _ := ImportCheckpointKey(path, "p1", "stg_"); other == key {
+		t.Error("key should depend on the prefix")
+	}
+
+	os.WriteFile(path, []byte("conn_id,encrypted_data\na,b\n"), 0600)
+	if other, _ := ImportCheckpointKey(path, "p1", ""); other == key {
+		t.Error("key should depend on the file content")
+	}
+}
@@ -1,10 +1,17 @@
 package services
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/flevanti/airflow-migrator/internal/core/models"
 )
@@ -15,6 +22,14 @@ var csvHeaders = []string{
 	"encrypted_data",
 }
 
+// DefaultDelimiter separates the CSV columns unless another delimiter is requested.
+const DefaultDelimiter = ','
+
+// ValidDelimiter reports whether r can separate CSV columns.
+func ValidDelimiter(r rune) bool {
+	return r != '"' && r != '\r' && r != '\n' && r != utf8.RuneError && utf8.ValidRune(r) && r != 0
+}
+
 // ConnectionData holds all connection fields to be encrypted as a blob
 type ConnectionData struct {
 	ConnType         string `json:"conn_type"`
@@ -28,10 +43,43 @@ type ConnectionData struct {
 	IsEncrypted      bool   `json:"is_encrypted"`
 	IsExtraEncrypted bool   `json:"is_extra_encrypted"`
 	ExportedAt       string `json:"exported_at"`
+
+	// JSON strings cannot carry invalid UTF-8, so such values are stored base64-encoded
+	// here instead of in Password/Extra
+	PasswordB64 string `json:"password_b64,omitempty"`
+	ExtraB64    string `json:"extra_b64,omitempty"`
+}
+
+// encodeBinary returns s unchanged when it survives JSON, otherwise "" and its base64 form.
+func encodeBinary(s string) (plain, b64 string) {
+	if utf8.ValidString(s) {
+		return s, ""
+	}
+	return "", base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// decodeBinary reverses encodeBinary.
+func decodeBinary(plain, b64 string) (string, error) {
+	if b64 == "" {
+		return plain, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
 }
 
 // WriteEncryptedCSV writes connections to a CSV file with encrypted data.
-func WriteEncryptedCSV(path string, records []*models.ExportRecord, fernet *Fernet) error {
+// A zero delimiter means DefaultDelimiter.
+func WriteEncryptedCSV(path string, records []*models.ExportRecord, fernet *Fernet, delimiter rune) error {
+	if delimiter == 0 {
+		delimiter = DefaultDelimiter
+	}
+	if !ValidDelimiter(delimiter) {
+		return fmt.Errorf("invalid delimiter %q", delimiter)
+	}
+
 	file, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
@@ -39,14 +87,99 @@ func WriteEncryptedCSV(path string, records []*models.ExportRecord, fernet *Fern
 	defer file.Close()
 
 	writer := csv.NewWriter(file)
+	writer.Comma = delimiter
 	defer writer.Flush()
 
-	// Write header
-	if err := writer.Write(csvHeaders); err != nil {
+	// Write header, recording the salt of a passphrase-derived key
+	header := csvHeaders
+	if salt := fernet.Salt(); salt != nil {
+		header = append(slices.Clip(csvHeaders), passphraseSaltColumn(salt))
+	}
+	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
-	// Write records
+	if err := writeExportRows(writer, records, fernet); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// AppendEncryptedCSV adds records to the export file at path, creating it like
+// WriteEncryptedCSV when it does not exist. An existing file keeps its header and
+// delimiter and must be encrypted with the same key, or passphrase and salt, as fernet.
+// Readers keep the last row of each conn_id, so appending a connection again replaces it.
+func AppendEncryptedCSV(path string, records []*models.ExportRecord, fernet *Fernet, delimiter rune) error {
+	header, fileDelimiter, err := readExportHeader(path)
+	if os.IsNotExist(err) || (err == nil && header == nil) {
+		return WriteEncryptedCSV(path, records, fernet, delimiter)
+	}
+	if err != nil {
+		return err
+	}
+	if delimiter != 0 && delimiter != fileDelimiter {
+		return fmt.Errorf("file uses delimiter %q, not %q", fileDelimiter, delimiter)
+	}
+
+	salt, err := parsePassphraseSalt(header)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(salt, fernet.Salt()) {
+		if salt == nil {
+			return ErrNoPassphraseSalt
+		}
+		return fmt.Errorf("file is protected by a passphrase, append with it")
+	}
+	if ok, err := CanDecryptFile(path, fernet); err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("file is encrypted with another key")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Comma = fileDelimiter
+	if err := writeExportRows(writer, records, fernet); err != nil {
+		return err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// readExportHeader returns the header row and delimiter of an export file, or a nil
+// header when the file is empty.
+func readExportHeader(path string) ([]string, rune, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	reader, err := newExportReader(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, reader.Comma, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return header, reader.Comma, nil
+}
+
+// writeExportRows encrypts each record as a blob and writes it as a conn_id row.
+func writeExportRows(writer *csv.Writer, records []*models.ExportRecord, fernet *Fernet) error {
 	for _, r := range records {
 		// Create data blob
 		data := ConnectionData{
@@ -62,6 +195,8 @@ func WriteEncryptedCSV(path string, records []*models.ExportRecord, fernet *Fern
 			IsExtraEncrypted: r.IsExtraEncrypted,
 			ExportedAt:       r.ExportedAt,
 		}
+		data.Password, data.PasswordB64 = encodeBinary(r.Password)
+		data.Extra, data.ExtraB64 = encodeBinary(r.Extra)
 
 		// Serialize to JSON
 		jsonData, err := json.Marshal(data)
@@ -81,10 +216,12 @@ func WriteEncryptedCSV(path string, records []*models.ExportRecord, fernet *Fern
 		}
 	}
 
-	return writer.Error()
+	return nil
 }
 
 // ReadEncryptedCSV reads connections from an encrypted CSV file.
+// The delimiter is detected from the header written by WriteEncryptedCSV. A conn_id
+// appended more than once (see AppendEncryptedCSV) is returned once, from its last row.
 func ReadEncryptedCSV(path string, fernet *Fernet) ([]*models.ExportRecord, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -92,7 +229,10 @@ func ReadEncryptedCSV(path string, fernet *Fernet) ([]*models.ExportRecord, erro
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
+	reader, err := newExportReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
 
 	// Read all rows
 	rows, err := reader.ReadAll()
@@ -125,6 +265,14 @@ func ReadEncryptedCSV(path string, fernet *Fernet) ([]*models.ExportRecord, erro
 		if err := json.Unmarshal([]byte(decrypted), &data); err != nil {
 			return nil, fmt.Errorf("failed to parse connection %s: %w", connID, err)
 		}
+		password, err := decodeBinary(data.Password, data.PasswordB64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode password of %s: %w", connID, err)
+		}
+		extra, err := decodeBinary(data.Extra, data.ExtraB64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode extra of %s: %w", connID, err)
+		}
 
 		records = append(records, &models.ExportRecord{
 			ConnID:           connID,
@@ -133,14 +281,100 @@ func ReadEncryptedCSV(path string, fernet *Fernet) ([]*models.ExportRecord, erro
 			Host:             data.Host,
 			Schema:           data.Schema,
 			Login:            data.Login,
-			Password:         data.Password,
+			Password:         password,
 			Port:             data.Port,
-			Extra:            data.Extra,
+			Extra:            extra,
 			IsEncrypted:      data.IsEncrypted,
 			IsExtraEncrypted: data.IsExtraEncrypted,
 			ExportedAt:       data.ExportedAt,
 		})
 	}
 
-	return records, nil
+	return latestByConnID(records), nil
+}
+
+// latestByConnID drops all but the last record of each conn_id, keeping the order of
+// the remaining ones.
+func latestByConnID(records []*models.ExportRecord) []*models.ExportRecord {
+	last := make(map[string]int, len(records))
+	for i, r := range records {
+		last[r.ConnID] = i
+	}
+	if len(last) == len(records) {
+		return records
+	}
+
+	latest := make([]*models.ExportRecord, 0, len(last))
+	for i, r := range records {
+		if last[r.ConnID] == i {
+			latest = append(latest, r)
+		}
+	}
+	return latest
+}
+
+// CanDecryptFile reports whether fernet decrypts the first record of an export file,
+// without reading the rest of it. A file with no records accepts any key.
+func CanDecryptFile(path string, fernet *Fernet) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := newExportReader(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	// Skip header
+	if _, err := reader.Read(); err == io.EOF {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	row, err := reader.Read()
+	if err == io.EOF {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(row) < 2 {
+		return false, fmt.Errorf("invalid row 2: expected 2 columns")
+	}
+
+	_, err = fernet.DecryptString(row[1])
+	return err == nil, nil
+}
+
+// newExportReader returns a CSV reader for an export file, using the delimiter
+// detected from its header line.
+func newExportReader(r io.Reader) (*csv.Reader, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), buffered))
+	reader.Comma = detectDelimiter(header)
+	// The header has a third column when the file key comes from a passphrase
+	reader.FieldsPerRecord = -1
+	return reader, nil
+}
+
+// detectDelimiter returns the rune following conn_id in an export header line,
+// falling back to DefaultDelimiter.
+func detectDelimiter(header string) rune {
+	rest, ok := strings.CutPrefix(header, csvHeaders[0])
+	if !ok {
+		return DefaultDelimiter
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	if !ValidDelimiter(r) {
+		return DefaultDelimiter
+	}
+	return r
 }
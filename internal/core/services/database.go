@@ -7,28 +7,34 @@ import (
 	"strings"
 
 	"github.com/flevanti/airflow-migrator/internal/core/models"
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq" // PostgreSQL driver
 )
 
 // Database provides operations on Airflow's metadata database.
 type Database struct {
-	db *sql.DB
+	db       *sql.DB
+	tx       *sql.Tx     // Set when bound to a transaction by Begin
+	shape    SchemaShape // Layout of the connection table
+	revision string      // Alembic revision, empty if unknown
 }
 
-// NewDatabase creates a new database connection.
-func NewDatabase(profile *models.Profile) (*Database, error) {
+// NewDatabase creates a new database connection. ctx bounds connecting and
+// detecting the schema, not the use of the returned Database.
+func NewDatabase(ctx context.Context, profile *models.Profile) (*Database, error) {
 	db, err := sql.Open("postgres", profile.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Test connection
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
 
-	return &Database{db: db}, nil
+	d := &Database{db: db}
+	d.shape = d.detectShape(ctx)
+	return d, nil
 }
 
 // Close closes the database connection.
@@ -36,21 +42,228 @@ func (d *Database) Close() error {
 	return d.db.Close()
 }
 
+// Shape returns the detected layout of the connection table.
+func (d *Database) Shape() SchemaShape {
+	return d.shape
+}
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction if one is active, the pool otherwise.
+func (d *Database) conn() querier {
+	if d.tx != nil {
+		return d.tx
+	}
+	return d.db
+}
+
+// isolationLevels maps the isolation level names accepted by ParseIsolationLevel
+var isolationLevels = map[string]sql.IsolationLevel{
+	"read_committed":  sql.LevelReadCommitted,
+	"repeatable_read": sql.LevelRepeatableRead,
+	"serializable":    sql.LevelSerializable,
+}
+
+// ParseIsolationLevel returns the transaction isolation level named by name:
+// read_committed, repeatable_read or serializable. Empty means read_committed.
+func ParseIsolationLevel(name string) (sql.IsolationLevel, error) {
+	if name == "" {
+		return sql.LevelReadCommitted, nil
+	}
+	level, ok := isolationLevels[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown isolation level: %s", name)
+	}
+	return level, nil
+}
+
+// Begin starts a transaction with the driver's default options. See BeginTx.
+func (d *Database) Begin(ctx context.Context) (*Database, error) {
+	return d.BeginTx(ctx, nil)
+}
+
+// BeginTx starts a transaction with opts. The returned Database runs every operation
+// inside it until Commit or Rollback; the receiver keeps using the pool.
+func (d *Database) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Database, error) {
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &Database{db: d.db, tx: tx, shape: d.shape, revision: d.revision}, nil
+}
+
+// InTx reports whether the Database is bound to a transaction.
+func (d *Database) InTx() bool {
+	return d.tx != nil
+}
+
+// Commit commits the transaction started by Begin.
+func (d *Database) Commit() error {
+	if d.tx == nil {
+		return fmt.Errorf("no transaction in progress")
+	}
+	if err := d.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback aborts the transaction started by Begin. It is a no-op after Commit.
+func (d *Database) Rollback() error {
+	if d.tx == nil {
+		return nil
+	}
+	if err := d.tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		return fmt.Errorf("failed to roll back transaction: %w", err)
+	}
+	return nil
+}
+
 // TestConnection tests the database connection.
 func (d *Database) TestConnection(ctx context.Context) error {
 	return d.db.PingContext(ctx)
 }
 
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanConnection scans a row selected with the shape's column list.
+func (d *Database) scanConnection(row rowScanner) (*models.Connection, error) {
+	conn := &models.Connection{}
+	var description, host, schema, login, password, extra sql.NullString
+	var port sql.NullInt32
+	var isEncrypted, isExtraEncrypted sql.NullBool
+
+	dest := []any{&conn.ID, &conn.ConnType}
+	if d.shape.Description {
+		dest = append(dest, &description)
+	}
+	dest = append(dest, &host)
+	if d.shape.Schema {
+		dest = append(dest, &schema)
+	}
+	dest = append(dest, &login, &password, &port, &extra)
+	if d.shape.IsEncrypted {
+		dest = append(dest, &isEncrypted)
+	}
+	if d.shape.IsExtraEncrypted {
+		dest = append(dest, &isExtraEncrypted)
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	conn.Description = description.String
+	conn.Host = host.String
+	conn.Schema = schema.String
+	conn.Login = login.String
+	conn.Password = password.String
+	conn.Port = int(port.Int32)
+	conn.Extra = extra.String
+	conn.IsEncrypted = isEncrypted.Bool
+	conn.IsExtraEncrypted = isExtraEncrypted.Bool
+
+	return conn, nil
+}
+
+// hostURI parses a host column holding a whole connection URI with credentials, e.g.
+// postgres://etl:secret@db:5432/airflow, as some clients store it. Plain hosts and
+// hosts with a protocol only (https://example.com) are not URIs here.
+func hostURI(host string) (*models.Connection, bool) {
+	_, rest, ok := strings.Cut(host, "://")
+	if !ok {
+		return nil, false
+	}
+	if end := strings.IndexAny(rest, "/?"); end >= 0 {
+		rest = rest[:end]
+	}
+	if !strings.Contains(rest, "@") {
+		return nil, false
+	}
+
+	parsed, err := models.ParseConnectionURI("", host)
+	if err != nil {
+		return nil, false
+	}
+	return parsed, true
+}
+
+// ExpandHostURI moves the parts of a URI stored in the host column into their own
+// fields and reports whether it did. Values already in their columns win; a password
+// from the URI is plaintext. Connections are read as stored, so callers opt in to it
+// and must not write the result back.
+func ExpandHostURI(conn *models.Connection) bool {
+	parsed, ok := hostURI(conn.Host)
+	if !ok {
+		return false
+	}
+
+	conn.Host = parsed.Host
+	if conn.Login == "" {
+		conn.Login = parsed.Login
+	}
+	if conn.Password == "" && parsed.Password != "" {
+		conn.Password = parsed.Password
+		conn.IsEncrypted = false
+	}
+	if conn.Port == 0 {
+		conn.Port = parsed.Port
+	}
+	if conn.Schema == "" {
+		conn.Schema = parsed.Schema
+	}
+	if conn.Extra == "" && parsed.Extra != "" {
+		conn.Extra = parsed.Extra
+		conn.IsExtraEncrypted = false
+	}
+	return true
+}
+
+// connectionValues returns the values for the shape's column list, in the same order.
+func (d *Database) connectionValues(conn *models.Connection) []any {
+	values := []any{conn.ID, conn.ConnType}
+	if d.shape.Description {
+		values = append(values, nullString(conn.Description))
+	}
+	values = append(values, nullString(conn.Host))
+	if d.shape.Schema {
+		values = append(values, nullString(conn.Schema))
+	}
+	values = append(values,
+		nullString(conn.Login),
+		nullString(conn.Password),
+		nullInt(conn.Port),
+		nullString(conn.Extra),
+	)
+	if d.shape.IsEncrypted {
+		values = append(values, conn.IsEncrypted)
+	}
+	if d.shape.IsExtraEncrypted {
+		values = append(values, conn.IsExtraEncrypted)
+	}
+	return values
+}
+
 // ListConnections retrieves all connections from the Airflow database.
 func (d *Database) ListConnections(ctx context.Context) ([]*models.Connection, error) {
-	query := `
-		SELECT conn_id, conn_type, description, host, schema, login, password, port, extra, 
-		       is_encrypted, is_extra_encrypted
-		FROM connection
-		ORDER BY conn_id
-	`
-
-	rows, err := d.db.QueryContext(ctx, query)
+	return d.ListConnectionsFiltered(ctx, models.ConnectionFilter{})
+}
+
+// ListConnectionsFiltered retrieves the connections matching filter, which is applied
+// in the query with placeholders.
+func (d *Database) ListConnectionsFiltered(ctx context.Context, filter models.ConnectionFilter) ([]*models.Connection, error) {
+	where, args := d.filterClause(filter)
+	query := fmt.Sprintf("SELECT %s FROM connection%s ORDER BY conn_id", d.shape.selectList(), where)
+
+	rows, err := d.conn().QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query connections: %w", err)
 	}
@@ -58,67 +271,119 @@ func (d *Database) ListConnections(ctx context.Context) ([]*models.Connection, e
 
 	var connections []*models.Connection
 	for rows.Next() {
-		conn := &models.Connection{}
-		var description, host, schema, login, password, extra sql.NullString
-		var port sql.NullInt32
-		var isEncrypted, isExtraEncrypted sql.NullBool
-
-		err := rows.Scan(
-			&conn.ID,
-			&conn.ConnType,
-			&description,
-			&host,
-			&schema,
-			&login,
-			&password,
-			&port,
-			&extra,
-			&isEncrypted,
-			&isExtraEncrypted,
-		)
+		conn, err := d.scanConnection(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
-
-		conn.Description = description.String
-		conn.Host = host.String
-		conn.Schema = schema.String
-		conn.Login = login.String
-		conn.Password = password.String
-		conn.Port = int(port.Int32)
-		conn.Extra = extra.String
-		conn.IsEncrypted = isEncrypted.Bool
-		conn.IsExtraEncrypted = isExtraEncrypted.Bool
-
 		connections = append(connections, conn)
 	}
 
 	return connections, rows.Err()
 }
 
+// filterClause returns the WHERE clause for filter, empty when it has no filters, and
+// its arguments.
+func (d *Database) filterClause(filter models.ConnectionFilter) (string, []any) {
+	var conditions []string
+	var args []any
+	add := func(condition, arg string) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ConnType != "" {
+		add("conn_type = $%d", filter.ConnType)
+	}
+	if filter.Host != "" {
+		add("lower(host) = lower($%d)", filter.Host)
+	}
+	if filter.Login != "" {
+		add("login = $%d", filter.Login)
+	}
+	if filter.Search != "" {
+		search := "conn_id ILIKE $%[1]d"
+		if d.shape.Description {
+			search = "(conn_id ILIKE $%[1]d OR description ILIKE $%[1]d)"
+		}
+		add(search, "%"+escapeLike(filter.Search)+"%")
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ListConnectionMeta retrieves the identifying fields of all connections, leaving
+// secrets in the database.
+func (d *Database) ListConnectionMeta(ctx context.Context) ([]models.ConnectionMeta, error) {
+	// Only whether a password is set is read, not the password itself
+	columns := "conn_id, conn_type, host, port, COALESCE(password, '') <> ''"
+	if d.shape.IsEncrypted {
+		columns += ", COALESCE(is_encrypted, false)"
+	}
+	if d.shape.Description {
+		columns += ", description"
+	}
+	query := fmt.Sprintf("SELECT %s FROM connection ORDER BY conn_id", columns)
+
+	rows, err := d.conn().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query connections: %w", err)
+	}
+	defer rows.Close()
+
+	var metas []models.ConnectionMeta
+	for rows.Next() {
+		var meta models.ConnectionMeta
+		var host, description sql.NullString
+		var port sql.NullInt32
+
+		dest := []any{&meta.ID, &meta.ConnType, &host, &port, &meta.HasPassword}
+		if d.shape.IsEncrypted {
+			dest = append(dest, &meta.IsEncrypted)
+		}
+		if d.shape.Description {
+			dest = append(dest, &description)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		meta.Host = host.String
+		meta.Port = int(port.Int32)
+		// A URI in the host column would show its password in selection lists
+		if parsed, ok := hostURI(meta.Host); ok {
+			meta.Host = parsed.Host
+			if meta.Port == 0 {
+				meta.Port = parsed.Port
+			}
+			if !meta.HasPassword && parsed.Password != "" {
+				meta.HasPassword = true
+				meta.IsEncrypted = false
+			}
+		}
+		meta.Description = description.String
+		metas = append(metas, meta)
+	}
+
+	return metas, rows.Err()
+}
+
+// CountConnections returns the number of connections in the database.
+func (d *Database) CountConnections(ctx context.Context) (int, error) {
+	var count int
+	if err := d.conn().QueryRowContext(ctx, "SELECT COUNT(*) FROM connection").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count connections: %w", err)
+	}
+	return count, nil
+}
+
 // GetConnection retrieves a single connection by ID.
 func (d *Database) GetConnection(ctx context.Context, connID string) (*models.Connection, error) {
-	query := `
-		SELECT conn_id, conn_type, description, host, schema, login, password, port, extra
-		FROM connection
-		WHERE conn_id = $1
-	`
+	query := fmt.Sprintf("SELECT %s FROM connection WHERE conn_id = $1", d.shape.selectList())
 
-	conn := &models.Connection{}
-	var description, host, schema, login, password, extra sql.NullString
-	var port sql.NullInt32
-
-	err := d.db.QueryRowContext(ctx, query, connID).Scan(
-		&conn.ID,
-		&conn.ConnType,
-		&description,
-		&host,
-		&schema,
-		&login,
-		&password,
-		&port,
-		&extra,
-	)
+	conn, err := d.scanConnection(d.conn().QueryRowContext(ctx, query, connID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -126,47 +391,37 @@ func (d *Database) GetConnection(ctx context.Context, connID string) (*models.Co
 		return nil, fmt.Errorf("failed to get connection: %w", err)
 	}
 
-	conn.Description = description.String
-	conn.Host = host.String
-	conn.Schema = schema.String
-	conn.Login = login.String
-	conn.Password = password.String
-	conn.Port = int(port.Int32)
-	conn.Extra = extra.String
-
 	return conn, nil
 }
 
 // ConnectionExists checks if a connection ID exists.
 func (d *Database) ConnectionExists(ctx context.Context, connID string) (bool, error) {
 	var exists bool
-	err := d.db.QueryRowContext(ctx,
+	err := d.conn().QueryRowContext(ctx,
 		"SELECT EXISTS(SELECT 1 FROM connection WHERE conn_id = $1)",
 		connID,
 	).Scan(&exists)
 	return exists, err
 }
 
+// insertStatement returns the INSERT for the shape's columns, taking connectionValues.
+func (d *Database) insertStatement() string {
+	columns := d.shape.columns()
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO connection (%s) VALUES (%s)",
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+	)
+}
+
 // InsertConnection inserts a new connection.
 func (d *Database) InsertConnection(ctx context.Context, conn *models.Connection) error {
-	query := `
-		INSERT INTO connection (conn_id, conn_type, description, host, schema, login, password, port, extra, is_encrypted, is_extra_encrypted)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-	`
-
-	_, err := d.db.ExecContext(ctx, query,
-		conn.ID,
-		conn.ConnType,
-		nullString(conn.Description),
-		nullString(conn.Host),
-		nullString(conn.Schema),
-		nullString(conn.Login),
-		nullString(conn.Password),
-		nullInt(conn.Port),
-		nullString(conn.Extra),
-		conn.IsEncrypted,
-		conn.IsExtraEncrypted,
-	)
+	_, err := d.conn().ExecContext(ctx, d.insertStatement(), d.connectionValues(conn)...)
 	if err != nil {
 		return fmt.Errorf("failed to insert connection: %w", err)
 	}
@@ -174,29 +429,131 @@ func (d *Database) InsertConnection(ctx context.Context, conn *models.Connection
 	return nil
 }
 
+// InsertConnectionBatch inserts conns with a single multi-row INSERT and returns the
+// conn_ids inserted. With skipExisting, connections whose conn_id already exists are
+// left alone and missing from the result. In a transaction the statement runs under a
+// savepoint, so a failed batch leaves the transaction usable for a per-row retry.
+func (d *Database) InsertConnectionBatch(ctx context.Context, conns []*models.Connection, skipExisting bool) (map[string]bool, error) {
+	if len(conns) == 0 {
+		return nil, nil
+	}
+
+	columns := d.shape.columns()
+	rows := make([]string, len(conns))
+	values := make([]any, 0, len(conns)*len(columns))
+	for i, conn := range conns {
+		placeholders := make([]string, len(columns))
+		for j := range columns {
+			placeholders[j] = fmt.Sprintf("$%d", i*len(columns)+j+1)
+		}
+		rows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		values = append(values, d.connectionValues(conn)...)
+	}
+
+	query := fmt.Sprintf("INSERT INTO connection (%s) VALUES %s", strings.Join(columns, ", "), strings.Join(rows, ", "))
+	if skipExisting {
+		query += " ON CONFLICT (conn_id) DO NOTHING"
+	}
+	query += " RETURNING conn_id"
+
+	if d.InTx() {
+		if _, err := d.tx.ExecContext(ctx, "SAVEPOINT insert_batch"); err != nil {
+			return nil, fmt.Errorf("failed to insert connections: %w", err)
+		}
+	}
+
+	inserted, err := d.queryInsertedIDs(ctx, query, values)
+	if d.InTx() {
+		release := "RELEASE SAVEPOINT insert_batch"
+		if err != nil {
+			release = "ROLLBACK TO SAVEPOINT insert_batch"
+		}
+		if _, releaseErr := d.tx.ExecContext(ctx, release); releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert connections: %w", err)
+	}
+
+	return inserted, nil
+}
+
+// queryInsertedIDs runs an INSERT ... RETURNING conn_id and collects the IDs.
+func (d *Database) queryInsertedIDs(ctx context.Context, query string, values []any) (map[string]bool, error) {
+	rows, err := d.conn().QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inserted := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		inserted[id] = true
+	}
+	return inserted, rows.Err()
+}
+
+// InsertConnectionIfAbsent inserts conn unless its conn_id already exists and reports
+// whether it was inserted. The check and insert are one statement, so a connection
+// created concurrently is left alone instead of failing with a duplicate key.
+func (d *Database) InsertConnectionIfAbsent(ctx context.Context, conn *models.Connection) (bool, error) {
+	query := d.insertStatement() + " ON CONFLICT (conn_id) DO NOTHING"
+
+	result, err := d.conn().ExecContext(ctx, query, d.connectionValues(conn)...)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert connection: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// UpsertConnection inserts conn or, when its conn_id already exists, updates it in
+// the same statement. It reports whether the connection was inserted.
+func (d *Database) UpsertConnection(ctx context.Context, conn *models.Connection) (bool, error) {
+	// conn_id is the conflict target, the remaining columns take the new values
+	columns := d.shape.columns()
+	assignments := make([]string, 0, len(columns)-1)
+	for _, col := range columns[1:] {
+		assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+	}
+
+	// xmax is 0 on a freshly inserted row and set on an updated one
+	query := fmt.Sprintf(
+		"%s ON CONFLICT (conn_id) DO UPDATE SET %s RETURNING (xmax = 0)",
+		d.insertStatement(),
+		strings.Join(assignments, ", "),
+	)
+
+	var inserted bool
+	err := d.conn().QueryRowContext(ctx, query, d.connectionValues(conn)...).Scan(&inserted)
+	if err != nil {
+		return false, fmt.Errorf("failed to upsert connection: %w", err)
+	}
+
+	return inserted, nil
+}
+
 // UpdateConnection updates an existing connection.
 func (d *Database) UpdateConnection(ctx context.Context, conn *models.Connection) error {
-	query := `
-		UPDATE connection
-		SET conn_type = $2, description = $3, host = $4, schema = $5,
-		    login = $6, password = $7, port = $8, extra = $9,
-		    is_encrypted = $10, is_extra_encrypted = $11
-		WHERE conn_id = $1
-	`
-
-	result, err := d.db.ExecContext(ctx, query,
-		conn.ID,
-		conn.ConnType,
-		nullString(conn.Description),
-		nullString(conn.Host),
-		nullString(conn.Schema),
-		nullString(conn.Login),
-		nullString(conn.Password),
-		nullInt(conn.Port),
-		nullString(conn.Extra),
-		conn.IsEncrypted,
-		conn.IsExtraEncrypted,
+	// conn_id is $1 and used in WHERE, the remaining columns are SET
+	columns := d.shape.columns()
+	assignments := make([]string, 0, len(columns)-1)
+	for i, col := range columns[1:] {
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", col, i+2))
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE connection SET %s WHERE conn_id = $1",
+		strings.Join(assignments, ", "),
 	)
+
+	result, err := d.conn().ExecContext(ctx, query, d.connectionValues(conn)...)
 	if err != nil {
 		return fmt.Errorf("failed to update connection: %w", err)
 	}
@@ -209,9 +566,38 @@ func (d *Database) UpdateConnection(ctx context.Context, conn *models.Connection
 	return nil
 }
 
+// ReplaceConnection overwrites the connection stored as oldID with conn, conn_id
+// included, in a single statement: when it fails the old row is left as it was.
+func (d *Database) ReplaceConnection(ctx context.Context, oldID string, conn *models.Connection) error {
+	// conn_id is $1 and SET with the other columns, oldID follows them in WHERE
+	columns := d.shape.columns()
+	assignments := make([]string, 0, len(columns))
+	for i, col := range columns {
+		assignments = append(assignments, fmt.Sprintf("%s = $%d", col, i+1))
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE connection SET %s WHERE conn_id = $%d",
+		strings.Join(assignments, ", "), len(columns)+1,
+	)
+
+	args := append(d.connectionValues(conn), oldID)
+	result, err := d.conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to replace connection: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("connection not found: %s", oldID)
+	}
+
+	return nil
+}
+
 // DeleteConnection deletes a connection by ID.
 func (d *Database) DeleteConnection(ctx context.Context, connID string) error {
-	result, err := d.db.ExecContext(ctx, "DELETE FROM connection WHERE conn_id = $1", connID)
+	result, err := d.conn().ExecContext(ctx, "DELETE FROM connection WHERE conn_id = $1", connID)
 	if err != nil {
 		return fmt.Errorf("failed to delete connection: %w", err)
 	}
@@ -224,26 +610,57 @@ func (d *Database) DeleteConnection(ctx context.Context, connID string) error {
 	return nil
 }
 
-// GetExistingConnectionIDs returns IDs that already exist from a given list.
-func (d *Database) GetExistingConnectionIDs(ctx context.Context, ids []string) ([]string, error) {
-	if len(ids) == 0 {
-		return nil, nil
+// DeleteAllConnections deletes every connection, or only those whose conn_id starts
+// with prefix when it is not empty. It returns the number of deleted connections.
+func (d *Database) DeleteAllConnections(ctx context.Context, prefix string) (int64, error) {
+	query := "DELETE FROM connection"
+	var args []any
+	if prefix != "" {
+		query += ` WHERE conn_id LIKE $1 ESCAPE '\'`
+		args = append(args, escapeLike(prefix)+"%")
 	}
 
-	// Build placeholder string: $1, $2, $3...
-	placeholders := make([]string, len(ids))
-	args := make([]interface{}, len(ids))
-	for i, id := range ids {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-		args[i] = id
+	result, err := d.conn().ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete connections: %w", err)
 	}
 
-	query := fmt.Sprintf(
-		"SELECT conn_id FROM connection WHERE conn_id IN (%s)",
-		strings.Join(placeholders, ", "),
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+// DeleteConnectionsByPrefix deletes the connections whose conn_id starts with prefix
+// in a single statement and returns their IDs.
+func (d *Database) DeleteConnectionsByPrefix(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		`DELETE FROM connection WHERE conn_id LIKE $1 ESCAPE '\' RETURNING conn_id`,
+		escapeLike(prefix)+"%",
 	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete connections: %w", err)
+	}
+	defer rows.Close()
 
-	rows, err := d.db.QueryContext(ctx, query, args...)
+	var deleted []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, id)
+	}
+
+	return deleted, rows.Err()
+}
+
+// GetExistingConnectionIDs returns IDs that already exist from a given list.
+// The list is bound as a single array parameter, so its size is not limited by
+// the number of query parameters Postgres accepts.
+func (d *Database) GetExistingConnectionIDs(ctx context.Context, ids []string) ([]string, error) {
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT conn_id FROM connection WHERE conn_id = ANY($1)",
+		pq.Array(ids),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -261,6 +678,42 @@ func (d *Database) GetExistingConnectionIDs(ctx context.Context, ids []string) (
 	return existing, rows.Err()
 }
 
+// GetCaseInsensitiveMatches returns, for each ID that matches an existing conn_id
+// ignoring case, the existing conn_id keyed by the lowercased ID.
+func (d *Database) GetCaseInsensitiveMatches(ctx context.Context, ids []string) (map[string]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := d.conn().QueryContext(ctx,
+		"SELECT conn_id FROM connection WHERE LOWER(conn_id) IN (SELECT LOWER(id) FROM unnest($1::text[]) AS id) ORDER BY conn_id",
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := make(map[string]string)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(id)
+		if _, ok := matches[key]; !ok {
+			matches[key] = id
+		}
+	}
+
+	return matches, rows.Err()
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
 // Helper functions for nullable fields
 func nullString(s string) sql.NullString {
 	if s == "" {
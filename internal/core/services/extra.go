@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// NormalizeExtra returns a connection's extra JSON in canonical form: minified, with
+// object keys sorted and numbers kept as written, so equal extras are byte-equal.
+// Extras that are empty or not valid JSON are returned unchanged.
+func NormalizeExtra(extra string) (string, bool) {
+	if strings.TrimSpace(extra) == "" {
+		return extra, false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(extra))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return extra, false
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return extra, false // Trailing data after the JSON value
+	}
+
+	normalized, err := encodeExtra(doc)
+	if err != nil || normalized == extra {
+		return extra, false
+	}
+	return normalized, true
+}
+
+// RewriteExtra replaces values in a connection's extra JSON. Keys of rewrites are
+// top-level keys or dot-separated paths into nested objects (e.g. "keyfile_dict.project_id").
+// Only keys already present are replaced. Extras that are not a JSON object are returned
+// unchanged, as is the original text when nothing matched.
+func RewriteExtra(extra string, rewrites map[string]string) (string, bool) {
+	if len(rewrites) == 0 || strings.TrimSpace(extra) == "" {
+		return extra, false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(extra))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return extra, false
+	}
+
+	changed := false
+	for path, value := range rewrites {
+		if setPath(doc, strings.Split(path, "."), value) {
+			changed = true
+		}
+	}
+	if !changed {
+		return extra, false
+	}
+
+	rewritten, err := encodeExtra(doc)
+	if err != nil {
+		return extra, false
+	}
+	return rewritten, true
+}
+
+// MergeExtra deep-merges an imported extra into the target's existing extra: keys of
+// imported win, keys only in target are kept and nested objects are merged the same way.
+// An empty imported extra keeps target. When either is not a JSON object, imported is
+// returned unchanged and false.
+func MergeExtra(target, imported string) (string, bool) {
+	if strings.TrimSpace(target) == "" {
+		return imported, false
+	}
+	base, ok := decodeExtraObject(target)
+	if !ok {
+		return imported, false
+	}
+	if strings.TrimSpace(imported) == "" {
+		return target, true
+	}
+	overlay, ok := decodeExtraObject(imported)
+	if !ok {
+		return imported, false
+	}
+
+	merged, err := encodeExtra(mergeObjects(base, overlay))
+	if err != nil {
+		return imported, false
+	}
+	return merged, true
+}
+
+// decodeExtraObject decodes an extra that is a JSON object, keeping numbers as written.
+func decodeExtraObject(extra string) (map[string]any, bool) {
+	decoder := json.NewDecoder(strings.NewReader(extra))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil || doc == nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// mergeObjects sets every key of overlay on base, merging objects found in both.
+func mergeObjects(base, overlay map[string]any) map[string]any {
+	for key, value := range overlay {
+		nestedOverlay, overlayIsObject := value.(map[string]any)
+		nestedBase, baseIsObject := base[key].(map[string]any)
+		if overlayIsObject && baseIsObject {
+			base[key] = mergeObjects(nestedBase, nestedOverlay)
+			continue
+		}
+		base[key] = value
+	}
+	return base
+}
+
+// encodeExtra marshals an extra document without escaping HTML characters,
+// which Airflow stores as they are.
+func encodeExtra(doc any) (string, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// setPath sets an existing key at the end of path, reporting whether it was found.
+func setPath(doc map[string]any, path []string, value string) bool {
+	current, ok := doc[path[0]]
+	if !ok {
+		return false
+	}
+	if len(path) == 1 {
+		doc[path[0]] = value
+		return true
+	}
+	nested, ok := current.(map[string]any)
+	if !ok {
+		return false
+	}
+	return setPath(nested, path[1:], value)
+}
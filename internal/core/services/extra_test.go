@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRewriteExtra(t *testing.T) {
+	rewrites := map[string]string{
+		"project":                  "new-project",
+		"keyfile_dict.project_id":  "new-project",
+		"missing":                  "ignored",
+		"region.not_an_object.key": "ignored",
+	}
+
+	extra := `{"project": "old-project", "region": "eu", "timeout": 30, "keyfile_dict": {"project_id": "old-project", "type": "service_account"}}`
+	got, changed := RewriteExtra(extra, rewrites)
+	if !changed {
+		t.Fatal("RewriteExtra() should report a change")
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(got), &doc); err != nil {
+		t.Fatalf("rewritten extra is not JSON: %v", err)
+	}
+	if doc["project"] != "new-project" {
+		t.Errorf("project = %v, want new-project", doc["project"])
+	}
+	keyfile := doc["keyfile_dict"].(map[string]any)
+	if keyfile["project_id"] != "new-project" || keyfile["type"] != "service_account" {
+		t.Errorf("keyfile_dict = %v", keyfile)
+	}
+	if doc["region"] != "eu" || doc["timeout"] != float64(30) {
+		t.Errorf("untouched keys changed: %v", doc)
+	}
+	if _, ok := doc["missing"]; ok {
+		t.Error("missing keys should not be added")
+	}
+}
+
+func TestRewriteExtra_Unchanged(t *testing.T) {
+	rewrites := map[string]string{"project": "new-project"}
+
+	tests := []struct {
+		name  string
+		extra string
+	}{
+		{"empty", ""},
+		{"not JSON", "project=old"},
+		{"JSON array", `["project"]`},
+		{"no matching key", `{"region": "eu"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := RewriteExtra(tt.extra, rewrites)
+			if changed || got != tt.extra {
+				t.Errorf("RewriteExtra(%q) = %q, %v; want unchanged", tt.extra, got, changed)
+			}
+		})
+	}
+}
+
+func TestRewriteExtra_PreservesNumbers(t *testing.T) {
+	got, _ := RewriteExtra(`{"project": "a", "account": 123456789012345678, "url": "a&b"}`, map[string]string{"project": "b"})
+	want := `{"account":123456789012345678,"project":"b","url":"a&b"}`
+	if got != want {
+		t.Errorf("RewriteExtra() = %s, want %s", got, want)
+	}
+}
+
+func TestNormalizeExtra(t *testing.T) {
+	pretty := "{\n  \"b\": 1.50,\n  \"a\": {\"z\": true, \"y\": [1, 2]},\n  \"url\": \"https://x?a=1&b=<2>\"\n}"
+	want := `{"a":{"y":[1,2],"z":true},"b":1.50,"url":"https://x?a=1&b=<2>"}`
+
+	got, changed := NormalizeExtra(pretty)
+	if !changed || got != want {
+		t.Errorf("NormalizeExtra() = %q, %v; want %q, true", got, changed, want)
+	}
+
+	// Already canonical, empty or invalid extras are left untouched
+	for _, extra := range []string{want, "", "  ", "not json", `{"a": 1} trailing`, `{"a": 1`} {
+		if got, changed := NormalizeExtra(extra); changed || got != extra {
+			t.Errorf("NormalizeExtra(%q) = %q, %v; want it unchanged", extra, got, changed)
+		}
+	}
+}
+
+func TestMergeExtra(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		imported   string
+		want       string
+		wantMerged bool
+	}{
+		{
+			"target-only keys are kept",
+			`{"last_checked": "2024-01-01", "timeout": 10}`,
+			`{"timeout": 30}`,
+			`{"last_checked":"2024-01-01","timeout":30}`,
+			true,
+		},
+		{
+			"nested objects are merged",
+			`{"keyfile_dict": {"project_id": "old", "client_email": "a@b"}}`,
+			`{"keyfile_dict": {"project_id": "new"}}`,
+			`{"keyfile_dict":{"client_email":"a@b","project_id":"new"}}`,
+			true,
+		},
+		{
+			"a value replaces an object",
+			`{"auth": {"user": "x"}}`,
+			`{"auth": "token"}`,
+			`{"auth":"token"}`,
+			true,
+		},
+		{"empty import keeps the target", `{"a": 1}`, "", `{"a": 1}`, true},
+		{"empty target", "", `{"a": 1}`, `{"a": 1}`, false},
+		{"target not an object", `[1, 2]`, `{"a": 1}`, `{"a": 1}`, false},
+		{"import not JSON", `{"a": 1}`, `not json`, `not json`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, merged := MergeExtra(tt.target, tt.imported)
+			if got != tt.want || merged != tt.wantMerged {
+				t.Errorf("MergeExtra() = %q, %v, want %q, %v", got, merged, tt.want, tt.wantMerged)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@
 package services
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 
 	"github.com/fernet/fernet-go"
 )
@@ -14,7 +17,8 @@ var (
 
 // Fernet provides Python-compatible Fernet encryption/decryption.
 type Fernet struct {
-	key *fernet.Key
+	key  *fernet.Key
+	salt []byte // Set when the key is derived from a passphrase
 }
 
 // NewFernet creates a Fernet instance from a base64-encoded key.
@@ -73,3 +77,86 @@ func ValidateKey(key string) bool {
 	_, err := NewFernet(key)
 	return err == nil
 }
+
+// Key encodings reported by InspectKey
+const (
+	KeyEncodingURLSafe  = "urlsafe_base64"
+	KeyEncodingStandard = "base64"
+	KeyEncodingUnpadded = "urlsafe_base64_unpadded"
+	KeyEncodingHex      = "hex"
+)
+
+// KeyInfo describes how a Fernet key is encoded.
+type KeyInfo struct {
+	Encoding string `json:"encoding"` // One of the KeyEncoding constants
+	Length   int    `json:"length"`   // Decoded length in bytes
+
+	// URLSafe reports whether the key uses the url-safe base64 alphabet
+	URLSafe bool `json:"url_safe"`
+
+	// AirflowCompatible reports whether Airflow (Python's cryptography) accepts the
+	// key: padded url-safe base64 of 32 bytes
+	AirflowCompatible bool `json:"airflow_compatible"`
+
+	// Warning explains why a key that works here is rejected by Airflow
+	Warning string `json:"warning,omitempty"`
+}
+
+// InspectKey reports the encoding and decoded length of a Fernet key. Keys this tool
+// accepts but Airflow does not, standard base64 and hex, are returned with a Warning;
+// keys that do not decode to 32 bytes are an error.
+func InspectKey(key string) (KeyInfo, error) {
+	var info KeyInfo
+	if key == "" {
+		return info, ErrInvalidFernetKey
+	}
+
+	var decoded []byte
+	var err error
+	switch {
+	case len(key) == hex.EncodedLen(32) && isHex(key):
+		info.Encoding = KeyEncodingHex
+		decoded, _ = hex.DecodeString(key)
+	default:
+		// The url-safe alphabet goes first, so keys without + / - _ count as url-safe
+		if decoded, err = base64.URLEncoding.DecodeString(key); err == nil {
+			info.Encoding = KeyEncodingURLSafe
+			info.URLSafe = true
+		} else if decoded, err = base64.StdEncoding.DecodeString(key); err == nil {
+			info.Encoding = KeyEncodingStandard
+		} else if decoded, err = base64.RawURLEncoding.DecodeString(key); err == nil {
+			info.Encoding = KeyEncodingUnpadded
+			info.URLSafe = true
+		} else {
+			return info, ErrInvalidFernetKey
+		}
+	}
+	info.Length = len(decoded)
+
+	if info.Length != 32 {
+		return info, fmt.Errorf("%w, decodes to %d bytes", ErrInvalidFernetKey, info.Length)
+	}
+
+	switch info.Encoding {
+	case KeyEncodingURLSafe:
+		info.AirflowCompatible = true
+	case KeyEncodingStandard:
+		info.Warning = "key uses standard base64 (+ and /), Airflow expects url-safe base64 (- and _)"
+	case KeyEncodingHex:
+		info.Warning = "key is hex encoded, Airflow expects url-safe base64"
+	case KeyEncodingUnpadded:
+		// fernet-go does not accept unpadded keys either
+		return info, fmt.Errorf("%w, missing base64 padding (=)", ErrInvalidFernetKey)
+	}
+	return info, nil
+}
+
+// isHex reports whether s only holds hex digits.
+func isHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
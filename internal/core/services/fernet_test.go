@@ -2,6 +2,9 @@ package services
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
 	"testing"
 )
 
@@ -48,6 +51,55 @@ func TestFernet_ValidateKey(t *testing.T) {
 	}
 }
 
+func TestInspectKey(t *testing.T) {
+	// The same 32 bytes, chosen to contain characters that differ between alphabets
+	raw := make([]byte, 32)
+	for i := range raw {
+		raw[i] = byte(0xfb + i)
+	}
+	urlSafe := base64.URLEncoding.EncodeToString(raw)
+	standard := base64.StdEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name       string
+		key        string
+		encoding   string
+		compatible bool
+		warning    bool
+		wantErr    bool
+	}{
+		{"canonical", urlSafe, KeyEncodingURLSafe, true, false, false},
+		{"standard base64", standard, KeyEncodingStandard, false, true, false},
+		{"hex", hex.EncodeToString(raw), KeyEncodingHex, false, true, false},
+		{"unpadded", strings.TrimRight(urlSafe, "="), KeyEncodingUnpadded, false, false, true},
+		{"too short", "dGVzdA==", KeyEncodingURLSafe, false, false, true},
+		{"not base64", "not-valid-base64!!!", "", false, false, true},
+		{"empty", "", "", false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := InspectKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InspectKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if info.Encoding != tt.encoding {
+				t.Errorf("Encoding = %q, want %q", info.Encoding, tt.encoding)
+			}
+			if info.AirflowCompatible != tt.compatible {
+				t.Errorf("AirflowCompatible = %v, want %v", info.AirflowCompatible, tt.compatible)
+			}
+			if (info.Warning != "") != tt.warning {
+				t.Errorf("Warning = %q, want warning %v", info.Warning, tt.warning)
+			}
+			// Every key InspectKey accepts must work as a key here
+			if err == nil && !ValidateKey(tt.key) {
+				t.Errorf("InspectKey accepted a key ValidateKey rejects")
+			}
+		})
+	}
+}
+
 func TestFernet_EncryptDecrypt(t *testing.T) {
 	key, _ := GenerateKey()
 	f, err := NewFernet(key)
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// fileNameTimeFormat is how {{.Timestamp}} prints in a file name template
+const fileNameTimeFormat = "20060102_150405"
+
+// FileNameTime prints as 20060102_150405 in a file name template; its time.Time
+// methods stay available, e.g. {{.Timestamp.Format "2006-01-02"}}.
+type FileNameTime struct {
+	time.Time
+}
+
+func (t FileNameTime) String() string {
+	return t.Format(fileNameTimeFormat)
+}
+
+// FileNameData holds the variables of an export file name template.
+type FileNameData struct {
+	Profile   string       // Profile name
+	Timestamp FileNameTime // Time of the export
+	Count     int          // Connections in the file
+}
+
+// RenderFileName executes a text/template file name with data and makes the result a
+// safe file name: anything but letters, digits, dots, dashes and underscores becomes
+// an underscore, and .csv is added when there is no extension.
+func RenderFileName(tmpl string, data FileNameData) (string, error) {
+	t, err := template.New("filename").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid filename template: %w", err)
+	}
+
+	var name strings.Builder
+	if err := t.Execute(&name, data); err != nil {
+		return "", fmt.Errorf("invalid filename template: %w", err)
+	}
+	return SanitizeFileName(name.String())
+}
+
+// SanitizeFileName replaces the characters of name that are unsafe in a file name
+// and adds .csv when it has no extension. Names left empty are an error.
+func SanitizeFileName(name string) (string, error) {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(name))
+
+	// No hidden files or names made of dots only
+	name = strings.TrimLeft(name, ".")
+	if strings.Trim(name, "_") == "" {
+		return "", fmt.Errorf("filename template gives an empty file name")
+	}
+	if filepath.Ext(name) == "" {
+		name += ".csv"
+	}
+	return name, nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenderFileName(t *testing.T) {
+	data := FileNameData{
+		Profile:   "prod env",
+		Timestamp: FileNameTime{time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)},
+		Count:     12,
+	}
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{"default shape", "airflow_{{.Profile}}_{{.Timestamp}}.csv", "airflow_prod_env_20260304_050607.csv", false},
+		{"formatted date", `{{.Profile}}-conns-{{.Timestamp.Format "2006-01-02"}}`, "prod_env-conns-2026-03-04.csv", false},
+		{"count", "{{.Profile}}_{{.Count}}.enc", "prod_env_12.enc", false},
+		{"path separators", "../{{.Profile}}/x.csv", "_prod_env_x.csv", false},
+		{"empty result", "{{if false}}x{{end}}", "", true},
+		{"unknown variable", "{{.Env}}.csv", "", true},
+		{"bad syntax", "{{.Profile", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderFileName(tt.tmpl, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderFileName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// schemeConnTypes maps a URL scheme in the host to a connection type.
+var schemeConnTypes = map[string]string{
+	"postgres":   models.ConnTypePostgres,
+	"postgresql": models.ConnTypePostgres,
+	"mysql":      models.ConnTypeMySQL,
+	"mssql":      models.ConnTypeMSSQL,
+	"sqlserver":  models.ConnTypeMSSQL,
+	"oracle":     models.ConnTypeOracle,
+	"http":       models.ConnTypeHTTP,
+	"https":      models.ConnTypeHTTP, // Airflow's HTTP hook serves both schemes
+	"ssh":        models.ConnTypeSSH,
+	"ftp":        models.ConnTypeFTP,
+	"sftp":       models.ConnTypeSFTP,
+	"s3":         models.ConnTypeAWS,
+	"s3a":        models.ConnTypeAWS,
+	"gs":         models.ConnTypeGCP,
+	"wasb":       models.ConnTypeAzure,
+	"wasbs":      models.ConnTypeAzure,
+	"abfs":       models.ConnTypeAzure,
+	"abfss":      models.ConnTypeAzure,
+	"smtp":       models.ConnTypeSMTP,
+	"smtps":      models.ConnTypeSMTP,
+}
+
+// hostSuffixConnTypes maps well-known host name suffixes to a connection type.
+var hostSuffixConnTypes = []struct {
+	suffix   string
+	connType string
+}{
+	{".amazonaws.com", models.ConnTypeAWS},
+	{".googleapis.com", models.ConnTypeGCP},
+	{".core.windows.net", models.ConnTypeAzure},
+	{"hooks.slack.com", models.ConnTypeSlack},
+}
+
+// extraKeyConnTypes maps keys that only one connection type puts in its extra.
+var extraKeyConnTypes = []struct {
+	key      string
+	connType string
+}{
+	{"aws_access_key_id", models.ConnTypeAWS},
+	{"role_arn", models.ConnTypeAWS},
+	{"region_name", models.ConnTypeAWS},
+	{"keyfile_dict", models.ConnTypeGCP},
+	{"key_path", models.ConnTypeGCP},
+	{"sslmode", models.ConnTypePostgres},
+}
+
+// portConnTypes maps default ports to a connection type, the weakest hint.
+var portConnTypes = map[int]string{
+	5432: models.ConnTypePostgres,
+	3306: models.ConnTypeMySQL,
+	1433: models.ConnTypeMSSQL,
+	1521: models.ConnTypeOracle,
+	22:   models.ConnTypeSSH,
+	21:   models.ConnTypeFTP,
+	25:   models.ConnTypeSMTP,
+	465:  models.ConnTypeSMTP,
+	587:  models.ConnTypeSMTP,
+	80:   models.ConnTypeHTTP,
+	443:  models.ConnTypeHTTP,
+}
+
+// InferConnType guesses a connection type from the host's URL scheme, well-known
+// host names, extra keys and finally the port. It returns "" when nothing matches.
+func InferConnType(host string, port int, extra string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+
+	if scheme, _, ok := strings.Cut(host, "://"); ok {
+		if connType, ok := schemeConnTypes[scheme]; ok {
+			return connType
+		}
+	}
+
+	hostname := host
+	if _, rest, ok := strings.Cut(hostname, "://"); ok {
+		hostname = rest
+	}
+	hostname, _, _ = strings.Cut(hostname, "/")
+	hostname, _, _ = strings.Cut(hostname, ":")
+	for _, h := range hostSuffixConnTypes {
+		if strings.HasSuffix(hostname, h.suffix) {
+			return h.connType
+		}
+	}
+
+	var fields map[string]any
+	if json.Unmarshal([]byte(extra), &fields) == nil {
+		for _, e := range extraKeyConnTypes {
+			if _, ok := fields[e.key]; ok {
+				return e.connType
+			}
+		}
+	}
+
+	return portConnTypes[port]
+}
@@ -0,0 +1,36 @@
+package services
+
+import "testing"
+
+func TestInferConnType(t *testing.T) {
+	tests := []struct {
+		name  string
+		host  string
+		port  int
+		extra string
+		want  string
+	}{
+		{name: "postgres port", host: "db.internal", port: 5432, want: "postgres"},
+		{name: "mysql port", host: "db.internal", port: 3306, want: "mysql"},
+		{name: "s3 scheme", host: "s3://my-bucket/path", want: "aws"},
+		{name: "scheme case", host: "PostgreSQL://db", want: "postgres"},
+		{name: "https scheme", host: "https://api.example.com", port: 8443, want: "http"},
+		{name: "scheme beats port", host: "sftp://files", port: 22, want: "sftp"},
+		{name: "aws host", host: "mydb.abc123.eu-west-1.rds.amazonaws.com", want: "aws"},
+		{name: "slack webhook", host: "https://hooks.slack.com/services/x", want: "http"},
+		{name: "slack host", host: "hooks.slack.com", want: "slack"},
+		{name: "gcp extra", extra: `{"keyfile_dict": {"project_id": "p"}}`, want: "google_cloud_platform"},
+		{name: "extra beats port", extra: `{"sslmode": "require"}`, port: 443, want: "postgres"},
+		{name: "non-JSON extra falls back to port", extra: "not json", port: 22, want: "ssh"},
+		{name: "unknown scheme falls back to port", host: "jdbc://db", port: 1433, want: "mssql"},
+		{name: "nothing to go on", host: "example.com", port: 9999, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InferConnType(tt.host, tt.port, tt.extra); got != tt.want {
+				t.Errorf("InferConnType(%q, %d, %q) = %q, want %q", tt.host, tt.port, tt.extra, got, tt.want)
+			}
+		})
+	}
+}
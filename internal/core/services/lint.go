@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// databaseConnTypes are connection types that need a host and a schema (database name).
+var databaseConnTypes = map[string]bool{
+	models.ConnTypePostgres: true,
+	models.ConnTypeMySQL:    true,
+	models.ConnTypeMSSQL:    true,
+	models.ConnTypeOracle:   true,
+}
+
+// hostConnTypes are connection types that are unusable without a host.
+var hostConnTypes = map[string]bool{
+	models.ConnTypeHTTP:  true,
+	models.ConnTypeHTTPS: true,
+	models.ConnTypeSSH:   true,
+	models.ConnTypeFTP:   true,
+	models.ConnTypeSFTP:  true,
+	models.ConnTypeSMTP:  true,
+}
+
+// LintConnection returns advisory messages about a connection that is likely malformed.
+// It expects the password and extra to be decrypted. An empty result means no findings.
+func LintConnection(conn *models.Connection) []string {
+	var lints []string
+
+	if conn.ConnType == "" {
+		lints = append(lints, "connection has no conn_type")
+	} else if suggestion := suggestConnType(conn.ConnType); suggestion != "" {
+		lints = append(lints, fmt.Sprintf("unknown conn_type %q, did you mean %s?", conn.ConnType, suggestion))
+	}
+
+	if (databaseConnTypes[conn.ConnType] || hostConnTypes[conn.ConnType]) && conn.Host == "" {
+		lints = append(lints, fmt.Sprintf("%s connection has no host", conn.ConnType))
+	}
+	if databaseConnTypes[conn.ConnType] && conn.Schema == "" {
+		lints = append(lints, fmt.Sprintf("%s connection missing schema", conn.ConnType))
+	}
+
+	if strings.Contains(conn.Host, "://") {
+		lints = append(lints, "host looks like a URI, its parts may belong in schema/login/port/extra")
+	}
+
+	if conn.Port < 0 || conn.Port > 65535 {
+		lints = append(lints, fmt.Sprintf("port %d is out of range", conn.Port))
+	}
+
+	if conn.Extra != "" && !json.Valid([]byte(conn.Extra)) {
+		lints = append(lints, "extra is not valid JSON")
+	}
+
+	return lints
+}
+
+// suggestConnType returns the known connection type connType is most likely a typo of,
+// or "" when it is known or not close to any. Provider types outside the known set
+// (snowflake, redshift, ...) are common, so only near misses are reported.
+func suggestConnType(connType string) string {
+	if models.IsKnownConnType(connType) {
+		return ""
+	}
+	lower := strings.ToLower(connType)
+	// Short types are one edit away from each other too often to allow two edits
+	maxDistance := 2
+	if len(lower) <= 5 {
+		maxDistance = 1
+	}
+	best, bestDistance := "", maxDistance+1
+	for _, known := range models.KnownConnTypes {
+		if d := editDistance(lower, known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+func TestLintConnection(t *testing.T) {
+	tests := []struct {
+		name string
+		conn *models.Connection
+		want []string
+	}{
+		{
+			name: "clean postgres",
+			conn: &models.Connection{ConnType: "postgres", Host: "db", Schema: "airflow", Port: 5432, Extra: `{"sslmode": "require"}`},
+			want: nil,
+		},
+		{
+			name: "http without host",
+			conn: &models.Connection{ConnType: "http"},
+			want: []string{"http connection has no host"},
+		},
+		{
+			name: "postgres without schema",
+			conn: &models.Connection{ConnType: "postgres", Host: "db"},
+			want: []string{"postgres connection missing schema"},
+		},
+		{
+			name: "uri in host and broken extra",
+			conn: &models.Connection{ConnType: "generic", Host: "postgres://user:pw@db/airflow", Extra: "{not json"},
+			want: []string{
+				"host looks like a URI, its parts may belong in schema/login/port/extra",
+				"extra is not valid JSON",
+			},
+		},
+		{
+			name: "missing conn_type",
+			conn: &models.Connection{},
+			want: []string{"connection has no conn_type"},
+		},
+		{
+			name: "misspelled conn_type",
+			conn: &models.Connection{ConnType: "httpss", Host: "example.com"},
+			want: []string{`unknown conn_type "httpss", did you mean https?`},
+		},
+		{
+			name: "provider conn_type outside the known set",
+			conn: &models.Connection{ConnType: "snowflake"},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LintConnection(tt.conn); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LintConnection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// mappedFields are the ExportRecord fields a mapped CSV column can target.
+var mappedFields = []string{
+	"conn_id", "conn_type", "description", "host", "schema", "login", "password", "port", "extra",
+}
+
+// headerAliases are common header names used by other tools, per field.
+var headerAliases = map[string][]string{
+	"conn_id":     {"conn_id", "connection_id", "connid", "id", "name"},
+	"conn_type":   {"conn_type", "connection_type", "type"},
+	"description": {"description", "desc", "comment", "notes"},
+	"host":        {"host", "hostname", "server", "address"},
+	"schema":      {"schema", "database", "db", "dbname"},
+	"login":       {"login", "user", "username", "user_name"},
+	"password":    {"password", "pass", "passwd", "pwd", "secret"},
+	"port":        {"port"},
+	"extra":       {"extra", "extras", "options", "extra_json"},
+}
+
+// DetectColumnMapping maps CSV headers to ExportRecord fields using common header names.
+// The result maps header -> field, as accepted by ReadMappedCSV.
+func DetectColumnMapping(headers []string) map[string]string {
+	mapping := make(map[string]string)
+	claimed := make(map[string]bool)
+
+	for _, field := range mappedFields {
+	aliases:
+		for _, alias := range headerAliases[field] {
+			for _, h := range headers {
+				if !claimed[h] && normalizeHeader(h) == alias {
+					mapping[h] = field
+					claimed[h] = true
+					break aliases
+				}
+			}
+		}
+	}
+
+	return mapping
+}
+
+// ReadMappedCSV reads an unencrypted CSV produced by another tool. mapping maps
+// column headers to ExportRecord fields (conn_id, conn_type, host, ...); when it
+// is empty the mapping is detected from the headers. Non-empty password and extra
+// are flagged as encrypted so they are encrypted with the target key on import.
+func ReadMappedCSV(path string, mapping map[string]string) ([]*models.ExportRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	headers := rows[0]
+	if len(mapping) == 0 {
+		mapping = DetectColumnMapping(headers)
+	}
+
+	// Resolve field -> column index
+	index := make(map[string]int)
+	for i, h := range headers {
+		field, ok := mapping[h]
+		if !ok {
+			continue
+		}
+		if !isMappedField(field) {
+			return nil, fmt.Errorf("column %q mapped to unknown field %q", h, field)
+		}
+		index[field] = i
+	}
+	if _, ok := index["conn_id"]; !ok {
+		return nil, fmt.Errorf("no column mapped to conn_id")
+	}
+	if _, ok := index["conn_type"]; !ok {
+		return nil, fmt.Errorf("no column mapped to conn_type")
+	}
+
+	value := func(row []string, field string) string {
+		i, ok := index[field]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var records []*models.ExportRecord
+	for n, row := range rows[1:] {
+		r := &models.ExportRecord{
+			ConnID:      value(row, "conn_id"),
+			ConnType:    value(row, "conn_type"),
+			Description: value(row, "description"),
+			Host:        value(row, "host"),
+			Schema:      value(row, "schema"),
+			Login:       value(row, "login"),
+			Password:    value(row, "password"),
+			Extra:       value(row, "extra"),
+		}
+		if r.ConnID == "" {
+			return nil, fmt.Errorf("row %d: empty conn_id", n+2)
+		}
+		if port := value(row, "port"); port != "" {
+			r.Port, err = strconv.Atoi(port)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid port %q", n+2, port)
+			}
+		}
+		r.IsEncrypted = r.Password != ""
+		r.IsExtraEncrypted = r.Extra != ""
+
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+// normalizeHeader lowercases a header, dropping the UTF-8 BOM spreadsheets prepend.
+func normalizeHeader(h string) string {
+	h = strings.TrimPrefix(h, "\ufeff")
+	h = strings.ToLower(strings.TrimSpace(h))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(h)
+}
+
+func isMappedField(field string) bool {
+	for _, f := range mappedFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
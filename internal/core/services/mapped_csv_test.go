@@ -0,0 +1,95 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMappedCSV_AutoDetect(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "sheet.csv")
+	content := "Name,Type,Hostname,Port,Database,Username,Password,Notes\n" +
+		"warehouse,postgres,db.internal,5432,dwh,etl,s3cret,Main warehouse\n" +
+		"api,http,api.example.com,,,,,\n"
+	os.WriteFile(csvPath, []byte(content), 0600)
+
+	records, err := ReadMappedCSV(csvPath, nil)
+	if err != nil {
+		t.Fatalf("ReadMappedCSV failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	r := records[0]
+	if r.ConnID != "warehouse" || r.ConnType != "postgres" || r.Host != "db.internal" ||
+		r.Port != 5432 || r.Schema != "dwh" || r.Login != "etl" || r.Password != "s3cret" ||
+		r.Description != "Main warehouse" {
+		t.Errorf("unexpected first record: %+v", r)
+	}
+	if !r.IsEncrypted || r.IsExtraEncrypted {
+		t.Errorf("flags: got IsEncrypted=%v IsExtraEncrypted=%v", r.IsEncrypted, r.IsExtraEncrypted)
+	}
+	if records[1].IsEncrypted {
+		t.Error("record without password should not be flagged encrypted")
+	}
+}
+
+func TestMappedCSV_ExplicitMapping(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "custom.csv")
+	content := "options,kind,key\n" +
+		`"{""region"": ""eu-west-1""}",aws,aws_default` + "\n"
+	os.WriteFile(csvPath, []byte(content), 0600)
+
+	records, err := ReadMappedCSV(csvPath, map[string]string{
+		"key":     "conn_id",
+		"kind":    "conn_type",
+		"options": "extra",
+	})
+	if err != nil {
+		t.Fatalf("ReadMappedCSV failed: %v", err)
+	}
+	if records[0].ConnID != "aws_default" || records[0].Extra != `{"region": "eu-west-1"}` {
+		t.Errorf("unexpected record: %+v", records[0])
+	}
+}
+
+func TestMappedCSV_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		mapping map[string]string
+	}{
+		{"no conn_id column", "type,host\nhttp,localhost\n", nil},
+		{"unknown field", "id,type,x\na,http,1\n", map[string]string{"id": "conn_id", "type": "conn_type", "x": "bogus"}},
+		{"invalid port", "id,type,port\na,http,abc\n", nil},
+		{"empty conn_id", "id,type\n,http\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csvPath := filepath.Join(dir, tt.name+".csv")
+			os.WriteFile(csvPath, []byte(tt.content), 0600)
+			if _, err := ReadMappedCSV(csvPath, tt.mapping); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestMappedCSV_UnicodeWithBOM(t *testing.T) {
+	csvPath := filepath.Join(t.TempDir(), "excel.csv")
+	content := "\ufeffconn_id,conn_type,description\n" +
+		"jp_db,postgres,本番データベース\n"
+	os.WriteFile(csvPath, []byte(content), 0600)
+
+	records, err := ReadMappedCSV(csvPath, nil)
+	if err != nil {
+		t.Fatalf("ReadMappedCSV failed: %v", err)
+	}
+	if records[0].ConnID != "jp_db" || records[0].Description != "本番データベース" {
+		t.Errorf("unexpected record: %+v", records[0])
+	}
+}
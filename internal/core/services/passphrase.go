@@ -0,0 +1,132 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fernet/fernet-go"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// Argon2id parameters for passphrase-derived file keys. Changing them makes
+	// existing passphrase files unreadable, so a new salt prefix must come with it
+	passphraseTime    = 1
+	passphraseMemory  = 64 * 1024 // 64MB
+	passphraseThreads = 4
+
+	// PassphraseSaltLen is the length of the salt generated by NewPassphraseSalt
+	PassphraseSaltLen = 16
+
+	// passphraseSaltPrefix starts the header column holding the base64 salt
+	passphraseSaltPrefix = "argon2id:"
+)
+
+var ErrNoPassphraseSalt = errors.New("file is not protected by a passphrase, use its file key")
+
+// NewPassphraseSalt returns a random salt for NewFernetFromPassphrase.
+func NewPassphraseSalt() ([]byte, error) {
+	salt := make([]byte, PassphraseSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// NewFernetFromPassphrase creates a Fernet instance whose key is derived from a
+// passphrase with Argon2id. Files written with it carry the salt in their header,
+// so the same passphrase opens them again (see FileFernet).
+func NewFernetFromPassphrase(passphrase string, salt []byte) (*Fernet, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required")
+	}
+	if len(salt) < 8 {
+		return nil, errors.New("passphrase salt must be at least 8 bytes")
+	}
+
+	var key fernet.Key
+	copy(key[:], argon2.IDKey([]byte(passphrase), salt, passphraseTime, passphraseMemory, passphraseThreads, uint32(len(key))))
+	return &Fernet{key: &key, salt: salt}, nil
+}
+
+// Salt returns the passphrase salt of a Fernet made by NewFernetFromPassphrase, or nil.
+func (f *Fernet) Salt() []byte {
+	return f.salt
+}
+
+// FileFernet returns the Fernet that opens an export file: derived from passphrase and
+// the salt in the file header when passphrase is set, otherwise from the base64 key.
+func FileFernet(path, key, passphrase string) (*Fernet, error) {
+	if passphrase == "" {
+		return NewFernet(key)
+	}
+
+	salt, err := ReadPassphraseSalt(path)
+	if err != nil {
+		return nil, err
+	}
+	if salt == nil {
+		return nil, ErrNoPassphraseSalt
+	}
+	return NewFernetFromPassphrase(passphrase, salt)
+}
+
+// FernetForFile opens an export file with secret, taken as the passphrase when the file
+// header has a passphrase salt and as the Fernet key otherwise. It serves screens with a
+// single field for either.
+func FernetForFile(path, secret string) (*Fernet, error) {
+	salt, err := ReadPassphraseSalt(path)
+	if err != nil {
+		return nil, err
+	}
+	if salt != nil {
+		return NewFernetFromPassphrase(secret, salt)
+	}
+	return NewFernet(secret)
+}
+
+// ReadPassphraseSalt returns the passphrase salt from the header of an export file,
+// or nil when the file is encrypted with a plain key.
+func ReadPassphraseSalt(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := newExportReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	header, err := reader.Read()
+	if err != nil {
+		// An empty file has no header and so no salt
+		return nil, nil
+	}
+	return parsePassphraseSalt(header)
+}
+
+// passphraseSaltColumn returns the header column recording salt.
+func passphraseSaltColumn(salt []byte) string {
+	return passphraseSaltPrefix + base64.StdEncoding.EncodeToString(salt)
+}
+
+// parsePassphraseSalt finds the salt column in an export header, nil if there is none.
+func parsePassphraseSalt(header []string) ([]byte, error) {
+	for _, col := range header {
+		encoded, ok := strings.CutPrefix(col, passphraseSaltPrefix)
+		if !ok {
+			continue
+		}
+		salt, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid passphrase salt in header: %w", err)
+		}
+		return salt, nil
+	}
+	return nil, nil
+}
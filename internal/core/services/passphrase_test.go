@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+func TestPassphrase_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+
+	salt, err := NewPassphraseSalt()
+	if err != nil {
+		t.Fatalf("NewPassphraseSalt() failed: %v", err)
+	}
+	fernet, err := NewFernetFromPassphrase("hunter2", salt)
+	if err != nil {
+		t.Fatalf("NewFernetFromPassphrase() failed: %v", err)
+	}
+
+	records := []*models.ExportRecord{{ConnID: "db", ConnType: "postgres", Password: "secret"}}
+	if err := WriteEncryptedCSV(path, records, fernet, ';'); err != nil {
+		t.Fatalf("WriteEncryptedCSV() failed: %v", err)
+	}
+
+	// The same passphrase opens the file with the salt from its header
+	opened, err := FileFernet(path, "", "hunter2")
+	if err != nil {
+		t.Fatalf("FileFernet() failed: %v", err)
+	}
+	got, err := ReadEncryptedCSV(path, opened)
+	if err != nil {
+		t.Fatalf("ReadEncryptedCSV() failed: %v", err)
+	}
+	if len(got) != 1 || got[0].ConnID != "db" || got[0].Password != "secret" {
+		t.Errorf("ReadEncryptedCSV() = %+v", got)
+	}
+
+	wrong, err := FileFernet(path, "", "hunter3")
+	if err != nil {
+		t.Fatalf("FileFernet() with another passphrase failed: %v", err)
+	}
+	if ok, _ := CanDecryptFile(path, wrong); ok {
+		t.Error("another passphrase should not decrypt the file")
+	}
+}
+
+func TestPassphrase_KeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+
+	key, _ := GenerateKey()
+	fernet, _ := NewFernet(key)
+	if err := WriteEncryptedCSV(path, []*models.ExportRecord{{ConnID: "db"}}, fernet, 0); err != nil {
+		t.Fatalf("WriteEncryptedCSV() failed: %v", err)
+	}
+
+	salt, err := ReadPassphraseSalt(path)
+	if err != nil || salt != nil {
+		t.Errorf("ReadPassphraseSalt() = %v, %v, want no salt", salt, err)
+	}
+	if _, err := FileFernet(path, "", "hunter2"); !errors.Is(err, ErrNoPassphraseSalt) {
+		t.Errorf("FileFernet() error = %v, want ErrNoPassphraseSalt", err)
+	}
+	if _, err := FileFernet(path, key, ""); err != nil {
+		t.Errorf("FileFernet() with the key failed: %v", err)
+	}
+}
+
+func TestNewFernetFromPassphrase_Invalid(t *testing.T) {
+	salt, _ := NewPassphraseSalt()
+	if _, err := NewFernetFromPassphrase("", salt); err == nil {
+		t.Error("empty passphrase should be rejected")
+	}
+	if _, err := NewFernetFromPassphrase("hunter2", []byte("short")); err == nil {
+		t.Error("short salt should be rejected")
+	}
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// ValidateFields checks that every field can be selected with ProjectRecord.
+func ValidateFields(fields []string) error {
+	for _, field := range fields {
+		if !isMappedField(field) {
+			return fmt.Errorf("unknown field %q", field)
+		}
+	}
+	return nil
+}
+
+// ProjectRecord returns a copy of r keeping only the given fields, named as in
+// mappedFields. conn_id and exported_at are always kept. Dropping password or extra
+// also clears its encryption flag, so nothing secret remains in the record.
+func ProjectRecord(r *models.ExportRecord, fields []string) (*models.ExportRecord, error) {
+	projected := &models.ExportRecord{ConnID: r.ConnID, ExportedAt: r.ExportedAt}
+	for _, field := range fields {
+		switch field {
+		case "conn_id":
+		case "conn_type":
+			projected.ConnType = r.ConnType
+		case "description":
+			projected.Description = r.Description
+		case "host":
+			projected.Host = r.Host
+		case "schema":
+			projected.Schema = r.Schema
+		case "login":
+			projected.Login = r.Login
+		case "password":
+			projected.Password = r.Password
+			projected.IsEncrypted = r.IsEncrypted
+		case "port":
+			projected.Port = r.Port
+		case "extra":
+			projected.Extra = r.Extra
+			projected.IsExtraEncrypted = r.IsExtraEncrypted
+		default:
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+	}
+	return projected, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+func TestProjectRecord(t *testing.T) {
+	r := &models.ExportRecord{
+		ConnID: "warehouse", ConnType: "postgres", Description: "DWH", Host: "db.internal",
+		Schema: "dwh", Login: "etl", Password: "secret", Port: 5432, Extra: `{"sslmode": "require"}`,
+		IsEncrypted: true, IsExtraEncrypted: true, ExportedAt: "2024-01-15T10:30:00Z",
+	}
+
+	got, err := ProjectRecord(r, []string{"conn_type", "host", "port"})
+	if err != nil {
+		t.Fatalf("ProjectRecord() failed: %v", err)
+	}
+	want := models.ExportRecord{
+		ConnID: "warehouse", ConnType: "postgres", Host: "db.internal", Port: 5432,
+		ExportedAt: "2024-01-15T10:30:00Z",
+	}
+	if *got != want {
+		t.Errorf("ProjectRecord() = %+v, want %+v", *got, want)
+	}
+	if r.Password != "secret" {
+		t.Error("ProjectRecord() should not modify the input record")
+	}
+
+	got, _ = ProjectRecord(r, []string{"password"})
+	if got.Password != "secret" || !got.IsEncrypted || got.IsExtraEncrypted {
+		t.Errorf("selected password should keep its flag: %+v", *got)
+	}
+
+	if _, err := ProjectRecord(r, []string{"host", "hostname"}); err == nil {
+		t.Error("ProjectRecord() should reject unknown fields")
+	}
+	if err := ValidateFields([]string{"conn_id", "extra"}); err != nil {
+		t.Errorf("ValidateFields() failed: %v", err)
+	}
+	if err := ValidateFields([]string{"secret"}); err == nil {
+		t.Error("ValidateFields() should reject unknown fields")
+	}
+}
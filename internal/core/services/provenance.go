@@ -0,0 +1,79 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ProvenanceFile is the name of the file recording when imported connections were
+// first exported.
+const ProvenanceFile = ".provenance.json"
+
+// Provenance keeps the exported_at of the file each connection was imported from, per
+// target profile. The Airflow database has no place for it, so without this a
+// re-export of an imported connection gets a new timestamp.
+type Provenance struct {
+	Profiles map[string]map[string]string `json:"profiles"` // profile ID -> conn_id -> exported_at
+
+	path string
+}
+
+// LoadProvenance reads the provenance file from dir. A missing file yields an empty one.
+func LoadProvenance(dir string) (*Provenance, error) {
+	p := &Provenance{
+		Profiles: make(map[string]map[string]string),
+		path:     filepath.Join(dir, ProvenanceFile),
+	}
+
+	data, err := os.ReadFile(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provenance: %w", err)
+	}
+
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse provenance: %w", err)
+	}
+	if p.Profiles == nil {
+		p.Profiles = make(map[string]map[string]string)
+	}
+
+	return p, nil
+}
+
+// ExportedAt returns the recorded exported_at of a connection of the profile.
+func (p *Provenance) ExportedAt(profileID, connID string) (string, bool) {
+	exportedAt, ok := p.Profiles[profileID][connID]
+	return exportedAt, ok
+}
+
+// Record sets the exported_at of a connection imported into the profile. An empty
+// exported_at, as read from files of other tools, is not recorded.
+func (p *Provenance) Record(profileID, connID, exportedAt string) {
+	if exportedAt == "" {
+		return
+	}
+	conns, ok := p.Profiles[profileID]
+	if !ok {
+		conns = make(map[string]string)
+		p.Profiles[profileID] = conns
+	}
+	conns[connID] = exportedAt
+}
+
+// Save writes the provenance file.
+func (p *Provenance) Save() error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal provenance: %w", err)
+	}
+	if err := os.WriteFile(p.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write provenance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import "testing"
+
+func TestProvenance_SaveAndReload(t *testing.T) {
+	dir := t.TempDir()
+
+	p, err := LoadProvenance(dir)
+	if err != nil {
+		t.Fatalf("LoadProvenance failed: %v", err)
+	}
+	if _, ok := p.ExportedAt("p1", "db"); ok {
+		t.Error("an empty provenance should not know any connection")
+	}
+
+	p.Record("p1", "db", "2024-01-15T10:30:00Z")
+	p.Record("p1", "mapped", "")
+	if err := p.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	reloaded, err := LoadProvenance(dir)
+	if err != nil {
+		t.Fatalf("LoadProvenance failed: %v", err)
+	}
+	if got, ok := reloaded.ExportedAt("p1", "db"); !ok || got != "2024-01-15T10:30:00Z" {
+		t.Errorf("ExportedAt(p1, db) = %q, %v", got, ok)
+	}
+	if _, ok := reloaded.ExportedAt("p2", "db"); ok {
+		t.Error("provenance is per profile")
+	}
+	if _, ok := reloaded.ExportedAt("p1", "mapped"); ok {
+		t.Error("an empty exported_at should not be recorded")
+	}
+}
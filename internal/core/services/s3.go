@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+const (
+	// s3DefaultRegion signs requests to stores that ignore the region, like MinIO
+	s3DefaultRegion = "us-east-1"
+
+	// s3Timeout bounds a single upload or download
+	s3Timeout = 5 * time.Minute
+)
+
+// UploadS3 uploads the file at path to obj with a PUT signed with AWS Signature
+// Version 4, and returns the object URL.
+func UploadS3(ctx context.Context, obj *models.S3Object, path string) (string, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	resp, err := doS3(ctx, http.MethodPut, obj, body)
+	if err != nil {
+		return "", fmt.Errorf("S3 upload failed: %w", err)
+	}
+	resp.Body.Close()
+	return S3ObjectURL(obj), nil
+}
+
+// DownloadS3 downloads obj to a new file at path, readable by the owner only.
+func DownloadS3(ctx context.Context, obj *models.S3Object, path string) error {
+	resp, err := doS3(ctx, http.MethodGet, obj, nil)
+	if err != nil {
+		return fmt.Errorf("S3 download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create download file: %w", err)
+	}
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return fmt.Errorf("S3 download failed: %w", err)
+	}
+	return file.Close()
+}
+
+// doS3 sends a signed request for obj and returns the response when it succeeded.
+func doS3(ctx context.Context, method string, obj *models.S3Object, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, S3ObjectURL(obj), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 object URL: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/csv")
+	}
+	signS3Request(req, obj, body, time.Now().UTC())
+
+	client := &http.Client{Timeout: s3Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		// S3 errors are small XML documents naming the problem, e.g. NoSuchBucket
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return resp, nil
+}
+
+// S3ObjectURL returns the path-style URL of obj.
+func S3ObjectURL(obj *models.S3Object) string {
+	endpoint := strings.TrimRight(obj.Endpoint, "/")
+	return endpoint + "/" + s3EscapePath(obj.Bucket) + "/" + s3EscapePath(strings.TrimLeft(obj.Key, "/"))
+}
+
+// s3EscapePath percent-encodes everything but unreserved characters and slashes, as
+// the Signature Version 4 canonical URI requires.
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for _, c := range []byte(p) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// signS3Request adds the Signature Version 4 headers for a request without a query.
+func signS3Request(req *http.Request, obj *models.S3Object, body []byte, now time.Time) {
+	region := obj.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Canonical headers, sorted by name
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	headers := []string{
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+	}
+	if obj.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", obj.SessionToken)
+		signedHeaders += ";x-amz-security-token"
+		headers = append(headers, "x-amz-security-token:"+obj.SessionToken)
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"", // No query
+		strings.Join(headers, "\n"),
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	signature := hex.EncodeToString(hmacSHA256(s3SigningKey(obj.SecretKey, date, region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		obj.AccessKey, scope, signedHeaders, signature))
+}
+
+// s3SigningKey derives the Signature Version 4 key for a day, region and service.
+func s3SigningKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
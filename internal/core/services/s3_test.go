@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+func TestS3SigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation
+	key := s3SigningKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+	want := "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("s3SigningKey() = %s, want %s", got, want)
+	}
+}
+
+func TestS3ObjectURL(t *testing.T) {
+	obj := &models.S3Object{Endpoint: "https://minio.internal:9000/", Bucket: "backups", Key: "/airflow/prod conns+1.csv"}
+	want := "https://minio.internal:9000/backups/airflow/prod%20conns%2B1.csv"
+	if got := S3ObjectURL(obj); got != want {
+		t.Errorf("S3ObjectURL() = %s, want %s", got, want)
+	}
+}
+
+func TestSignS3Request_SessionToken(t *testing.T) {
+	obj := &models.S3Object{Endpoint: "https://s3.eu-west-1.amazonaws.com", Region: "eu-west-1", Bucket: "b", Key: "k",
+		AccessKey: "AK", SecretKey: "SK", SessionToken: "token"}
+	req := httptest.NewRequest(http.MethodGet, S3ObjectURL(obj), nil)
+	signS3Request(req, obj, nil, time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC))
+
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("X-Amz-Security-Token = %q, want token", got)
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") ||
+		!strings.Contains(auth, "/20240115/eu-west-1/s3/aws4_request") {
+		t.Errorf("Authorization = %s", auth)
+	}
+}
+
+func TestUploadS3(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	if err := os.WriteFile(path, []byte("conn_id,data\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotPath, gotBody, gotAuth, gotHash string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotBody = r.URL.EscapedPath(), string(body)
+		gotAuth, gotHash = r.Header.Get("Authorization"), r.Header.Get("X-Amz-Content-Sha256")
+	}))
+	defer server.Close()
+
+	obj := &models.S3Object{Endpoint: server.URL, Bucket: "backups", Key: "daily/export.csv", AccessKey: "AK", SecretKey: "SK"}
+	objectURL, err := UploadS3(context.Background(), obj, path)
+	if err != nil {
+		t.Fatalf("UploadS3() failed: %v", err)
+	}
+	if objectURL != server.URL+"/backups/daily/export.csv" || gotPath != "/backups/daily/export.csv" {
+		t.Errorf("uploaded to %s (%s)", gotPath, objectURL)
+	}
+	if gotBody != "conn_id,data\n" || gotHash != sha256Hex([]byte(gotBody)) {
+		t.Errorf("body %q with hash %s", gotBody, gotHash)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AK/") || !strings.Contains(gotAuth, "/us-east-1/s3/aws4_request") {
+		t.Errorf("Authorization = %s", gotAuth)
+	}
+
+	t.Run("error response", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchBucket</Code></Error>")
+		}))
+		defer failing.Close()
+
+		obj.Endpoint = failing.URL
+		if _, err := UploadS3(context.Background(), obj, path); err == nil || !strings.Contains(err.Error(), "NoSuchBucket") {
+			t.Errorf("UploadS3() error = %v, want NoSuchBucket", err)
+		}
+	})
+}
+
+func TestDownloadS3(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/backups/airflow.csv" {
+			t.Errorf("request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(nil) {
+			t.Error("GET should be signed with the hash of an empty payload")
+		}
+		io.WriteString(w, "conn_id,data\n")
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "airflow.csv")
+	obj := &models.S3Object{Endpoint: server.URL, Bucket: "backups", Key: "airflow.csv", AccessKey: "AK", SecretKey: "SK"}
+	if err := DownloadS3(context.Background(), obj, path); err != nil {
+		t.Fatalf("DownloadS3() failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "conn_id,data\n" {
+		t.Errorf("downloaded %q", data)
+	}
+}
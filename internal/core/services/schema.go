@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// SchemaShape describes which optional columns the Airflow connection table has.
+// The layout changed across Airflow versions, so queries are built from it.
+type SchemaShape struct {
+	Description      bool // Added in Airflow 2.0
+	IsEncrypted      bool // Added in Airflow 1.5
+	IsExtraEncrypted bool // Added in Airflow 1.8
+	Schema           bool // Missing on some instances; its value reads as empty then
+}
+
+// Known connection table layouts
+var (
+	// ShapeCurrent is the layout used by Airflow 2.x and later
+	ShapeCurrent = SchemaShape{Description: true, IsEncrypted: true, IsExtraEncrypted: true, Schema: true}
+
+	// ShapeLegacy is the Airflow 1.8 - 1.10 layout (no description column)
+	ShapeLegacy = SchemaShape{Description: false, IsEncrypted: true, IsExtraEncrypted: true, Schema: true}
+
+	// ShapeAncient is the pre-1.8 layout (no is_extra_encrypted column)
+	ShapeAncient = SchemaShape{Description: false, IsEncrypted: true, IsExtraEncrypted: false, Schema: true}
+)
+
+// knownRevisions maps Alembic revisions to the connection table layout at that revision.
+// It is a fallback for when the columns cannot be read, see detectShape.
+var knownRevisions = map[string]SchemaShape{
+	// Airflow 1.x
+	"1507a7289a2f": ShapeAncient, // create is_encrypted
+	"bba5a7cfc896": ShapeLegacy,  // add is_extra_encrypted
+	"952da73b5eff": ShapeLegacy,  // 1.10.10: add dag_code table
+	"a4c2fd67d16b": ShapeLegacy,  // 1.10.10: add pool_slots to task_instance
+	"b25a55525161": ShapeLegacy,  // 1.10.11: increase length of pool name
+	"03afc6b6f902": ShapeLegacy,  // 1.10.13: increase length of FAB view_menu name
+
+	// Airflow 2.x
+	"61ec73d9401f": ShapeCurrent, // 2.0.0: add description to connection
+}
+
+// ShapeForRevision returns the connection table layout for an Alembic revision, and
+// false for a revision it does not know.
+func ShapeForRevision(revision string) (SchemaShape, bool) {
+	shape, ok := knownRevisions[revision]
+	return shape, ok
+}
+
+// columns returns the connection table columns available for this shape, in scan order.
+func (s SchemaShape) columns() []string {
+	cols := []string{"conn_id", "conn_type"}
+	if s.Description {
+		cols = append(cols, "description")
+	}
+	cols = append(cols, "host")
+	if s.Schema {
+		cols = append(cols, "schema")
+	}
+	cols = append(cols, "login", "password", "port", "extra")
+	if s.IsEncrypted {
+		cols = append(cols, "is_encrypted")
+	}
+	if s.IsExtraEncrypted {
+		cols = append(cols, "is_extra_encrypted")
+	}
+	return cols
+}
+
+// selectList returns the comma-separated column list for SELECT statements.
+func (s SchemaShape) selectList() string {
+	return strings.Join(s.columns(), ", ")
+}
+
+// DetectAirflowVersion returns the Alembic revision of the Airflow metadata database.
+func (d *Database) DetectAirflowVersion(ctx context.Context) (string, error) {
+	var revision string
+	err := d.db.QueryRowContext(ctx, "SELECT version_num FROM alembic_version LIMIT 1").Scan(&revision)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("alembic_version table is empty")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read alembic_version: %w", err)
+	}
+	return revision, nil
+}
+
+// detectShape resolves the connection table layout from the columns the table has,
+// read from information_schema or else from an empty SELECT on the table. When
+// neither works it falls back to a known Alembic revision, and to ShapeCurrent.
+func (d *Database) detectShape(ctx context.Context) SchemaShape {
+	revision, err := d.DetectAirflowVersion(ctx)
+	if err == nil {
+		d.revision = revision
+	}
+
+	if columns, err := d.connectionColumns(ctx); err == nil {
+		return shapeFromColumns(columns)
+	}
+	if columns, err := d.selectedColumns(ctx); err == nil {
+		return shapeFromColumns(columns)
+	}
+	if shape, ok := ShapeForRevision(d.revision); ok {
+		return shape
+	}
+	return ShapeCurrent
+}
+
+// selectedColumns returns the names of the columns of the connection table as seen
+// by a query that reads no rows, for when information_schema hides them.
+func (d *Database) selectedColumns(ctx context.Context) (map[string]bool, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT * FROM connection LIMIT 0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection columns: %w", err)
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection columns: %w", err)
+	}
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+	return columns, nil
+}
+
+// connectionColumns returns the names of the columns of the connection table in the
+// current schema, read once from information_schema.
+func (d *Database) connectionColumns(ctx context.Context) (map[string]bool, error) {
+	rows, err := d.db.QueryContext(ctx,
+		"SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'connection'")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection columns: %w", err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to read connection columns: %w", err)
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read connection columns: %w", err)
+	}
+	// Nothing is visible without privileges on the table
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("connection table columns are not visible")
+	}
+	return columns, nil
+}
+
+// shapeFromColumns returns the layout with the optional columns present in columns.
+func shapeFromColumns(columns map[string]bool) SchemaShape {
+	return SchemaShape{
+		Description:      columns["description"],
+		IsEncrypted:      columns["is_encrypted"],
+		IsExtraEncrypted: columns["is_extra_encrypted"],
+		Schema:           columns["schema"],
+	}
+}
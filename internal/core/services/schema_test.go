@@ -0,0 +1,74 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+func TestSchema_ShapeForRevision(t *testing.T) {
+	tests := []struct {
+		name      string
+		revision  string
+		want      SchemaShape
+		wantKnown bool
+	}{
+		{"airflow 2 description", "61ec73d9401f", ShapeCurrent, true},
+		{"airflow 1.10", "03afc6b6f902", ShapeLegacy, true},
+		{"pre extra encryption", "1507a7289a2f", ShapeAncient, true},
+		{"unknown revision", "ffffffffffff", SchemaShape{}, false},
+		{"empty revision", "", SchemaShape{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, known := ShapeForRevision(tt.revision); got != tt.want || known != tt.wantKnown {
+				t.Errorf("ShapeForRevision(%q) = %+v, %v, want %+v, %v", tt.revision, got, known, tt.want, tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestSchema_Columns(t *testing.T) {
+	current := ShapeCurrent.selectList()
+	want := "conn_id, conn_type, description, host, schema, login, password, port, extra, is_encrypted, is_extra_encrypted"
+	if current != want {
+		t.Errorf("current columns: got %q, want %q", current, want)
+	}
+
+	legacy := ShapeLegacy.selectList()
+	want = "conn_id, conn_type, host, schema, login, password, port, extra, is_encrypted, is_extra_encrypted"
+	if legacy != want {
+		t.Errorf("legacy columns: got %q, want %q", legacy, want)
+	}
+
+	// Values must line up with columns for every shape
+	d := &Database{}
+	for _, shape := range []SchemaShape{ShapeCurrent, ShapeLegacy, ShapeAncient, {Description: true, IsEncrypted: true}} {
+		d.shape = shape
+		cols := shape.columns()
+		vals := d.connectionValues(&models.Connection{ID: "x", ConnType: "http"})
+		if len(cols) != len(vals) {
+			t.Errorf("shape %+v: %d columns but %d values", shape, len(cols), len(vals))
+		}
+	}
+}
+
+func TestSchema_ShapeFromColumns(t *testing.T) {
+	columns := map[string]bool{"conn_id": true, "conn_type": true, "description": true, "host": true,
+		"login": true, "password": true, "port": true, "extra": true, "is_encrypted": true, "is_extra_encrypted": true}
+
+	shape := shapeFromColumns(columns)
+	if shape.Schema || !shape.Description || !shape.IsEncrypted || !shape.IsExtraEncrypted {
+		t.Errorf("shapeFromColumns() = %+v, want every optional column but schema", shape)
+	}
+	want := "conn_id, conn_type, description, host, login, password, port, extra, is_encrypted, is_extra_encrypted"
+	if got := shape.selectList(); got != want {
+		t.Errorf("columns without schema: got %q, want %q", got, want)
+	}
+
+	columns["schema"] = true
+	if got := shapeFromColumns(columns); got != ShapeCurrent {
+		t.Errorf("shapeFromColumns() = %+v, want ShapeCurrent", got)
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// Ways to split an export into several files, see models.SplitNone
+const (
+	SplitNone   = models.SplitNone
+	SplitType   = models.SplitType
+	SplitPrefix = models.SplitPrefix
+)
+
+// RecordGroup is the set of records written to one file of a split export.
+type RecordGroup struct {
+	Name    string
+	Records []*models.ExportRecord
+}
+
+// SplitRecords groups records by conn_type (SplitType) or by the conn_id part
+// before the first underscore (SplitPrefix). Groups are sorted by name and keep
+// the order of records. SplitNone or empty gives a single unnamed group.
+func SplitRecords(records []*models.ExportRecord, by string) ([]RecordGroup, error) {
+	var key func(r *models.ExportRecord) string
+	switch by {
+	case "", SplitNone:
+		return []RecordGroup{{Records: records}}, nil
+	case SplitType:
+		key = func(r *models.ExportRecord) string { return r.ConnType }
+	case SplitPrefix:
+		key = func(r *models.ExportRecord) string {
+			prefix, _, _ := strings.Cut(r.ConnID, "_")
+			return prefix
+		}
+	default:
+		return nil, fmt.Errorf("unknown split %q, expected %s, %s or %s", by, SplitNone, SplitType, SplitPrefix)
+	}
+
+	index := make(map[string]int)
+	var groups []RecordGroup
+	for _, r := range records {
+		name := groupFileName(key(r))
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, RecordGroup{Name: name})
+		}
+		groups[i].Records = append(groups[i].Records, r)
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+// groupFileName makes a group name safe for a file name: lower case letters,
+// digits, dashes and underscores. Empty names become "unknown".
+func groupFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '_'
+		}
+	}, name)
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// SplitPath returns the path of a group's file: path with _<group> before the
+// extension, e.g. airflow_prod.csv becomes airflow_prod_postgres.csv.
+func SplitPath(path, group string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + group + ext
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+func TestSplitRecords(t *testing.T) {
+	records := []*models.ExportRecord{
+		{ConnID: "dev_db", ConnType: "postgres"},
+		{ConnID: "dev_api", ConnType: "http"},
+		{ConnID: "prod_db", ConnType: "postgres"},
+		{ConnID: "solo", ConnType: ""},
+	}
+
+	names := func(groups []RecordGroup) map[string][]string {
+		out := make(map[string][]string)
+		for _, g := range groups {
+			for _, r := range g.Records {
+				out[g.Name] = append(out[g.Name], r.ConnID)
+			}
+		}
+		return out
+	}
+
+	byType, err := SplitRecords(records, SplitType)
+	if err != nil {
+		t.Fatalf("SplitRecords(type) failed: %v", err)
+	}
+	if len(byType) != 3 || byType[0].Name != "http" || byType[1].Name != "postgres" || byType[2].Name != "unknown" {
+		t.Errorf("unexpected type groups: %v", names(byType))
+	}
+	if got := names(byType)["postgres"]; len(got) != 2 || got[0] != "dev_db" || got[1] != "prod_db" {
+		t.Errorf("postgres group = %v, want [dev_db prod_db]", got)
+	}
+
+	byPrefix, err := SplitRecords(records, SplitPrefix)
+	if err != nil {
+		t.Fatalf("SplitRecords(prefix) failed: %v", err)
+	}
+	got := names(byPrefix)
+	if len(got["dev"]) != 2 || len(got["prod"]) != 1 || len(got["solo"]) != 1 {
+		t.Errorf("unexpected prefix groups: %v", got)
+	}
+
+	none, err := SplitRecords(records, "")
+	if err != nil || len(none) != 1 || len(none[0].Records) != len(records) {
+		t.Errorf("SplitRecords(\"\") = %v, %v, want one group with every record", names(none), err)
+	}
+
+	if _, err := SplitRecords(records, "owner"); err == nil {
+		t.Error("SplitRecords should reject an unknown split")
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	tests := []struct {
+		path, group, want string
+	}{
+		{"/tmp/airflow_prod.csv", "postgres", "/tmp/airflow_prod_postgres.csv"},
+		{"export", "http", "export_http"},
+	}
+	for _, tt := range tests {
+		if got := SplitPath(tt.path, tt.group); got != tt.want {
+			t.Errorf("SplitPath(%q, %q) = %q, want %q", tt.path, tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestGroupFileName(t *testing.T) {
+	if got := groupFileName("Google Cloud/Platform"); got != "google_cloud_platform" {
+		t.Errorf("groupFileName() = %q", got)
+	}
+	if got := groupFileName(""); got != "unknown" {
+		t.Errorf("groupFileName(\"\") = %q, want unknown", got)
+	}
+}
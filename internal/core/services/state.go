@@ -0,0 +1,114 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// ExportStateFile is the name of the incremental export state file.
+const ExportStateFile = ".export-state.json"
+
+// ExportState tracks the content hash of every exported connection, per profile,
+// so later exports can write only what changed. The hashes cover decrypted secrets,
+// so they are keyed by the profile's Fernet key (see StateKey): the state file does
+// not let anyone test a guessed password. Rotating the key makes the next export a
+// full one.
+type ExportState struct {
+	Profiles map[string]map[string]string `json:"profiles"` // profile ID -> conn_id -> hash
+
+	path string
+}
+
+// LoadExportState reads the state file from dir. A missing file yields an empty state.
+func LoadExportState(dir string) (*ExportState, error) {
+	state := &ExportState{
+		Profiles: make(map[string]map[string]string),
+		path:     filepath.Join(dir, ExportStateFile),
+	}
+
+	data, err := os.ReadFile(state.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse export state: %w", err)
+	}
+	if state.Profiles == nil {
+		state.Profiles = make(map[string]map[string]string)
+	}
+
+	return state, nil
+}
+
+// Changed reports whether a record differs from the last export of the profile.
+func (s *ExportState) Changed(profile *models.Profile, r *models.ExportRecord) bool {
+	hash, ok := s.Profiles[profile.ID][r.ConnID]
+	return !ok || !hmac.Equal([]byte(hash), []byte(RecordHash(StateKey(profile), r)))
+}
+
+// Update records the hashes of exported records for the profile.
+func (s *ExportState) Update(profile *models.Profile, records []*models.ExportRecord) {
+	hashes, ok := s.Profiles[profile.ID]
+	if !ok {
+		hashes = make(map[string]string)
+		s.Profiles[profile.ID] = hashes
+	}
+	key := StateKey(profile)
+	for _, r := range records {
+		hashes[r.ConnID] = RecordHash(key, r)
+	}
+}
+
+// Save writes the state file.
+func (s *ExportState) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal export state: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write export state: %w", err)
+	}
+	return nil
+}
+
+// StateKey derives the record hash key of a profile from its Fernet key.
+func StateKey(profile *models.Profile) []byte {
+	sum := sha256.Sum256([]byte("airflow-migrator export state\x00" + profile.FernetKey))
+	return sum[:]
+}
+
+// RecordHash returns a stable HMAC-SHA256 of a record's content under key,
+// ignoring ExportedAt.
+func RecordHash(key []byte, r *models.ExportRecord) string {
+	data := ConnectionData{
+		ConnType:         r.ConnType,
+		Description:      r.Description,
+		Host:             r.Host,
+		Schema:           r.Schema,
+		Login:            r.Login,
+		Password:         r.Password,
+		Port:             r.Port,
+		Extra:            r.Extra,
+		IsEncrypted:      r.IsEncrypted,
+		IsExtraEncrypted: r.IsExtraEncrypted,
+	}
+	jsonData, _ := json.Marshal(data)
+
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(r.ConnID))
+	h.Write([]byte{0})
+	h.Write(jsonData)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+func TestState_MissingFileIsEmpty(t *testing.T) {
+	state, err := LoadExportState(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadExportState failed: %v", err)
+	}
+	if !state.Changed(&models.Profile{ID: "p1", FernetKey: "k1"}, &models.ExportRecord{ConnID: "a"}) {
+		t.Error("every record should be changed with an empty state")
+	}
+}
+
+func TestState_SaveAndReload(t *testing.T) {
+	dir := t.TempDir()
+	record := &models.ExportRecord{ConnID: "a", ConnType: "http", Password: "secret", ExportedAt: "2024-01-15T10:30:00Z"}
+	p1 := &models.Profile{ID: "p1", FernetKey: "k1"}
+
+	state, _ := LoadExportState(dir)
+	state.Update(p1, []*models.ExportRecord{record})
+	if err := state.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ExportStateFile)); err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+
+	reloaded, err := LoadExportState(dir)
+	if err != nil {
+		t.Fatalf("LoadExportState failed: %v", err)
+	}
+
+	// ExportedAt does not count as a change
+	same := *record
+	same.ExportedAt = "2024-01-16T10:30:00Z"
+	if reloaded.Changed(p1, &same) {
+		t.Error("record with a new timestamp only should be unchanged")
+	}
+
+	edited := *record
+	edited.Password = "rotated"
+	if !reloaded.Changed(p1, &edited) {
+		t.Error("record with a new password should be changed")
+	}
+
+	// State is per profile
+	if !reloaded.Changed(&models.Profile{ID: "p2", FernetKey: "k1"}, record) {
+		t.Error("record should be changed for a profile without state")
+	}
+
+	// Hashes are keyed by the Fernet key, a rotated key exports everything again
+	if !reloaded.Changed(&models.Profile{ID: "p1", FernetKey: "k2"}, record) {
+		t.Error("record should be changed after the Fernet key changed")
+	}
+}
+
+func TestRecordHash_Keyed(t *testing.T) {
+	record := &models.ExportRecord{ConnID: "a", Password: "secret"}
+
+	if RecordHash([]byte("k1"), record) != RecordHash([]byte("k1"), record) {
+		t.Error("hash should be stable under the same key")
+	}
+	if RecordHash([]byte("k1"), record) == RecordHash([]byte("k2"), record) {
+		t.Error("hash should depend on the key")
+	}
+}
+
+func TestState_CorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ExportStateFile), []byte("not json"), 0600)
+
+	if _, err := LoadExportState(dir); err == nil {
+		t.Error("should fail on a corrupt state file")
+	}
+}
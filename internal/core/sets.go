@@ -0,0 +1,67 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+// connectionSetPrefix starts the secret store key of each connection set
+const connectionSetPrefix = "connset:"
+
+// SaveConnectionSet stores ids as the connection set name, replacing a set of the
+// same name. The IDs are kept sorted and without duplicates.
+func (m *Migrator) SaveConnectionSet(store *secrets.Store, name string, ids []string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("connection set name is required")
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("connection set %q has no connections", name)
+	}
+
+	sorted := slices.Compact(slices.Sorted(slices.Values(ids)))
+
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return err
+	}
+	return store.Set(connectionSetPrefix+name, string(data))
+}
+
+// ListConnectionSets returns the saved connection sets sorted by name. Sets that
+// cannot be parsed are skipped.
+func (m *Migrator) ListConnectionSets(store *secrets.Store) []models.ConnectionSet {
+	var sets []models.ConnectionSet
+	for _, key := range store.List() {
+		name, ok := strings.CutPrefix(key, connectionSetPrefix)
+		if !ok {
+			continue
+		}
+		data, err := store.Get(key)
+		if err != nil {
+			continue
+		}
+		var ids []string
+		if err := json.Unmarshal([]byte(data), &ids); err != nil {
+			continue
+		}
+		sets = append(sets, models.ConnectionSet{Name: name, ConnectionIDs: ids})
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+	return sets
+}
+
+// DeleteConnectionSet removes the connection set name.
+func (m *Migrator) DeleteConnectionSet(store *secrets.Store, name string) error {
+	key := connectionSetPrefix + strings.TrimSpace(name)
+	if !store.Has(key) {
+		return fmt.Errorf("connection set %q not found", name)
+	}
+	return store.Delete(key)
+}
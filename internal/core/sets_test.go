@@ -0,0 +1,49 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+func TestMigrator_ConnectionSets(t *testing.T) {
+	store, err := secrets.New(t.TempDir(), "password")
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	store.Set("profile:1:meta", `{"id":"1"}`)
+	m := New()
+
+	if err := m.SaveConnectionSet(store, " critical ", []string{"warehouse", "api", "warehouse"}); err != nil {
+		t.Fatalf("SaveConnectionSet() failed: %v", err)
+	}
+	if err := m.SaveConnectionSet(store, "billing", []string{"stripe"}); err != nil {
+		t.Fatalf("SaveConnectionSet() failed: %v", err)
+	}
+	if err := m.SaveConnectionSet(store, " ", []string{"x"}); err == nil {
+		t.Error("SaveConnectionSet() should reject an empty name")
+	}
+	if err := m.SaveConnectionSet(store, "empty", nil); err == nil {
+		t.Error("SaveConnectionSet() should reject a set without connections")
+	}
+
+	want := []models.ConnectionSet{
+		{Name: "billing", ConnectionIDs: []string{"stripe"}},
+		{Name: "critical", ConnectionIDs: []string{"api", "warehouse"}},
+	}
+	if got := m.ListConnectionSets(store); !reflect.DeepEqual(got, want) {
+		t.Errorf("ListConnectionSets() = %+v, want %+v", got, want)
+	}
+
+	if err := m.DeleteConnectionSet(store, "billing"); err != nil {
+		t.Fatalf("DeleteConnectionSet() failed: %v", err)
+	}
+	if err := m.DeleteConnectionSet(store, "billing"); err == nil {
+		t.Error("DeleteConnectionSet() should fail for a missing set")
+	}
+	if got := m.ListConnectionSets(store); len(got) != 1 || got[0].Name != "critical" {
+		t.Errorf("ListConnectionSets() after delete = %+v", got)
+	}
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/core/services"
+)
+
+// webhookAttempts is how many times a summary is posted before giving up
+const webhookAttempts = 3
+
+// webhookRetryDelay is the wait before the first retry, doubled before each next one
+var webhookRetryDelay = 5 * time.Second
+
+// NotifyWebhook posts summary as JSON to url. Network errors, 429 and 5xx answers are
+// retried, up to webhookAttempts deliveries in all; other answers fail at once.
+func (m *Migrator) NotifyWebhook(ctx context.Context, url string, summary any) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook summary: %w", err)
+	}
+
+	delay := webhookRetryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := services.PostWebhook(ctx, url, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,220 @@
+// Package headless runs one JSON command read from stdin and writes a JSON result,
+// for scripts driving the migrator without the TUI.
+package headless
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flevanti/airflow-migrator/internal/app"
+	"github.com/flevanti/airflow-migrator/internal/core"
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+// Operations accepted in Command.Op
+const (
+	OpExport = "export"
+	OpList   = "list"
+)
+
+// commandTimeout bounds a whole command, like the TUI export
+const commandTimeout = 60 * time.Second
+
+// Command is the JSON read from stdin, e.g. {"op":"export","profile":"prod","ids":["db"]}
+type Command struct {
+	Op      string   `json:"op"`
+	Profile string   `json:"profile"` // Profile name or ID
+	IDs     []string `json:"ids,omitempty"`
+
+	// Export only. Output defaults to airflow_<profile>_<timestamp>.csv in the working
+	// directory; without Key or Passphrase a key is generated and returned
+	Output     string `json:"output,omitempty"`
+	Key        string `json:"key,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+
+	// Export only. Uploads the file to this object; Output is then only written if set
+	S3 *models.S3Object `json:"s3,omitempty"`
+
+	// Export only. Adds the rows to Output instead of replacing it, see ExportRequest.Append
+	Append bool `json:"append,omitempty"`
+}
+
+// errorResult is written when the command fails before reaching the migrator
+type errorResult struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+}
+
+// listResult is the result of OpList
+type listResult struct {
+	Success     bool                    `json:"success"`
+	Connections []models.ConnectionMeta `json:"connections"`
+}
+
+// Serve reads the master password from the first line of stdin and the command from the
+// rest, like the piped password of the TUI, opens the store of configDir and runs the
+// command. It returns false when anything failed.
+func Serve(configDir string, stdin io.Reader, stdout io.Writer) bool {
+	reader := bufio.NewReader(stdin)
+	password, err := reader.ReadString('\n')
+	if err != nil {
+		return write(stdout, errorResult{Error: "expected the master password on the first line of stdin"}, false)
+	}
+
+	application, err := app.Open(configDir, strings.TrimSpace(password))
+	if err != nil {
+		return write(stdout, errorResult{Error: err.Error()}, false)
+	}
+	defer application.Secrets.Close()
+	return Run(reader, stdout, application.Secrets, application.Migrator)
+}
+
+// Run decodes one command from r, runs it and writes the JSON result to w. It returns
+// false when the command failed, the result then holds the error.
+func Run(r io.Reader, w io.Writer, store *secrets.Store, migrator *core.Migrator) bool {
+	result, ok := run(r, store, migrator)
+	return write(w, result, ok)
+}
+
+// write encodes result to w and passes ok through, false if the result can't be written.
+func write(w io.Writer, result any, ok bool) bool {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write result: %v\n", err)
+		return false
+	}
+	return ok
+}
+
+func run(r io.Reader, store *secrets.Store, migrator *core.Migrator) (any, bool) {
+	var cmd Command
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cmd); err != nil {
+		return fail("invalid command: %v", err)
+	}
+
+	if cmd.Op != OpExport && cmd.Op != OpList {
+		return fail("unknown op %q, expected %s or %s", cmd.Op, OpExport, OpList)
+	}
+	if cmd.Profile == "" {
+		return fail("profile is required")
+	}
+	profile, err := findProfile(store, cmd.Profile)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	switch cmd.Op {
+	case OpExport:
+		output := cmd.Output
+		if output == "" && cmd.S3 == nil {
+			name := strings.ReplaceAll(profile.Name, " ", "_")
+			output = fmt.Sprintf("airflow_%s_%s.csv", name, time.Now().Format("20060102_150405"))
+		}
+		if output != "" {
+			if abs, err := filepath.Abs(output); err == nil {
+				output = abs
+			}
+		}
+
+		result, err := migrator.Export(ctx, models.ExportRequest{
+			SourceProfile:     profile,
+			OutputPath:        output,
+			ConnectionIDs:     cmd.IDs,
+			FileEncryptionKey: cmd.Key,
+			FilePassphrase:    cmd.Passphrase,
+			S3:                cmd.S3,
+			Append:            cmd.Append,
+		})
+		if err != nil {
+			return fail("%v", err)
+		}
+		return result, result.Success
+
+	case OpList:
+		connections, err := migrator.ListConnectionMeta(ctx, profile)
+		if err != nil {
+			return fail("failed to list connections: %v", err)
+		}
+		return listResult{Success: true, Connections: connections}, true
+	}
+	return fail("unknown op %q", cmd.Op)
+}
+
+func fail(format string, args ...any) (any, bool) {
+	return errorResult{Error: fmt.Sprintf(format, args...)}, false
+}
+
+// findProfile loads the profile whose ID or name is nameOrID. A name matching several
+// profiles is an error, the ID must be used then.
+func findProfile(store *secrets.Store, nameOrID string) (*models.Profile, error) {
+	var found []*models.Profile
+	for _, key := range store.List() {
+		if !strings.HasPrefix(key, "profile:") || !strings.HasSuffix(key, ":meta") {
+			continue
+		}
+		metaJSON, err := store.Get(key)
+		if err != nil {
+			continue
+		}
+
+		var data struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			DBHost string `json:"db_host"`
+			DBPort int    `json:"db_port"`
+			DBName string `json:"db_name"`
+			DBUser string `json:"db_user"`
+		}
+		if err := json.Unmarshal([]byte(metaJSON), &data); err != nil {
+			continue
+		}
+		if data.ID != nameOrID && data.Name != nameOrID {
+			continue
+		}
+
+		profile := &models.Profile{
+			ID:     data.ID,
+			Name:   data.Name,
+			DBHost: data.DBHost,
+			DBPort: data.DBPort,
+			DBName: data.DBName,
+			DBUser: data.DBUser,
+		}
+		keys := profile.GetSecretKeys()
+		if pw, err := store.Get(keys.Password); err == nil {
+			profile.DBPassword = pw
+		}
+		if fk, err := store.Get(keys.FernetKey); err == nil {
+			profile.FernetKey = fk
+		}
+
+		// An exact ID match wins over names
+		if data.ID == nameOrID {
+			return profile, nil
+		}
+		found = append(found, profile)
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("profile %q not found", nameOrID)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("%d profiles are named %q, use the profile ID", len(found), nameOrID)
+	}
+}
@@ -0,0 +1,105 @@
+package headless
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/flevanti/airflow-migrator/internal/core"
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+func newTestStore(t *testing.T) *secrets.Store {
+	t.Helper()
+	store, err := secrets.New(t.TempDir(), "master")
+	if err != nil {
+		t.Fatalf("secrets.New() failed: %v", err)
+	}
+	profiles := map[string]string{
+		"profile:p1:meta":     `{"id":"p1","name":"prod","db_host":"db","db_port":5432,"db_name":"airflow","db_user":"airflow"}`,
+		"profile:p1:password": "secret",
+		"profile:p1:fernet":   "key",
+		"profile:p2:meta":     `{"id":"p2","name":"dev"}`,
+		"profile:p3:meta":     `{"id":"p3","name":"dev"}`,
+	}
+	if err := store.SetBatch(profiles); err != nil {
+		t.Fatalf("SetBatch() failed: %v", err)
+	}
+	return store
+}
+
+func TestFindProfile(t *testing.T) {
+	store := newTestStore(t)
+
+	for _, nameOrID := range []string{"prod", "p1"} {
+		p, err := findProfile(store, nameOrID)
+		if err != nil {
+			t.Fatalf("findProfile(%q) failed: %v", nameOrID, err)
+		}
+		if p.ID != "p1" || p.DBPassword != "secret" || p.FernetKey != "key" {
+			t.Errorf("findProfile(%q) = %+v", nameOrID, p)
+		}
+	}
+
+	if _, err := findProfile(store, "dev"); err == nil || !strings.Contains(err.Error(), "use the profile ID") {
+		t.Errorf("ambiguous name: error = %v", err)
+	}
+	if p, err := findProfile(store, "p3"); err != nil || p.ID != "p3" {
+		t.Errorf("ID of an ambiguous name: %+v, %v", p, err)
+	}
+	if _, err := findProfile(store, "staging"); err == nil {
+		t.Error("unknown profile should be an error")
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	store := newTestStore(t)
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"not json", `export prod`, "invalid command"},
+		{"unknown field", `{"op":"export","profile":"prod","key_file":"x"}`, "invalid command"},
+		{"unknown op", `{"op":"delete","profile":"prod"}`, `unknown op "delete"`},
+		{"missing profile", `{"op":"export"}`, "profile is required"},
+		{"unknown profile", `{"op":"list","profile":"staging"}`, `profile "staging" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if Run(strings.NewReader(tt.input), &out, store, core.New()) {
+				t.Fatal("Run() = true, want false")
+			}
+			var result errorResult
+			if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+				t.Fatalf("output is not JSON: %q", out.String())
+			}
+			if result.Success || !strings.Contains(result.Error, tt.wantErr) {
+				t.Errorf("result = %+v, want error %q", result, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServe_WrongPassword(t *testing.T) {
+	dir := t.TempDir()
+	store, err := secrets.New(dir, "master")
+	if err != nil {
+		t.Fatalf("secrets.New() failed: %v", err)
+	}
+	if err := store.Set("profile:p1:meta", `{"id":"p1","name":"prod"}`); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	if Serve(dir, strings.NewReader("wrong\n{\"op\":\"list\",\"profile\":\"prod\"}"), &out) {
+		t.Fatal("Serve() with a wrong password = true, want false")
+	}
+	if !strings.Contains(out.String(), `"success": false`) {
+		t.Errorf("output = %q", out.String())
+	}
+}
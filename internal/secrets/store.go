@@ -3,6 +3,7 @@
 package secrets
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -27,12 +28,19 @@ const (
 	credentialsFile = "credentials.enc"
 	saltFile        = "salt"
 	saltLength      = 16
+
+	// Suffix of the copy Recover keeps of a corrupt credentials file
+	corruptSuffix = ".corrupt"
 )
 
 var (
 	ErrInvalidPassword = errors.New("invalid master password")
+	ErrCorruptStore    = errors.New("credentials file decrypts with this password but its content is corrupt")
 	ErrKeyNotFound     = errors.New("key not found")
+	ErrKeyExists       = errors.New("key already exists")
 	ErrNotInitialized  = errors.New("store not initialized")
+	ErrLocked          = errors.New("store is locked, unlock it with the master password")
+	ErrClosed          = errors.New("store is closed")
 )
 
 // Store provides encrypted storage for sensitive data.
@@ -43,10 +51,18 @@ type Store struct {
 	filePath string            // Path to encrypted file
 	saltPath string            // Path to salt file
 	data     map[string]string // Decrypted data in memory
+	corrupt  []byte            // Decrypted content that failed to parse, until Recover
+
+	// check is a value encrypted with key when the store was locked; Unlock
+	// accepts a password whose key decrypts it. Nil while unlocked.
+	check  []byte
+	closed bool // Set by Close, for good
 }
 
 // New creates a new secret store. If the store already exists, it decrypts it
 // using the provided master password. If it doesn't exist, it creates a new one.
+// If the file decrypts but does not parse, New returns ErrCorruptStore with an empty
+// store that refuses to save; call Recover on it to salvage the readable entries.
 func New(configDir string, masterPassword string) (*Store, error) {
 	if err := os.MkdirAll(configDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
@@ -65,18 +81,14 @@ func New(configDir string, masterPassword string) (*Store, error) {
 	}
 
 	// Derive key from password
-	s.key = argon2.IDKey(
-		[]byte(masterPassword),
-		salt,
-		argonTime,
-		argonMemory,
-		argonThreads,
-		argonKeyLen,
-	)
+	s.key = deriveKey(masterPassword, salt)
 
 	// Load existing data if file exists
 	if _, err := os.Stat(s.filePath); err == nil {
 		if err := s.load(); err != nil {
+			if errors.Is(err, ErrCorruptStore) {
+				return s, err
+			}
 			return nil, err
 		}
 	}
@@ -84,6 +96,13 @@ func New(configDir string, masterPassword string) (*Store, error) {
 	return s, nil
 }
 
+// deriveKey derives the AES key from password with Argon2, wiping its copy of the password.
+func deriveKey(password string, salt []byte) []byte {
+	passwordBytes := []byte(password)
+	defer clear(passwordBytes)
+	return argon2.IDKey(passwordBytes, salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+}
+
 // getOrCreateSalt retrieves existing salt or creates a new one
 func (s *Store) getOrCreateSalt() ([]byte, error) {
 	// Try to read existing salt
@@ -117,19 +136,180 @@ func (s *Store) load() error {
 		return ErrInvalidPassword
 	}
 
+	// The password is right at this point, a parse failure means a damaged file
 	if err := json.Unmarshal(plaintext, &s.data); err != nil {
+		s.data = make(map[string]string)
+		s.corrupt = plaintext
+		return ErrCorruptStore
+	}
+
+	clear(plaintext)
+	return nil
+}
+
+// Recover salvages the readable entries of a store opened with ErrCorruptStore and
+// saves them, keeping the corrupt file next to it with a .corrupt suffix. It returns
+// the number of entries recovered.
+func (s *Store) Recover() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.corrupt == nil {
+		return 0, errors.New("store is not corrupt")
+	}
+
+	data := salvageEntries(s.corrupt)
+	if err := os.Rename(s.filePath, s.filePath+corruptSuffix); err != nil {
+		return 0, fmt.Errorf("failed to keep the corrupt file: %w", err)
+	}
+
+	clear(s.corrupt)
+	s.corrupt = nil
+	s.data = data
+	if err := s.save(); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// salvageEntries decodes the string entries of a JSON object, skipping values that are
+// not strings and stopping at the first syntax error with the entries read until then.
+func salvageEntries(plaintext []byte) map[string]string {
+	data := make(map[string]string)
+
+	dec := json.NewDecoder(bytes.NewReader(plaintext))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return data
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, ok := tok.(string)
+		if !ok {
+			break
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			break
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			data[key] = value
+		}
+	}
+	return data
+}
+
+// Lock zeroes the derived key and drops the decrypted data from memory. Until Unlock,
+// Get and every change return ErrLocked, and List and Has see an empty store.
+// Locking a locked store does nothing.
+func (s *Store) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.check != nil || s.closed {
+		return
+	}
+	check, err := s.encrypt([]byte(credentialsFile))
+	if err != nil {
+		// Without a check value Unlock could not tell a wrong password, keep the key
+		return
+	}
+	s.check = check
+
+	clear(s.key)
+	s.key = nil
+	s.data = make(map[string]string)
+	clear(s.corrupt)
+	s.corrupt = nil
+}
+
+// Unlock derives the key from password again and reloads the credentials file.
+// A wrong password returns ErrInvalidPassword and leaves the store locked. Like New,
+// a file that decrypts but does not parse returns ErrCorruptStore with the store unlocked.
+func (s *Store) Unlock(password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.check == nil {
+		return nil
+	}
+	salt, err := os.ReadFile(s.saltPath)
+	if err != nil {
+		return fmt.Errorf("failed to read salt: %w", err)
+	}
+
+	s.key = deriveKey(password, salt)
+	if _, err := s.decrypt(s.check); err != nil {
+		clear(s.key)
+		s.key = nil
 		return ErrInvalidPassword
 	}
+	s.check = nil
+
+	s.data = make(map[string]string)
+	if _, err := os.Stat(s.filePath); err != nil {
+		return nil
+	}
+	return s.load()
+}
 
+// Close zeroes the derived key and drops the decrypted data for good, for when the
+// store is no longer needed. Every later call fails with ErrClosed.
+func (s *Store) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	clear(s.key)
+	s.key = nil
+	s.data = make(map[string]string)
+	clear(s.corrupt)
+	s.corrupt = nil
+	s.check = nil
+}
+
+// usable returns why the store cannot be read or changed, if it cannot.
+func (s *Store) usable() error {
+	if s.closed {
+		return ErrClosed
+	}
+	if s.check != nil {
+		return ErrLocked
+	}
 	return nil
 }
 
+// Locked reports whether the store is locked.
+func (s *Store) Locked() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.check != nil
+}
+
 // save encrypts and saves data to the encrypted file
 func (s *Store) save() error {
+	// Saving while locked or closed would replace the file with an empty store
+	if err := s.usable(); err != nil {
+		return err
+	}
+
+	// Saving would replace the corrupt file with whatever little is in memory
+	if s.corrupt != nil {
+		return ErrCorruptStore
+	}
+
 	plaintext, err := json.Marshal(s.data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
+	defer clear(plaintext)
 
 	ciphertext, err := s.encrypt(plaintext)
 	if err != nil {
@@ -191,6 +371,9 @@ func (s *Store) Get(key string) (string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	if err := s.usable(); err != nil {
+		return "", err
+	}
 	value, ok := s.data[key]
 	if !ok {
 		return "", ErrKeyNotFound
@@ -204,6 +387,9 @@ func (s *Store) Set(key, value string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.usable(); err != nil {
+		return err
+	}
 	s.data[key] = value
 	return s.save()
 }
@@ -213,6 +399,9 @@ func (s *Store) Delete(key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.usable(); err != nil {
+		return err
+	}
 	if _, ok := s.data[key]; !ok {
 		return ErrKeyNotFound
 	}
@@ -221,6 +410,108 @@ func (s *Store) Delete(key string) error {
 	return s.save()
 }
 
+// Rename moves a value to a new key and persists to disk in a single save.
+// If saving fails the in-memory data is left unchanged.
+func (s *Store) Rename(oldKey, newKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.usable(); err != nil {
+		return err
+	}
+	value, ok := s.data[oldKey]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if _, ok := s.data[newKey]; ok {
+		return ErrKeyExists
+	}
+
+	s.data[newKey] = value
+	delete(s.data, oldKey)
+	if err := s.save(); err != nil {
+		s.data[oldKey] = value
+		delete(s.data, newKey)
+		return err
+	}
+
+	return nil
+}
+
+// Txn buffers changes made inside Store.Transaction.
+// Its methods must only be called from within the transaction function.
+type Txn struct {
+	data map[string]string
+}
+
+// Get retrieves a value by key, seeing earlier changes of the transaction
+func (t *Txn) Get(key string) (string, error) {
+	value, ok := t.data[key]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return value, nil
+}
+
+// Set stores a key-value pair
+func (t *Txn) Set(key, value string) {
+	t.data[key] = value
+}
+
+// Delete removes a key
+func (t *Txn) Delete(key string) error {
+	if _, ok := t.data[key]; !ok {
+		return ErrKeyNotFound
+	}
+	delete(t.data, key)
+	return nil
+}
+
+// Has checks if a key exists
+func (t *Txn) Has(key string) bool {
+	_, ok := t.data[key]
+	return ok
+}
+
+// Transaction runs fn with a Txn and persists all of its changes in a single save.
+// If fn returns an error or saving fails, none of the changes are applied.
+// fn must not call other Store methods, which would deadlock.
+func (s *Store) Transaction(fn func(txn *Txn) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.usable(); err != nil {
+		return err
+	}
+	txn := &Txn{data: make(map[string]string, len(s.data))}
+	for k, v := range s.data {
+		txn.data[k] = v
+	}
+
+	if err := fn(txn); err != nil {
+		return err
+	}
+
+	previous := s.data
+	s.data = txn.data
+	if err := s.save(); err != nil {
+		s.data = previous
+		return err
+	}
+
+	return nil
+}
+
+// SetBatch stores several key-value pairs and persists to disk once
+func (s *Store) SetBatch(values map[string]string) error {
+	return s.Transaction(func(txn *Txn) error {
+		for k, v := range values {
+			txn.Set(k, v)
+		}
+		return nil
+	})
+}
+
 // List returns all keys in the store
 func (s *Store) List() []string {
 	s.mu.RLock()
@@ -248,6 +539,9 @@ func (s *Store) Clear() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.usable(); err != nil {
+		return err
+	}
 	s.data = make(map[string]string)
 	return s.save()
 }
@@ -1,6 +1,7 @@
 package secrets
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -274,3 +275,229 @@ func TestStore_CredentialsFilePath(t *testing.T) {
 		t.Error("credentials.enc should be created after Set()")
 	}
 }
+
+func TestStore_Rename(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "secrets-test-*")
+	defer os.RemoveAll(tmpDir)
+
+	store, _ := New(tmpDir, "password")
+	store.Set("old", "value")
+	store.Set("taken", "other")
+
+	if err := store.Rename("old", "new"); err != nil {
+		t.Fatalf("Rename() failed: %v", err)
+	}
+	if store.Has("old") {
+		t.Error("old key should be gone after Rename()")
+	}
+
+	// Reopen to verify the rename was persisted
+	store2, _ := New(tmpDir, "password")
+	if val, _ := store2.Get("new"); val != "value" {
+		t.Errorf("Get() after Rename(): got %q, want %q", val, "value")
+	}
+
+	if err := store.Rename("missing", "other"); err != ErrKeyNotFound {
+		t.Errorf("Rename() missing key: got %v, want %v", err, ErrKeyNotFound)
+	}
+	if err := store.Rename("new", "taken"); err != ErrKeyExists {
+		t.Errorf("Rename() onto existing key: got %v, want %v", err, ErrKeyExists)
+	}
+}
+
+func TestStore_Transaction(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "secrets-test-*")
+	defer os.RemoveAll(tmpDir)
+
+	store, _ := New(tmpDir, "password")
+	store.Set("keep", "value")
+	store.Set("drop", "value")
+
+	err := store.Transaction(func(txn *Txn) error {
+		txn.Set("a", "1")
+		txn.Set("b", "2")
+		if v, _ := txn.Get("a"); v != "1" {
+			t.Errorf("Txn.Get() should see earlier changes, got %q", v)
+		}
+		return txn.Delete("drop")
+	})
+	if err != nil {
+		t.Fatalf("Transaction() failed: %v", err)
+	}
+
+	// Reopen to verify the changes were persisted
+	store2, _ := New(tmpDir, "password")
+	if v, _ := store2.Get("b"); v != "2" || store2.Has("drop") || !store2.Has("keep") {
+		t.Errorf("unexpected keys after Transaction(): %v", store2.List())
+	}
+
+	// A failing function applies nothing
+	err = store.Transaction(func(txn *Txn) error {
+		txn.Set("c", "3")
+		return txn.Delete("missing")
+	})
+	if err != ErrKeyNotFound {
+		t.Errorf("Transaction() error: got %v, want %v", err, ErrKeyNotFound)
+	}
+	if store.Has("c") {
+		t.Error("changes of a failed transaction should be discarded")
+	}
+}
+
+func TestStore_SetBatch(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "secrets-test-*")
+	defer os.RemoveAll(tmpDir)
+
+	store, _ := New(tmpDir, "password")
+	if err := store.SetBatch(map[string]string{"x": "1", "y": "2"}); err != nil {
+		t.Fatalf("SetBatch() failed: %v", err)
+	}
+
+	store2, _ := New(tmpDir, "password")
+	if v, _ := store2.Get("y"); v != "2" || len(store2.List()) != 2 {
+		t.Errorf("unexpected keys after SetBatch(): %v", store2.List())
+	}
+
+	// When saving fails the in-memory data is left unchanged
+	store.filePath = tmpDir
+	if err := store.SetBatch(map[string]string{"z": "3"}); err == nil {
+		t.Fatal("SetBatch() should fail when the file cannot be written")
+	}
+	if store.Has("z") {
+		t.Error("SetBatch() should not keep changes that were not saved")
+	}
+}
+
+func TestStore_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	store, err := New(dir, "password")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	// Valid encryption around JSON with a non-string value and a truncated tail
+	ciphertext, err := store.encrypt([]byte(`{"a":"1","bad":2,"b":"2","c":"tru`))
+	if err != nil {
+		t.Fatalf("encrypt() failed: %v", err)
+	}
+	if err := os.WriteFile(store.filePath, ciphertext, 0600); err != nil {
+		t.Fatalf("failed to write credentials file: %v", err)
+	}
+
+	if _, err := New(dir, "wrong"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("wrong password: got %v, want ErrInvalidPassword", err)
+	}
+
+	corrupt, err := New(dir, "password")
+	if !errors.Is(err, ErrCorruptStore) {
+		t.Fatalf("right password: got %v, want ErrCorruptStore", err)
+	}
+	if err := corrupt.Set("x", "y"); !errors.Is(err, ErrCorruptStore) {
+		t.Errorf("Set() on a corrupt store: got %v, want ErrCorruptStore", err)
+	}
+
+	recovered, err := corrupt.Recover()
+	if err != nil || recovered != 2 {
+		t.Fatalf("Recover() = %d, %v, want 2 entries", recovered, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, credentialsFile+corruptSuffix)); err != nil {
+		t.Errorf("corrupt file should be kept: %v", err)
+	}
+
+	reopened, err := New(dir, "password")
+	if err != nil {
+		t.Fatalf("reopening the recovered store failed: %v", err)
+	}
+	for key, want := range map[string]string{"a": "1", "b": "2"} {
+		if got, _ := reopened.Get(key); got != want {
+			t.Errorf("Get(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestStore_LockUnlock(t *testing.T) {
+	dir := t.TempDir()
+
+	store, _ := New(dir, "password")
+	store.Set("key", "value")
+
+	store.Lock()
+	if !store.Locked() {
+		t.Fatal("Locked() = false after Lock()")
+	}
+	if store.key != nil || len(store.data) != 0 {
+		t.Error("Lock() should drop the key and the decrypted data")
+	}
+	if _, err := store.Get("key"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Get() while locked: got %v, want ErrLocked", err)
+	}
+	if err := store.Set("other", "x"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Set() while locked: got %v, want ErrLocked", err)
+	}
+
+	if err := store.Unlock("wrong"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("Unlock() with a wrong password: got %v, want ErrInvalidPassword", err)
+	}
+	if !store.Locked() {
+		t.Error("a wrong password should leave the store locked")
+	}
+
+	if err := store.Unlock("password"); err != nil {
+		t.Fatalf("Unlock() failed: %v", err)
+	}
+	if got, _ := store.Get("key"); got != "value" {
+		t.Errorf("Get() after Unlock(): got %q, want %q", got, "value")
+	}
+	if store.Has("other") {
+		t.Error("a Set() refused while locked should not be kept")
+	}
+}
+
+func TestStore_LockUnsaved(t *testing.T) {
+	store, _ := New(t.TempDir(), "password")
+
+	// Nothing was saved yet, the password is checked all the same
+	store.Lock()
+	if err := store.Unlock("wrong"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("Unlock() with a wrong password: got %v, want ErrInvalidPassword", err)
+	}
+	if err := store.Unlock("password"); err != nil {
+		t.Errorf("Unlock() failed: %v", err)
+	}
+}
+
+func TestStore_Close(t *testing.T) {
+	dir := t.TempDir()
+
+	store, _ := New(dir, "password")
+	store.Set("key", "value")
+	key := store.key
+
+	store.Close()
+	for _, b := range key {
+		if b != 0 {
+			t.Fatal("Close() should zero the derived key")
+		}
+	}
+	if store.Has("key") {
+		t.Error("Close() should drop the decrypted data")
+	}
+	if _, err := store.Get("key"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get() after Close(): got %v, want ErrClosed", err)
+	}
+	if err := store.Set("key", "other"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Set() after Close(): got %v, want ErrClosed", err)
+	}
+	if err := store.Unlock("password"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Unlock() after Close(): got %v, want ErrClosed", err)
+	}
+
+	// The file is left as it was
+	reopened, err := New(dir, "password")
+	if err != nil {
+		t.Fatalf("reopening after Close() failed: %v", err)
+	}
+	if got, _ := reopened.Get("key"); got != "value" {
+		t.Errorf("Get() after reopening: got %q, want %q", got, "value")
+	}
+}
@@ -24,7 +24,7 @@ func (m *Model) updateAbout(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *Model) viewAbout() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("ℹ️  About"))
+	s.WriteString(TitleStyle.Render(glyph("ℹ️  About")))
 	s.WriteString("\n\n")
 
 	s.WriteString(SelectedStyle.Render(app.Name))
@@ -38,14 +38,14 @@ func (m *Model) viewAbout() string {
 	s.WriteString("\n")
 
 	s.WriteString("Links:\n")
-	s.WriteString(fmt.Sprintf("  🔗 GitHub:        %s\n", app.GitHub))
-	s.WriteString(fmt.Sprintf("  🐛 Issues:        %s\n", app.GitHubIssues))
-	s.WriteString(fmt.Sprintf("  🔀 Pull Requests: %s\n", app.GitHubPR))
+	s.WriteString(fmt.Sprintf(glyph("  🔗 GitHub:        %s\n"), app.GitHub))
+	s.WriteString(fmt.Sprintf(glyph("  🐛 Issues:        %s\n"), app.GitHubIssues))
+	s.WriteString(fmt.Sprintf(glyph("  🔀 Pull Requests: %s\n"), app.GitHubPR))
 	s.WriteString("\n")
 
 	s.WriteString(fmt.Sprintf("Developer: %s (%s)\n", app.DevName, app.DevEmail))
 	s.WriteString(fmt.Sprintf("License: %s\n", app.License))
-	s.WriteString(fmt.Sprintf("© %d\n", app.CurrentYear()))
+	s.WriteString(fmt.Sprintf(glyph("© %d\n"), app.CurrentYear()))
 	s.WriteString("\n")
 
 	s.WriteString(SubtleStyle.Render("[Enter] back"))
@@ -0,0 +1,190 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// Backup sub-states
+type backupState int
+
+const (
+	backupEnterDir backupState = iota
+	backupProcessing
+	backupResult
+)
+
+type backupModel struct {
+	state    backupState
+	dirInput textinput.Model
+	result   *models.ExportAllResult
+	err      string
+}
+
+func newBackupModel() backupModel {
+	dirInput := textinput.New()
+	dirInput.Placeholder = "backup_" + time.Now().Format("20060102_150405")
+	dirInput.CharLimit = 256
+	dirInput.Focus()
+
+	return backupModel{
+		state:    backupEnterDir,
+		dirInput: dirInput,
+	}
+}
+
+func (m *Model) resetBackup() {
+	m.Backup = newBackupModel()
+	m.loadProfiles()
+}
+
+type backupCompleteMsg struct {
+	result *models.ExportAllResult
+	err    error
+}
+
+func (m *Model) updateBackup(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch m.Backup.state {
+	case backupEnterDir:
+		return m.updateBackupEnterDir(msg)
+	case backupResult:
+		return m.updateBackupResult(msg)
+	}
+	return m, nil
+}
+
+func (m *Model) updateBackupEnterDir(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			m.State = StateMainMenu
+			return m, nil
+		case "enter":
+			if len(m.Profile.profiles) == 0 {
+				m.Backup.err = "No profiles to back up"
+				return m, nil
+			}
+			m.Backup.state = backupProcessing
+			m.Backup.err = ""
+			return m, m.performBackup()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.Backup.dirInput, cmd = m.Backup.dirInput.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) updateBackupResult(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter", "esc", "q":
+			m.State = StateMainMenu
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) performBackup() tea.Cmd {
+	dir := m.Backup.dirInput.Value()
+	if dir == "" {
+		dir = m.Backup.dirInput.Placeholder
+	}
+
+	var profiles []*models.Profile
+	for _, p := range m.Profile.profiles {
+		if profile := m.loadFullProfile(p.ID); profile != nil {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		result, err := m.Migrator.ExportAll(ctx, profiles, dir)
+		return backupCompleteMsg{result: result, err: err}
+	}
+}
+
+func (m *Model) viewBackup() string {
+	switch m.Backup.state {
+	case backupEnterDir:
+		return m.viewBackupEnterDir()
+	case backupProcessing:
+		return m.viewBackupProcessing()
+	case backupResult:
+		return m.viewBackupResult()
+	}
+	return ""
+}
+
+func (m *Model) viewBackupEnterDir() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("💾 Backup Everything")))
+	s.WriteString("\n\n")
+	s.WriteString(fmt.Sprintf("Export the connections of all %d profiles, one file and key per profile.\n", len(m.Profile.profiles)))
+	s.WriteString("Unreachable profiles are skipped.\n\n")
+	s.WriteString("Backup directory:\n")
+	s.WriteString(m.Backup.dirInput.View())
+	s.WriteString("\n\n")
+
+	if m.Backup.err != "" {
+		s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Backup.err))
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(SubtleStyle.Render("[Enter] start backup  [Esc] back"))
+
+	return s.String()
+}
+
+func (m *Model) viewBackupProcessing() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("💾 Backup Everything")))
+	s.WriteString("\n\n")
+	s.WriteString(fmt.Sprintf("Exporting %d profiles...\n", len(m.Profile.profiles)))
+
+	return s.String()
+}
+
+func (m *Model) viewBackupResult() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("💾 Backup Complete")))
+	s.WriteString("\n\n")
+
+	if m.Backup.err != "" {
+		s.WriteString(ErrorStyle.Render(glyph("✗ Backup failed: ") + m.Backup.err))
+		s.WriteString("\n\n")
+	} else if r := m.Backup.result; r != nil {
+		s.WriteString(SuccessStyle.Render(fmt.Sprintf(glyph("✓ Backed up %d profiles"), len(r.Manifest.Profiles))))
+		s.WriteString("\n\n")
+
+		for _, e := range r.Manifest.Profiles {
+			s.WriteString(fmt.Sprintf(glyph("  %s: %d connections → %s\n"), e.ProfileName, e.ConnectionCount, filepath.Join(r.Directory, e.File)))
+		}
+		for _, f := range r.Skipped {
+			s.WriteString(ErrorStyle.Render(fmt.Sprintf(glyph("  ✗ %s skipped: %s"), f.ProfileName, f.Error)))
+			s.WriteString("\n")
+		}
+		s.WriteString("\n")
+		s.WriteString(fmt.Sprintf("File keys are in %s, keep it safe.\n\n", r.ManifestPath))
+	}
+
+	s.WriteString(SubtleStyle.Render("[Enter] done"))
+
+	return s.String()
+}
@@ -2,9 +2,11 @@ package tui
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -20,7 +22,10 @@ type exportState int
 const (
 	exportSelectProfile exportState = iota
 	exportLoadingConnections
+	exportLoadError
 	exportSelectConnections
+	exportInspect
+	exportConfirmLarge
 	exportEnterKey
 	exportProcessing
 	exportResult
@@ -32,34 +37,140 @@ type exportModel struct {
 	profiles        []models.ProfileSummary
 	profileCursor   int
 	selectedProfile *models.Profile
-	connections     []*models.Connection
+	connections     []models.ConnectionMeta // allConnections matching the filter, as listed
+	allConnections  []models.ConnectionMeta
+	dbCount         int // Connections in the database when the list was loaded
+	inspected       *models.Connection
 	selected        map[string]bool
 	connCursor      int
 	keyInput        textinput.Model
 	result          *exportResultData
 	err             string
 	copied          bool
+	confirmedLarge  bool     // The user confirmed exporting more than largeExportThreshold connections
+	lastSelection   []string // Connection IDs of the last export of the selected profile
+	usePassphrase   bool     // keyInput holds a passphrase to derive the file key from
+	keyGenerated    bool     // keyInput holds a key generated with Ctrl+G, shown in clear
+
+	// filter narrows the list to connections matching by ID, type or description; '/' edits it
+	filter    textinput.Model
+	filtering bool
+
+	// sets loads a saved connection set into the selection or saves it as one
+	sets setPrompt
+}
+
+// lastExportSelectionKey is the secret holding the connection IDs last exported from a profile.
+func lastExportSelectionKey(profileID string) string {
+	profile := models.Profile{ID: profileID}
+	return profile.GetSecretKeys().LastExportSelection
+}
+
+// loadLastExportSelection returns the connection IDs last exported from a profile, or nil.
+func (m *Model) loadLastExportSelection(profileID string) []string {
+	data, err := m.Secrets.Get(lastExportSelectionKey(profileID))
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	if err := json.Unmarshal([]byte(data), &ids); err != nil {
+		return nil
+	}
+	return ids
 }
 
+// saveLastExportSelection remembers the exported connection IDs for the next export of a profile.
+func (m *Model) saveLastExportSelection(profileID string, ids []string) error {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return err
+	}
+	return m.Secrets.Set(lastExportSelectionKey(profileID), string(data))
+}
+
+// restoreLastExportSelection checks the connections of the last export that still exist
+// and returns how many were found.
+func (m *Model) restoreLastExportSelection() int {
+	exists := make(map[string]bool, len(m.Export.allConnections))
+	for _, c := range m.Export.allConnections {
+		exists[c.ID] = true
+	}
+	restored := 0
+	m.Export.selected = make(map[string]bool)
+	for _, id := range m.Export.lastSelection {
+		if exists[id] {
+			m.Export.selected[id] = true
+			restored++
+		}
+	}
+	return restored
+}
+
+// largeExportThreshold is the selection size above which an export must be confirmed.
+const largeExportThreshold = 500
+
 type exportResultData struct {
 	filename  string
 	location  string
 	fernetKey string
 	count     int
+	warnings  []string
 }
 
 func newExportModel() exportModel {
 	keyInput := textinput.New()
 	keyInput.Placeholder = "Leave empty to auto-generate"
 	keyInput.EchoMode = textinput.EchoPassword
-	keyInput.EchoCharacter = '•'
+	keyInput.EchoCharacter = maskGlyph()
 	keyInput.CharLimit = 256
 
+	filter := textinput.New()
+	filter.Placeholder = "ID, type or description"
+	filter.CharLimit = 256
+
 	return exportModel{
 		state:    exportSelectProfile,
 		selected: make(map[string]bool),
 		keyInput: keyInput,
+		filter:   filter,
+		sets:     newSetPrompt(),
+	}
+}
+
+// setExportConnections replaces the loaded connections, keeping the current filter.
+func (m *Model) setExportConnections(connections []models.ConnectionMeta, dbCount int) {
+	m.Export.allConnections = connections
+	m.Export.dbCount = dbCount
+	m.applyExportFilter()
+}
+
+// applyExportFilter lists the loaded connections matching the filter. Selections of
+// hidden connections are kept.
+func (m *Model) applyExportFilter() {
+	m.Export.connCursor = 0
+	query := m.Export.filter.Value()
+	if query == "" {
+		m.Export.connections = m.Export.allConnections
+		return
+	}
+
+	m.Export.connections = nil
+	for _, c := range m.Export.allConnections {
+		if c.Matches(query) {
+			m.Export.connections = append(m.Export.connections, c)
+		}
+	}
+}
+
+// exportConnectionIDs returns the IDs of connections.
+func exportConnectionIDs(connections []models.ConnectionMeta) []string {
+	ids := make([]string, len(connections))
+	for i, c := range connections {
+		ids[i] = c.ID
 	}
+	return ids
 }
 
 func (m *Model) resetExport() {
@@ -68,12 +179,66 @@ func (m *Model) resetExport() {
 	m.Export.profiles = m.Profile.profiles
 }
 
+// exportProfile opens the export screen on a profile, skipping its profile list.
+func (m *Model) exportProfile(profileID string) tea.Cmd {
+	m.State = StateExport
+	m.resetExport()
+	for i, p := range m.Export.profiles {
+		if p.ID == profileID {
+			m.Export.profileCursor = i
+		}
+	}
+
+	m.Export.selectedProfile = m.loadFullProfile(profileID)
+	if m.Export.selectedProfile == nil {
+		m.Export.err = "Failed to load profile"
+		return nil
+	}
+	m.Export.state = exportLoadingConnections
+	return m.fetchConnections()
+}
+
+// StartExportWith opens the TUI on the export of the profile with this name or ID, as
+// asked by -profile. A missing or ambiguous name leaves the main menu showing why.
+func (m *Model) StartExportWith(nameOrID string) {
+	m.loadProfiles()
+
+	var matches []models.ProfileSummary
+	for _, p := range m.Profile.profiles {
+		// An exact ID match wins over names
+		if p.ID == nameOrID {
+			matches = []models.ProfileSummary{p}
+			break
+		}
+		if p.Name == nameOrID {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		m.State = StateMainMenu
+		m.startErr = fmt.Sprintf("Profile %q not found", nameOrID)
+	case 1:
+		m.initCmd = m.exportProfile(matches[0].ID)
+	default:
+		m.State = StateMainMenu
+		m.startErr = fmt.Sprintf("%d profiles are named %q, pass the profile ID instead", len(matches), nameOrID)
+	}
+}
+
 func (m *Model) updateExport(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch m.Export.state {
 	case exportSelectProfile:
 		return m.updateExportSelectProfile(msg)
+	case exportLoadError:
+		return m.updateExportLoadError(msg)
 	case exportSelectConnections:
 		return m.updateExportSelectConnections(msg)
+	case exportInspect:
+		return m.updateExportInspect(msg)
+	case exportConfirmLarge:
+		return m.updateExportConfirmLarge(msg)
 	case exportEnterKey:
 		return m.updateExportEnterKey(msg)
 	case exportResult:
@@ -84,6 +249,9 @@ func (m *Model) updateExport(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) updateExportSelectProfile(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		m.Export.profileCursor, _ = wheelCursor(msg, m.Export.profileCursor, len(m.Export.profiles))
+		return m, nil
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "q", "esc":
@@ -116,9 +284,42 @@ func (m *Model) updateExportSelectProfile(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateExportLoadError offers a retry or a fix of the profile when loading connections failed.
+func (m *Model) updateExportLoadError(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			// Reload the profile too, it may have been edited since
+			if profile := m.loadFullProfile(m.Export.selectedProfile.ID); profile != nil {
+				m.Export.selectedProfile = profile
+			}
+			m.Export.state = exportLoadingConnections
+			m.Export.err = ""
+			return m, m.fetchConnections()
+		case "e":
+			m.State = StateProfiles
+			m.loadProfiles()
+			m.Profile.state = profileEdit
+			m.Profile.editingID = m.Export.selectedProfile.ID
+			m.loadProfileIntoForm(m.Profile.editingID)
+			return m, nil
+		case "esc":
+			m.Export.state = exportSelectProfile
+			m.Export.err = ""
+			return m, nil
+		case "q":
+			m.State = StateMainMenu
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
 // Message type for async connection fetching
 type connectionsLoadedMsg struct {
-	connections []*models.Connection
+	connections []models.ConnectionMeta
+	dbCount     int // From CountConnections, the list length if counting failed
 	err         error
 }
 
@@ -127,18 +328,82 @@ func (m *Model) fetchConnections() tea.Cmd {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		connections, err := m.Migrator.ListConnections(ctx, m.Export.selectedProfile)
-		return connectionsLoadedMsg{connections: connections, err: err}
+		connections, err := m.Migrator.ListConnectionMeta(ctx, m.Export.selectedProfile)
+		if err != nil {
+			return connectionsLoadedMsg{err: err}
+		}
+		dbCount, err := m.Migrator.CountConnections(ctx, m.Export.selectedProfile)
+		if err != nil {
+			dbCount = len(connections)
+		}
+		return connectionsLoadedMsg{connections: connections, dbCount: dbCount}
+	}
+}
+
+// updateExportFilter edits the list filter. Enter keeps it, Esc clears it.
+func (m *Model) updateExportFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			m.Export.filtering = false
+			m.Export.filter.Blur()
+			return m, nil
+		case "esc":
+			m.Export.filtering = false
+			m.Export.filter.Blur()
+			m.Export.filter.SetValue("")
+			m.applyExportFilter()
+			return m, nil
+		}
 	}
+
+	var cmd tea.Cmd
+	m.Export.filter, cmd = m.Export.filter.Update(msg)
+	m.applyExportFilter()
+	return m, cmd
 }
 
 func (m *Model) updateExportSelectConnections(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		if cursor, ok := wheelCursor(msg, m.Export.connCursor, len(m.Export.connections)); ok {
+			m.Export.connCursor = cursor
+			return m, nil
+		}
+		if !isLeftClick(msg) || len(m.Export.connections) == 0 {
+			return m, nil
+		}
+		start, end := listWindow(len(m.Export.connections), m.Export.connCursor, m.Height)
+		if i := listRowAt(msg.Y, m.exportListHeader(), start, end); i >= 0 {
+			m.Export.connCursor = i
+			connID := m.Export.connections[i].ID
+			m.Export.selected[connID] = !m.Export.selected[connID]
+		}
+		return m, nil
 	case tea.KeyMsg:
+		if m.Export.filtering {
+			return m.updateExportFilter(msg)
+		}
+		if m.Export.sets.active {
+			var cmd tea.Cmd
+			m.Export.err, cmd = m.updateSetPrompt(&m.Export.sets, msg, m.Export.selected, exportConnectionIDs(m.Export.allConnections))
+			return m, cmd
+		}
+		m.Export.sets.notice = ""
 		switch msg.String() {
 		case "q", "esc":
+			// Esc clears an active filter before leaving the list
+			if msg.String() == "esc" && m.Export.filter.Value() != "" {
+				m.Export.filter.SetValue("")
+				m.applyExportFilter()
+				return m, nil
+			}
 			m.Export.state = exportSelectProfile
 			return m, nil
+		case "/":
+			m.Export.filtering = true
+			m.Export.err = ""
+			return m, m.Export.filter.Focus()
 		case "up", "k":
 			if m.Export.connCursor > 0 {
 				m.Export.connCursor--
@@ -153,6 +418,11 @@ func (m *Model) updateExportSelectConnections(msg tea.Msg) (tea.Model, tea.Cmd)
 				connID := m.Export.connections[m.Export.connCursor].ID
 				m.Export.selected[connID] = !m.Export.selected[connID]
 			}
+		case "i":
+			if len(m.Export.connections) > 0 {
+				m.inspectConnection(m.Export.connections[m.Export.connCursor].ID)
+			}
+			return m, nil
 		case "a":
 			// Select all
 			for _, c := range m.Export.connections {
@@ -163,6 +433,20 @@ func (m *Model) updateExportSelectConnections(msg tea.Msg) (tea.Model, tea.Cmd)
 			for _, c := range m.Export.connections {
 				m.Export.selected[c.ID] = false
 			}
+		case "r":
+			// Restore the selection of the last export
+			if len(m.Export.lastSelection) == 0 {
+				m.Export.err = "No previous export of this profile"
+				return m, nil
+			}
+			if m.restoreLastExportSelection() == 0 {
+				m.Export.err = "None of the last exported connections exist anymore"
+				return m, nil
+			}
+			m.Export.err = ""
+		case "l", "s":
+			m.Export.err = ""
+			return m, m.Export.sets.open(msg.String() == "s")
 		case "enter":
 			// Check if any selected
 			selectedCount := 0
@@ -175,6 +459,12 @@ func (m *Model) updateExportSelectConnections(msg tea.Msg) (tea.Model, tea.Cmd)
 				m.Export.err = "Please select at least one connection"
 				return m, nil
 			}
+			m.Export.err = ""
+			m.Export.confirmedLarge = false
+			if selectedCount > largeExportThreshold {
+				m.Export.state = exportConfirmLarge
+				return m, nil
+			}
 			m.Export.state = exportEnterKey
 			m.Export.keyInput.Focus()
 			m.Export.err = ""
@@ -184,6 +474,67 @@ func (m *Model) updateExportSelectConnections(msg tea.Msg) (tea.Model, tea.Cmd)
 	return m, nil
 }
 
+// passwordStatus tells an encrypted password from a plain text or missing one.
+func passwordStatus(hasPassword, encrypted bool) string {
+	switch {
+	case !hasPassword:
+		return "no password"
+	case encrypted:
+		return glyph("🔒 encrypted")
+	default:
+		return glyph("🔓 plain text")
+	}
+}
+
+// inspectConnection loads the full connection for the inspect view.
+func (m *Model) inspectConnection(connID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := m.Migrator.GetConnection(ctx, m.Export.selectedProfile, connID)
+	if err != nil {
+		m.Export.err = "Failed to load connection: " + err.Error()
+		return
+	}
+	if conn == nil {
+		m.Export.err = "Connection " + connID + " no longer exists"
+		return
+	}
+
+	m.Export.inspected = conn
+	m.Export.err = ""
+	m.Export.state = exportInspect
+}
+
+func (m *Model) updateExportInspect(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "i", "enter":
+			m.Export.state = exportSelectConnections
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) updateExportConfirmLarge(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y":
+			m.Export.confirmedLarge = true
+			m.Export.state = exportEnterKey
+			m.Export.keyInput.Focus()
+			return m, nil
+		case "n", "N", "esc":
+			m.Export.state = exportSelectConnections
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
 func (m *Model) updateExportEnterKey(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -191,15 +542,43 @@ func (m *Model) updateExportEnterKey(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc":
 			m.Export.state = exportSelectConnections
 			return m, nil
+		case "ctrl+p":
+			m.Export.usePassphrase = !m.Export.usePassphrase
+			m.Export.keyGenerated = false
+			m.Export.keyInput.EchoMode = textinput.EchoPassword
+			m.Export.err = ""
+			return m, nil
+		case "ctrl+g":
+			// Like the profile form, and shown so the key can be noted before exporting
+			key, err := m.Migrator.GenerateFernetKey()
+			if err != nil {
+				m.Export.err = "Failed to generate Fernet key: " + err.Error()
+				return m, nil
+			}
+			m.Export.usePassphrase = false
+			m.Export.keyInput.SetValue(key)
+			m.Export.keyInput.EchoMode = textinput.EchoNormal
+			m.Export.keyGenerated = true
+			m.Export.err = ""
+			return m, nil
 		case "enter":
+			if m.Export.usePassphrase && m.Export.keyInput.Value() == "" {
+				m.Export.err = "Enter a passphrase, or press Ctrl+P to use a key"
+				return m, nil
+			}
 			// Perform export
+			m.Export.err = ""
 			m.Export.state = exportProcessing
 			return m, m.performExport()
 		}
 	}
 
 	var cmd tea.Cmd
+	before := m.Export.keyInput.Value()
 	m.Export.keyInput, cmd = m.Export.keyInput.Update(msg)
+	if m.Export.keyInput.Value() != before {
+		m.Export.keyGenerated = false
+	}
 	return m, cmd
 }
 
@@ -218,9 +597,11 @@ func (m *Model) performExport() tea.Cmd {
 			}
 		}
 
-		// Get or generate Fernet key
-		fernetKey := m.Export.keyInput.Value()
-		if fernetKey == "" {
+		// Get or generate Fernet key, unless it is derived from a passphrase
+		fernetKey, passphrase := m.Export.keyInput.Value(), ""
+		if m.Export.usePassphrase {
+			fernetKey, passphrase = "", fernetKey
+		} else if fernetKey == "" {
 			var err error
 			fernetKey, err = m.Migrator.GenerateFernetKey()
 			if err != nil {
@@ -241,7 +622,11 @@ func (m *Model) performExport() tea.Cmd {
 			SourceProfile:     m.Export.selectedProfile,
 			OutputPath:        tempPath,
 			FileEncryptionKey: fernetKey,
+			FilePassphrase:    passphrase,
 			ConnectionIDs:     selectedIDs,
+			MaxConnections:    largeExportThreshold,
+			Force:             m.Export.confirmedLarge,
+			FilenameTemplate:  m.FilenameTemplate,
 		}
 
 		// Perform export
@@ -256,6 +641,10 @@ func (m *Model) performExport() tea.Cmd {
 			return exportCompleteMsg{err: fmt.Errorf("%s", result.Error)}
 		}
 
+		// The filename template may have renamed the file
+		tempPath = result.OutputPath
+		filename = filepath.Base(tempPath)
+
 		// Copy to current directory
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -278,12 +667,19 @@ func (m *Model) performExport() tea.Cmd {
 		// Clean up temp file
 		os.Remove(tempPath)
 
+		var warnings []string
+		warnings = append(warnings, result.Warnings...)
+		if err := m.saveLastExportSelection(m.Export.selectedProfile.ID, result.ExportedIDs); err != nil {
+			warnings = append(warnings, "Failed to remember this selection: "+err.Error())
+		}
+
 		return exportCompleteMsg{
 			result: &exportResultData{
 				filename:  filename,
 				location:  destPath,
 				fernetKey: fernetKey,
 				count:     result.ConnectionCount,
+				warnings:  warnings,
 			},
 		}
 	}
@@ -317,8 +713,14 @@ func (m *Model) viewExport() string {
 		return m.viewExportSelectProfile()
 	case exportLoadingConnections:
 		return m.viewExportLoading()
+	case exportLoadError:
+		return m.viewExportLoadError()
 	case exportSelectConnections:
 		return m.viewExportSelectConnections()
+	case exportInspect:
+		return m.viewExportInspect()
+	case exportConfirmLarge:
+		return m.viewExportConfirmLarge()
 	case exportEnterKey:
 		return m.viewExportEnterKey()
 	case exportProcessing:
@@ -332,18 +734,18 @@ func (m *Model) viewExport() string {
 func (m *Model) viewExportSelectProfile() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📤 Export Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📤 Export Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("Select source profile:\n\n")
 
 	if len(m.Export.profiles) == 0 {
-		s.WriteString(SubtleStyle.Render("No profiles available. Create one first."))
+		s.WriteString(SubtleStyle.Render("No profiles yet. Press [1] on the main menu to add one."))
 		s.WriteString("\n\n")
 	} else {
 		for i, p := range m.Export.profiles {
 			cursor := "  "
 			if i == m.Export.profileCursor {
-				cursor = "▸ "
+				cursor = glyph("▸ ")
 			}
 
 			line := fmt.Sprintf("%s%s", cursor, p.Name)
@@ -362,7 +764,7 @@ func (m *Model) viewExportSelectProfile() string {
 	}
 
 	if m.Export.err != "" {
-		s.WriteString(ErrorStyle.Render("✗ " + m.Export.err))
+		s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Export.err))
 		s.WriteString("\n\n")
 	}
 
@@ -374,7 +776,7 @@ func (m *Model) viewExportSelectProfile() string {
 func (m *Model) viewExportLoading() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📤 Export Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📤 Export Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("Loading connections from ")
 	s.WriteString(SelectedStyle.Render(m.Export.selectedProfile.Name))
@@ -383,10 +785,27 @@ func (m *Model) viewExportLoading() string {
 	return s.String()
 }
 
-func (m *Model) viewExportSelectConnections() string {
+func (m *Model) viewExportLoadError() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("📤 Export Connections")))
+	s.WriteString("\n\n")
+	s.WriteString("Failed to load connections from ")
+	s.WriteString(SelectedStyle.Render(m.Export.selectedProfile.Name))
+	s.WriteString(":\n\n")
+	s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Export.err))
+	s.WriteString("\n\n")
+	s.WriteString(SubtleStyle.Render("[r]etry  [e]dit profile  [Esc] back  [q] main menu"))
+
+	return s.String()
+}
+
+// exportListHeader renders everything above the connection rows. Mouse handling
+// relies on it to map clicks back to rows.
+func (m *Model) exportListHeader() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📤 Export Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📤 Export Connections")))
 	s.WriteString("\n\n")
 
 	// Count selected
@@ -397,40 +816,38 @@ func (m *Model) viewExportSelectConnections() string {
 		}
 	}
 
-	s.WriteString(fmt.Sprintf("Select connections to export (%d/%d selected):\n\n",
-		selectedCount, len(m.Export.connections)))
+	if !m.Export.filtering && m.Export.filter.Value() == "" {
+		s.WriteString(fmt.Sprintf("Select connections to export (%d/%d selected):\n\n",
+			selectedCount, len(m.Export.allConnections)))
+		return s.String()
+	}
+
+	// The database total shows that hidden connections are filtered out, not gone
+	s.WriteString("Filter: " + m.Export.filter.View())
+	s.WriteString("\n\n")
+	s.WriteString(fmt.Sprintf("Select connections to export (%d selected, showing %d of %d):\n\n",
+		selectedCount, len(m.Export.connections), m.Export.dbCount))
 
-	if len(m.Export.connections) == 0 {
-		s.WriteString(SubtleStyle.Render("No connections found in this database."))
-		s.WriteString("\n\n")
-	} else {
-		// Dynamic max visible based on terminal height
-		// Reserve space for: title(2) + header(2) + footer(4) + messages(2) = ~10 lines
-		maxVisible := m.Height - 10
-		if maxVisible < 5 {
-			maxVisible = 5
-		}
-		if maxVisible > len(m.Export.connections) {
-			maxVisible = len(m.Export.connections)
-		}
+	return s.String()
+}
 
-		startIdx := 0
-		endIdx := len(m.Export.connections)
+func (m *Model) viewExportSelectConnections() string {
+	var s strings.Builder
 
-		if len(m.Export.connections) > maxVisible {
-			startIdx = m.Export.connCursor - maxVisible/2
-			if startIdx < 0 {
-				startIdx = 0
-			}
-			endIdx = startIdx + maxVisible
-			if endIdx > len(m.Export.connections) {
-				endIdx = len(m.Export.connections)
-				startIdx = endIdx - maxVisible
-			}
+	s.WriteString(m.exportListHeader())
+
+	if len(m.Export.connections) == 0 {
+		if len(m.Export.allConnections) > 0 {
+			s.WriteString(SubtleStyle.Render("No connections match the filter. Press Esc to clear it."))
+		} else {
+			s.WriteString(SubtleStyle.Render("No connections found in this database."))
 		}
+		s.WriteString("\n\n")
+	} else {
+		startIdx, endIdx := listWindow(len(m.Export.connections), m.Export.connCursor, m.Height)
 
 		if startIdx > 0 {
-			s.WriteString(SubtleStyle.Render("    ↑ more above"))
+			s.WriteString(SubtleStyle.Render(glyph("    ↑ more above")))
 			s.WriteString("\n\n")
 		}
 
@@ -438,16 +855,16 @@ func (m *Model) viewExportSelectConnections() string {
 			c := m.Export.connections[i]
 			cursor := "  "
 			if i == m.Export.connCursor {
-				cursor = "▸ "
+				cursor = glyph("▸ ")
 			}
 
 			checkbox := "[ ]"
 			if m.Export.selected[c.ID] {
-				checkbox = "[✓]"
+				checkbox = glyph("[✓]")
 			}
 
 			line := fmt.Sprintf("%s%s %s", cursor, checkbox, c.ID)
-			detail := fmt.Sprintf(" (%s)", c.ConnType)
+			detail := fmt.Sprintf(" (%s, %s)", c.ConnType, passwordStatus(c.HasPassword, c.IsEncrypted))
 
 			if i == m.Export.connCursor {
 				s.WriteString(SelectedStyle.Render(line))
@@ -461,18 +878,93 @@ func (m *Model) viewExportSelectConnections() string {
 
 		if endIdx < len(m.Export.connections) {
 			s.WriteString("\n")
-			s.WriteString(SubtleStyle.Render("    ↓ more below"))
+			s.WriteString(SubtleStyle.Render(glyph("    ↓ more below")))
 		}
 
 		s.WriteString("\n\n")
 	}
 
+	s.WriteString(m.viewSetPrompt(&m.Export.sets))
 	if m.Export.err != "" {
-		s.WriteString(ErrorStyle.Render("✗ " + m.Export.err))
+		s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Export.err))
 		s.WriteString("\n\n")
 	}
 
-	s.WriteString(SubtleStyle.Render("[Space] toggle  [a]ll  [n]one  [Enter] continue  [Esc] back"))
+	if m.Export.filtering {
+		s.WriteString(SubtleStyle.Render("[Enter] apply filter  [Esc] clear"))
+		return s.String()
+	}
+	if m.Export.sets.active {
+		s.WriteString(SubtleStyle.Render("[Enter] confirm  [Esc] cancel"))
+		return s.String()
+	}
+	s.WriteString(SubtleStyle.Render("[Space] toggle  [a]ll  [n]one  [/] filter  [r]estore last  [l]oad/[s]ave set  [i]nspect  [Enter] continue  [Esc] back"))
+
+	return s.String()
+}
+
+func (m *Model) viewExportInspect() string {
+	var s strings.Builder
+
+	c := m.Export.inspected
+
+	s.WriteString(TitleStyle.Render(glyph("🔍 ") + c.ID))
+	s.WriteString("\n\n")
+
+	secret := func(value string, encrypted bool) string {
+		if value == "" {
+			return "(empty)"
+		}
+		if encrypted {
+			return "set, encrypted"
+		}
+		return "set, plain text"
+	}
+
+	s.WriteString(fmt.Sprintf("  Type:        %s\n", c.ConnType))
+	s.WriteString(fmt.Sprintf("  Description: %s\n", c.Description))
+	s.WriteString(fmt.Sprintf("  Host:        %s\n", c.Host))
+	s.WriteString(fmt.Sprintf("  Port:        %d\n", c.Port))
+	s.WriteString(fmt.Sprintf("  Schema:      %s\n", c.Schema))
+	s.WriteString(fmt.Sprintf("  Login:       %s\n", c.Login))
+	s.WriteString(fmt.Sprintf("  Password:    %s\n", secret(c.Password, c.IsEncrypted)))
+	s.WriteString(fmt.Sprintf("  Extra:       %s\n", secret(c.Extra, c.IsExtraEncrypted)))
+	s.WriteString("\n")
+
+	if len(c.Lints) == 0 {
+		s.WriteString(SuccessStyle.Render(glyph("✓ No lint findings")))
+		s.WriteString("\n")
+	} else {
+		for _, lint := range c.Lints {
+			s.WriteString(ErrorStyle.Render(glyph("⚠ ") + lint))
+			s.WriteString("\n")
+		}
+	}
+	s.WriteString("\n")
+
+	s.WriteString(SubtleStyle.Render("[Esc] back"))
+
+	return s.String()
+}
+
+func (m *Model) viewExportConfirmLarge() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("⚠️  Large Export")))
+	s.WriteString("\n\n")
+
+	selectedCount := 0
+	for _, v := range m.Export.selected {
+		if v {
+			selectedCount++
+		}
+	}
+
+	s.WriteString(fmt.Sprintf("%d of %d connections are selected from ", selectedCount, len(m.Export.connections)))
+	s.WriteString(SelectedStyle.Render(m.Export.selectedProfile.Name))
+	s.WriteString(".\n\n")
+	s.WriteString("Export all of them?\n\n")
+	s.WriteString(SubtleStyle.Render("[y]es  [n]o, back to the selection"))
 
 	return s.String()
 }
@@ -480,7 +972,7 @@ func (m *Model) viewExportSelectConnections() string {
 func (m *Model) viewExportEnterKey() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📤 Export Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📤 Export Connections")))
 	s.WriteString("\n\n")
 
 	// Count selected
@@ -495,13 +987,29 @@ func (m *Model) viewExportEnterKey() string {
 	s.WriteString(SelectedStyle.Render(m.Export.selectedProfile.Name))
 	s.WriteString("\n\n")
 
-	s.WriteString("Enter Fernet key for file encryption:\n")
-	s.WriteString(m.Export.keyInput.View())
-	s.WriteString("\n")
-	s.WriteString(SubtleStyle.Render("(Leave empty to auto-generate a new key)"))
+	if m.Export.usePassphrase {
+		s.WriteString("Enter a passphrase for file encryption:\n")
+		s.WriteString(m.Export.keyInput.View())
+		s.WriteString("\n")
+		s.WriteString(SubtleStyle.Render("(The import asks for the same passphrase)"))
+	} else {
+		s.WriteString("Enter Fernet key for file encryption:\n")
+		s.WriteString(m.Export.keyInput.View())
+		s.WriteString("\n")
+		if m.Export.keyGenerated {
+			s.WriteString(SuccessStyle.Render(glyph("✓ Fernet key generated, note it down: the import needs it")))
+		} else {
+			s.WriteString(SubtleStyle.Render("(Leave empty to auto-generate a new key, or press Ctrl+G to see it now)"))
+		}
+	}
 	s.WriteString("\n\n")
 
-	s.WriteString(SubtleStyle.Render("[Enter] export  [Esc] back"))
+	if m.Export.err != "" {
+		s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Export.err))
+		s.WriteString("\n\n")
+	}
+
+	s.WriteString(SubtleStyle.Render("[Enter] export  [Ctrl+G] generate key  [Ctrl+P] key/passphrase  [Esc] back"))
 
 	return s.String()
 }
@@ -509,7 +1017,7 @@ func (m *Model) viewExportEnterKey() string {
 func (m *Model) viewExportProcessing() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📤 Export Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📤 Export Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("Exporting connections...\n")
 
@@ -519,25 +1027,34 @@ func (m *Model) viewExportProcessing() string {
 func (m *Model) viewExportResult() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📤 Export Complete"))
+	s.WriteString(TitleStyle.Render(glyph("📤 Export Complete")))
 	s.WriteString("\n\n")
 
 	if m.Export.err != "" {
-		s.WriteString(ErrorStyle.Render("✗ Export failed: " + m.Export.err))
+		s.WriteString(ErrorStyle.Render(glyph("✗ Export failed: ") + m.Export.err))
 		s.WriteString("\n\n")
 	} else if m.Export.result != nil {
-		s.WriteString(SuccessStyle.Render("✓ Export successful!"))
+		s.WriteString(SuccessStyle.Render(glyph("✓ Export successful!")))
 		s.WriteString("\n\n")
 
 		s.WriteString(fmt.Sprintf("Connections exported: %d\n", m.Export.result.count))
 		s.WriteString(fmt.Sprintf("Filename: %s\n", m.Export.result.filename))
 		s.WriteString(fmt.Sprintf("Location: %s\n", m.Export.result.location))
+		for _, warning := range m.Export.result.warnings {
+			s.WriteString(ErrorStyle.Render(glyph("⚠ ") + warning))
+			s.WriteString("\n")
+		}
 		s.WriteString("\n")
+		if m.Export.result.fernetKey == "" {
+			s.WriteString("Encrypted with your passphrase, the import asks for it.\n\n")
+			s.WriteString(SubtleStyle.Render("[Enter] done"))
+			return s.String()
+		}
 		s.WriteString("Fernet Key (save this to decrypt the file):\n")
 		s.WriteString(SelectedStyle.Render(m.Export.result.fernetKey))
 		if m.Export.copied {
 			s.WriteString("  ")
-			s.WriteString(SuccessStyle.Render("✓ Copied!"))
+			s.WriteString(SuccessStyle.Render(glyph("✓ Copied!")))
 		}
 		s.WriteString("\n\n")
 	}
@@ -21,11 +21,13 @@ const (
 	importSelectFile importState = iota
 	importEnterKey
 	importDecrypting
+	importDecryptError
 	importSelectConnections
 	importEnterPrefix
 	importSelectProfile
 	importSelectStrategy
 	importConfirm
+	importConfirmPurge
 	importProcessing
 	importResult
 )
@@ -46,23 +48,34 @@ type importModel struct {
 	selectedProfile *models.Profile
 	strategies      []string
 	strategyCursor  int
+	continueOnError bool
+	ignoreCase      bool
+	mergeExtra      bool // Only applies to the overwrite strategy
+	purge           bool
 	result          *importResultData
 	err             string
 	fileKey         string
+	keyChecked      bool // keyMatches holds the result of a quick check of the typed key
+	keyMatches      bool
+
+	// sets loads a saved connection set into the selection or saves it as one
+	sets setPrompt
 }
 
 type importResultData struct {
 	imported  int
 	skipped   int
 	overwrote int
+	purged    int
 	errors    []string
+	variants  []string
 }
 
 func newImportModel() importModel {
 	keyInput := textinput.New()
-	keyInput.Placeholder = "Enter Fernet key to decrypt file"
+	keyInput.Placeholder = "Fernet key or passphrase"
 	keyInput.EchoMode = textinput.EchoPassword
-	keyInput.EchoCharacter = '•'
+	keyInput.EchoCharacter = maskGlyph()
 	keyInput.CharLimit = 256
 
 	prefixInput := textinput.New()
@@ -75,6 +88,7 @@ func newImportModel() importModel {
 		keyInput:    keyInput,
 		prefixInput: prefixInput,
 		strategies:  []string{"skip", "overwrite", "stop"},
+		sets:        newSetPrompt(),
 	}
 }
 
@@ -113,6 +127,8 @@ func (m *Model) updateImport(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case importDecrypting:
 		// Handled by async message
 		return m, nil
+	case importDecryptError:
+		return m.updateImportDecryptError(msg)
 	case importSelectConnections:
 		return m.updateImportSelectConnections(msg)
 	case importEnterPrefix:
@@ -123,6 +139,8 @@ func (m *Model) updateImport(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateImportSelectStrategy(msg)
 	case importConfirm:
 		return m.updateImportConfirm(msg)
+	case importConfirmPurge:
+		return m.updateImportConfirmPurge(msg)
 	case importResult:
 		return m.updateImportResult(msg)
 	}
@@ -131,6 +149,9 @@ func (m *Model) updateImport(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) updateImportSelectFile(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		m.Import.fileCursor, _ = wheelCursor(msg, m.Import.fileCursor, len(m.Import.files))
+		return m, nil
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "q", "esc":
@@ -157,6 +178,7 @@ func (m *Model) updateImportSelectFile(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.Import.state = importEnterKey
 				m.Import.keyInput.Focus()
 				m.Import.err = ""
+				m.checkImportKey()
 				return m, nil
 			}
 		}
@@ -171,11 +193,17 @@ func (m *Model) updateImportEnterKey(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "esc":
 			m.Import.state = importSelectFile
 			m.Import.keyInput.SetValue("")
+			m.Import.keyChecked = false
 			return m, nil
 		case "enter":
 			key := m.Import.keyInput.Value()
 			if key == "" {
-				m.Import.err = "Fernet key is required"
+				m.Import.err = "Fernet key or passphrase is required"
+				return m, nil
+			}
+			// Fail fast instead of decrypting the whole file with the wrong key
+			if m.Import.keyChecked && !m.Import.keyMatches {
+				m.Import.err = "This key does not decrypt the selected file"
 				return m, nil
 			}
 			m.Import.fileKey = key
@@ -186,10 +214,71 @@ func (m *Model) updateImportEnterKey(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	var cmd tea.Cmd
+	previous := m.Import.keyInput.Value()
 	m.Import.keyInput, cmd = m.Import.keyInput.Update(msg)
+	if m.Import.keyInput.Value() != previous {
+		m.checkImportKey()
+	}
 	return m, cmd
 }
 
+// checkImportKey tries the typed key against the first record of the selected file,
+// so a wrong key is reported while typing rather than after a full decrypt.
+func (m *Model) checkImportKey() {
+	m.Import.keyChecked = false
+	filePath, err := m.importFilePath()
+	if err != nil {
+		return
+	}
+	// Deriving a passphrase key on every keystroke is too slow, those are checked on Enter
+	if salt, err := services.ReadPassphraseSalt(filePath); err != nil || salt != nil {
+		return
+	}
+	fernet, err := services.NewFernet(m.Import.keyInput.Value())
+	if err != nil {
+		return
+	}
+	ok, err := services.CanDecryptFile(filePath, fernet)
+	if err != nil {
+		return
+	}
+	m.Import.keyChecked = true
+	m.Import.keyMatches = ok
+	if ok {
+		m.Import.err = ""
+	}
+}
+
+// importFilePath returns the absolute path of the selected import file.
+func (m *Model) importFilePath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Join(cwd, m.Import.selectedFile), nil
+}
+
+// updateImportDecryptError offers a retry or another key when decrypting the file failed.
+func (m *Model) updateImportDecryptError(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			m.Import.state = importDecrypting
+			m.Import.err = ""
+			return m, m.decryptImportFile()
+		case "k", "esc":
+			m.Import.state = importEnterKey
+			m.Import.err = ""
+			return m, nil
+		case "q":
+			m.State = StateMainMenu
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
 type importDecryptedMsg struct {
 	records []*models.ExportRecord
 	err     error
@@ -197,17 +286,15 @@ type importDecryptedMsg struct {
 
 func (m *Model) decryptImportFile() tea.Cmd {
 	return func() tea.Msg {
-		cwd, err := os.Getwd()
+		filePath, err := m.importFilePath()
 		if err != nil {
-			return importDecryptedMsg{err: fmt.Errorf("failed to get current directory: %w", err)}
+			return importDecryptedMsg{err: err}
 		}
 
-		filePath := filepath.Join(cwd, m.Import.selectedFile)
-
-		// Create Fernet instance
-		fernet, err := services.NewFernet(m.Import.fileKey)
+		// Create Fernet instance, from the passphrase for a passphrase-protected file
+		fernet, err := services.FernetForFile(filePath, m.Import.fileKey)
 		if err != nil {
-			return importDecryptedMsg{err: fmt.Errorf("invalid Fernet key: %w", err)}
+			return importDecryptedMsg{err: fmt.Errorf("invalid Fernet key or passphrase: %w", err)}
 		}
 
 		// Read and decrypt CSV
@@ -222,7 +309,32 @@ func (m *Model) decryptImportFile() tea.Cmd {
 
 func (m *Model) updateImportSelectConnections(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		if cursor, ok := wheelCursor(msg, m.Import.connCursor, len(m.Import.records)); ok {
+			m.Import.connCursor = cursor
+			return m, nil
+		}
+		if !isLeftClick(msg) || len(m.Import.records) == 0 {
+			return m, nil
+		}
+		start, end := listWindow(len(m.Import.records), m.Import.connCursor, m.Height)
+		if i := listRowAt(msg.Y, m.importListHeader(), start, end); i >= 0 {
+			m.Import.connCursor = i
+			connID := m.Import.records[i].ConnID
+			m.Import.selected[connID] = !m.Import.selected[connID]
+		}
+		return m, nil
 	case tea.KeyMsg:
+		if m.Import.sets.active {
+			ids := make([]string, len(m.Import.records))
+			for i, r := range m.Import.records {
+				ids[i] = r.ConnID
+			}
+			var cmd tea.Cmd
+			m.Import.err, cmd = m.updateSetPrompt(&m.Import.sets, msg, m.Import.selected, ids)
+			return m, cmd
+		}
+		m.Import.sets.notice = ""
 		switch msg.String() {
 		case "esc":
 			m.Import.state = importEnterKey
@@ -248,6 +360,9 @@ func (m *Model) updateImportSelectConnections(msg tea.Msg) (tea.Model, tea.Cmd)
 			for _, r := range m.Import.records {
 				m.Import.selected[r.ConnID] = false
 			}
+		case "l", "s":
+			m.Import.err = ""
+			return m, m.Import.sets.open(msg.String() == "s")
 		case "enter":
 			selectedCount := 0
 			for _, v := range m.Import.selected {
@@ -289,6 +404,9 @@ func (m *Model) updateImportEnterPrefix(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m *Model) updateImportSelectProfile(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		m.Import.profileCursor, _ = wheelCursor(msg, m.Import.profileCursor, len(m.Import.profiles))
+		return m, nil
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
@@ -350,8 +468,24 @@ func (m *Model) updateImportConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Import.state = importSelectStrategy
 			return m, nil
 		case "y", "Y", "enter":
+			if m.Import.purge {
+				m.Import.state = importConfirmPurge
+				return m, nil
+			}
 			m.Import.state = importProcessing
 			return m, m.performImport()
+		case "p":
+			m.Import.purge = !m.Import.purge
+			return m, nil
+		case "c":
+			m.Import.continueOnError = !m.Import.continueOnError
+			return m, nil
+		case "i":
+			m.Import.ignoreCase = !m.Import.ignoreCase
+			return m, nil
+		case "x":
+			m.Import.mergeExtra = !m.Import.mergeExtra
+			return m, nil
 		case "n", "N":
 			m.State = StateMainMenu
 			m.resetImport()
@@ -361,6 +495,21 @@ func (m *Model) updateImportConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m *Model) updateImportConfirmPurge(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "Y":
+			m.Import.state = importProcessing
+			return m, m.performImport()
+		default:
+			m.Import.state = importConfirm
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
 type importCompleteMsg struct {
 	result *importResultData
 	err    error
@@ -393,14 +542,28 @@ func (m *Model) performImport() tea.Cmd {
 			return importCompleteMsg{err: fmt.Errorf("failed to get current directory: %w", err)}
 		}
 
+		// The key field takes the passphrase of a passphrase-protected file
+		inputPath := filepath.Join(cwd, m.Import.selectedFile)
+		fileKey, passphrase := m.Import.fileKey, ""
+		if salt, err := services.ReadPassphraseSalt(inputPath); err == nil && salt != nil {
+			fileKey, passphrase = "", fileKey
+		}
+
 		// Build import request
 		req := models.ImportRequest{
 			TargetProfile:     m.Import.selectedProfile,
-			InputPath:         filepath.Join(cwd, m.Import.selectedFile),
-			FileDecryptionKey: m.Import.fileKey,
+			InputPath:         inputPath,
+			FileDecryptionKey: fileKey,
+			FilePassphrase:    passphrase,
 			ConnectionIDs:     selectedIDs,
+			ExpectedCount:     len(m.Import.records),
 			ConnectionPrefix:  m.Import.prefixInput.Value(),
 			CollisionStrategy: strategy,
+			ContinueOnError:   m.Import.continueOnError,
+
+			CaseInsensitiveCollision: m.Import.ignoreCase,
+			PurgeBeforeImport:        m.Import.purge,
+			MergeExtra:               m.Import.mergeExtra && strategy == models.CollisionOverwrite,
 		}
 
 		// Perform import
@@ -411,17 +574,23 @@ func (m *Model) performImport() tea.Cmd {
 		if err != nil {
 			return importCompleteMsg{err: err}
 		}
-		if !result.Success {
+		if !result.Success && len(result.Failures) == 0 {
 			return importCompleteMsg{err: fmt.Errorf("%s", result.Error)}
 		}
 
-		return importCompleteMsg{
-			result: &importResultData{
-				imported:  result.ImportedCount,
-				skipped:   result.SkippedCount,
-				overwrote: result.OverwrittenCount,
-			},
+		data := &importResultData{
+			imported:  result.ImportedCount,
+			skipped:   result.SkippedCount,
+			overwrote: result.OverwrittenCount,
+			purged:    result.PurgedCount,
+		}
+		for _, f := range result.Failures {
+			data.errors = append(data.errors, fmt.Sprintf("%s: %s", f.ConnID, f.Error))
 		}
+		for _, c := range result.CaseCollisions {
+			data.variants = append(data.variants, fmt.Sprintf("%s matched %s", c.ConnID, c.ExistingID))
+		}
+		return importCompleteMsg{result: data}
 	}
 }
 
@@ -446,6 +615,8 @@ func (m *Model) viewImport() string {
 		return m.viewImportEnterKey()
 	case importDecrypting:
 		return m.viewImportDecrypting()
+	case importDecryptError:
+		return m.viewImportDecryptError()
 	case importSelectConnections:
 		return m.viewImportSelectConnections()
 	case importEnterPrefix:
@@ -456,6 +627,8 @@ func (m *Model) viewImport() string {
 		return m.viewImportSelectStrategy()
 	case importConfirm:
 		return m.viewImportConfirm()
+	case importConfirmPurge:
+		return m.viewImportConfirmPurge()
 	case importProcessing:
 		return m.viewImportProcessing()
 	case importResult:
@@ -467,12 +640,12 @@ func (m *Model) viewImport() string {
 func (m *Model) viewImportSelectFile() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("Select CSV file to import:\n\n")
 
 	if len(m.Import.files) == 0 {
-		s.WriteString(SubtleStyle.Render("No CSV files found in current directory."))
+		s.WriteString(SubtleStyle.Render("No CSV files found in current directory. Export some first."))
 		s.WriteString("\n")
 		s.WriteString(SubtleStyle.Render("Press 'r' to refresh after adding files."))
 		s.WriteString("\n\n")
@@ -480,7 +653,7 @@ func (m *Model) viewImportSelectFile() string {
 		for i, f := range m.Import.files {
 			cursor := "  "
 			if i == m.Import.fileCursor {
-				cursor = "▸ "
+				cursor = glyph("▸ ")
 			}
 
 			line := fmt.Sprintf("%s%s", cursor, f)
@@ -496,7 +669,7 @@ func (m *Model) viewImportSelectFile() string {
 	}
 
 	if m.Import.err != "" {
-		s.WriteString(ErrorStyle.Render("✗ " + m.Import.err))
+		s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Import.err))
 		s.WriteString("\n\n")
 	}
 
@@ -508,18 +681,27 @@ func (m *Model) viewImportSelectFile() string {
 func (m *Model) viewImportEnterKey() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("File: ")
 	s.WriteString(SelectedStyle.Render(m.Import.selectedFile))
 	s.WriteString("\n\n")
 
-	s.WriteString("Enter Fernet key to decrypt file:\n")
+	s.WriteString("Enter the Fernet key or passphrase to decrypt the file:\n")
 	s.WriteString(m.Import.keyInput.View())
-	s.WriteString("\n\n")
+	s.WriteString("\n")
+	if m.Import.keyChecked {
+		if m.Import.keyMatches {
+			s.WriteString(SuccessStyle.Render(glyph("✓ Key valid for this file")))
+		} else {
+			s.WriteString(ErrorStyle.Render(glyph("✗ Key does not decrypt this file")))
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
 
 	if m.Import.err != "" {
-		s.WriteString(ErrorStyle.Render("✗ " + m.Import.err))
+		s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Import.err))
 		s.WriteString("\n\n")
 	}
 
@@ -528,20 +710,37 @@ func (m *Model) viewImportEnterKey() string {
 	return s.String()
 }
 
+func (m *Model) viewImportDecryptError() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
+	s.WriteString("\n\n")
+	s.WriteString("Failed to decrypt ")
+	s.WriteString(SelectedStyle.Render(m.Import.selectedFile))
+	s.WriteString(":\n\n")
+	s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Import.err))
+	s.WriteString("\n\n")
+	s.WriteString(SubtleStyle.Render("[r]etry  [k] change key  [q] main menu"))
+
+	return s.String()
+}
+
 func (m *Model) viewImportDecrypting() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("Decrypting file...\n")
 
 	return s.String()
 }
 
-func (m *Model) viewImportSelectConnections() string {
+// importListHeader renders everything above the connection rows. Mouse handling
+// relies on it to map clicks back to rows.
+func (m *Model) importListHeader() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
 	s.WriteString("\n\n")
 
 	selectedCount := 0
@@ -554,37 +753,22 @@ func (m *Model) viewImportSelectConnections() string {
 	s.WriteString(fmt.Sprintf("Select connections to import (%d/%d selected):\n\n",
 		selectedCount, len(m.Import.records)))
 
+	return s.String()
+}
+
+func (m *Model) viewImportSelectConnections() string {
+	var s strings.Builder
+
+	s.WriteString(m.importListHeader())
+
 	if len(m.Import.records) == 0 {
 		s.WriteString(SubtleStyle.Render("No connections found in file."))
 		s.WriteString("\n\n")
 	} else {
-		// Dynamic max visible based on terminal height
-		// Reserve space for: title(2) + header(2) + footer(4) + messages(2) = ~10 lines
-		maxVisible := m.Height - 10
-		if maxVisible < 5 {
-			maxVisible = 5
-		}
-		if maxVisible > len(m.Import.records) {
-			maxVisible = len(m.Import.records)
-		}
-
-		startIdx := 0
-		endIdx := len(m.Import.records)
-
-		if len(m.Import.records) > maxVisible {
-			startIdx = m.Import.connCursor - maxVisible/2
-			if startIdx < 0 {
-				startIdx = 0
-			}
-			endIdx = startIdx + maxVisible
-			if endIdx > len(m.Import.records) {
-				endIdx = len(m.Import.records)
-				startIdx = endIdx - maxVisible
-			}
-		}
+		startIdx, endIdx := listWindow(len(m.Import.records), m.Import.connCursor, m.Height)
 
 		if startIdx > 0 {
-			s.WriteString(SubtleStyle.Render("    ↑ more above"))
+			s.WriteString(SubtleStyle.Render(glyph("    ↑ more above")))
 			s.WriteString("\n\n")
 		}
 
@@ -592,12 +776,12 @@ func (m *Model) viewImportSelectConnections() string {
 			r := m.Import.records[i]
 			cursor := "  "
 			if i == m.Import.connCursor {
-				cursor = "▸ "
+				cursor = glyph("▸ ")
 			}
 
 			checkbox := "[ ]"
 			if m.Import.selected[r.ConnID] {
-				checkbox = "[✓]"
+				checkbox = glyph("[✓]")
 			}
 
 			line := fmt.Sprintf("%s%s %s", cursor, checkbox, r.ConnID)
@@ -615,18 +799,23 @@ func (m *Model) viewImportSelectConnections() string {
 
 		if endIdx < len(m.Import.records) {
 			s.WriteString("\n")
-			s.WriteString(SubtleStyle.Render("    ↓ more below"))
+			s.WriteString(SubtleStyle.Render(glyph("    ↓ more below")))
 		}
 
 		s.WriteString("\n\n")
 	}
 
+	s.WriteString(m.viewSetPrompt(&m.Import.sets))
 	if m.Import.err != "" {
-		s.WriteString(ErrorStyle.Render("✗ " + m.Import.err))
+		s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Import.err))
 		s.WriteString("\n\n")
 	}
 
-	s.WriteString(SubtleStyle.Render("[Space] toggle  [a]ll  [n]one  [Enter] continue  [Esc] back"))
+	if m.Import.sets.active {
+		s.WriteString(SubtleStyle.Render("[Enter] confirm  [Esc] cancel"))
+		return s.String()
+	}
+	s.WriteString(SubtleStyle.Render("[Space] toggle  [a]ll  [n]one  [l]oad/[s]ave set  [Enter] continue  [Esc] back"))
 
 	return s.String()
 }
@@ -634,7 +823,7 @@ func (m *Model) viewImportSelectConnections() string {
 func (m *Model) viewImportEnterPrefix() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
 	s.WriteString("\n\n")
 
 	selectedCount := 0
@@ -660,18 +849,18 @@ func (m *Model) viewImportEnterPrefix() string {
 func (m *Model) viewImportSelectProfile() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("Select target profile:\n\n")
 
 	if len(m.Import.profiles) == 0 {
-		s.WriteString(SubtleStyle.Render("No profiles available. Create one first."))
+		s.WriteString(SubtleStyle.Render("No profiles yet. Press [1] on the main menu to add one."))
 		s.WriteString("\n\n")
 	} else {
 		for i, p := range m.Import.profiles {
 			cursor := "  "
 			if i == m.Import.profileCursor {
-				cursor = "▸ "
+				cursor = glyph("▸ ")
 			}
 
 			line := fmt.Sprintf("%s%s", cursor, p.Name)
@@ -690,7 +879,7 @@ func (m *Model) viewImportSelectProfile() string {
 	}
 
 	if m.Import.err != "" {
-		s.WriteString(ErrorStyle.Render("✗ " + m.Import.err))
+		s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Import.err))
 		s.WriteString("\n\n")
 	}
 
@@ -702,7 +891,7 @@ func (m *Model) viewImportSelectProfile() string {
 func (m *Model) viewImportSelectStrategy() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("Select collision strategy:\n\n")
 
@@ -715,7 +904,7 @@ func (m *Model) viewImportSelectStrategy() string {
 	for i, strat := range m.Import.strategies {
 		cursor := "  "
 		if i == m.Import.strategyCursor {
-			cursor = "▸ "
+			cursor = glyph("▸ ")
 		}
 
 		line := fmt.Sprintf("%s%s", cursor, strat)
@@ -739,7 +928,7 @@ func (m *Model) viewImportSelectStrategy() string {
 func (m *Model) viewImportConfirm() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Confirm Import"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Confirm Import")))
 	s.WriteString("\n\n")
 
 	// Count selected
@@ -765,10 +954,56 @@ func (m *Model) viewImportConfirm() string {
 	s.WriteString(fmt.Sprintf("  Target DB:      %s/%s\n", m.Import.selectedProfile.DBHost, m.Import.selectedProfile.DBName))
 	s.WriteString(fmt.Sprintf("  Strategy:       %s\n", m.Import.strategies[m.Import.strategyCursor]))
 
+	onError := "stop"
+	if m.Import.continueOnError {
+		onError = "continue"
+	}
+	s.WriteString(fmt.Sprintf("  On error:       %s\n", onError))
+
+	matchCase := "exact"
+	if m.Import.ignoreCase {
+		matchCase = "ignore case"
+	}
+	s.WriteString(fmt.Sprintf("  Match IDs:      %s\n", matchCase))
+
+	if m.Import.strategies[m.Import.strategyCursor] == "overwrite" {
+		extra := "replace"
+		if m.Import.mergeExtra {
+			extra = "merge, keep keys only the target has"
+		}
+		s.WriteString(fmt.Sprintf("  Extra:          %s\n", extra))
+	}
+
+	if m.Import.purge {
+		s.WriteString(ErrorStyle.Render("  Purge target:   yes, existing connections are deleted first"))
+		s.WriteString("\n")
+	} else {
+		s.WriteString("  Purge target:   no\n")
+	}
+
 	s.WriteString("\n")
 	s.WriteString("Proceed with import?\n\n")
 
-	s.WriteString(SubtleStyle.Render("[y]es / [Enter]  [n]o  [Esc] back"))
+	s.WriteString(SubtleStyle.Render("[y]es / [Enter]  [n]o  [c] toggle on error  [i] toggle case  [x] toggle extra merge  [p] toggle purge  [Esc] back"))
+
+	return s.String()
+}
+
+func (m *Model) viewImportConfirmPurge() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("⚠ Confirm Purge")))
+	s.WriteString("\n\n")
+
+	scope := "ALL connections"
+	if prefix := m.Import.prefixInput.Value(); prefix != "" {
+		scope = fmt.Sprintf("all connections starting with %q", prefix)
+	}
+	s.WriteString(ErrorStyle.Render(fmt.Sprintf("This deletes %s in %s before importing.", scope, m.Import.selectedProfile.Name)))
+	s.WriteString("\n\n")
+	s.WriteString("The purge and the import run in one transaction and are rolled back together on failure.\n\n")
+
+	s.WriteString(SubtleStyle.Render("[Shift+Y] purge and import  [any other key] back"))
 
 	return s.String()
 }
@@ -776,7 +1011,7 @@ func (m *Model) viewImportConfirm() string {
 func (m *Model) viewImportProcessing() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Connections"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Connections")))
 	s.WriteString("\n\n")
 	s.WriteString("Importing connections...\n")
 
@@ -786,20 +1021,40 @@ func (m *Model) viewImportProcessing() string {
 func (m *Model) viewImportResult() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📥 Import Complete"))
+	s.WriteString(TitleStyle.Render(glyph("📥 Import Complete")))
 	s.WriteString("\n\n")
 
 	if m.Import.err != "" {
-		s.WriteString(ErrorStyle.Render("✗ Import failed: " + m.Import.err))
+		s.WriteString(ErrorStyle.Render(glyph("✗ Import failed: ") + m.Import.err))
 		s.WriteString("\n\n")
 	} else if m.Import.result != nil {
-		s.WriteString(SuccessStyle.Render("✓ Import successful!"))
+		if len(m.Import.result.errors) > 0 {
+			s.WriteString(ErrorStyle.Render(fmt.Sprintf(glyph("✗ %d connection(s) failed to import"), len(m.Import.result.errors))))
+		} else {
+			s.WriteString(SuccessStyle.Render(glyph("✓ Import successful!")))
+		}
 		s.WriteString("\n\n")
 
 		s.WriteString(fmt.Sprintf("Imported:    %d\n", m.Import.result.imported))
 		s.WriteString(fmt.Sprintf("Skipped:     %d\n", m.Import.result.skipped))
 		s.WriteString(fmt.Sprintf("Overwritten: %d\n", m.Import.result.overwrote))
+		if m.Import.result.purged > 0 {
+			s.WriteString(fmt.Sprintf("Purged:      %d\n", m.Import.result.purged))
+		}
+		s.WriteString(fmt.Sprintf("Failed:      %d\n", len(m.Import.result.errors)))
 		s.WriteString("\n")
+
+		for _, v := range m.Import.result.variants {
+			s.WriteString(SubtleStyle.Render("  Case variant: " + v))
+			s.WriteString("\n")
+		}
+		for _, e := range m.Import.result.errors {
+			s.WriteString(ErrorStyle.Render("  " + e))
+			s.WriteString("\n")
+		}
+		if len(m.Import.result.errors) > 0 || len(m.Import.result.variants) > 0 {
+			s.WriteString("\n")
+		}
 	}
 
 	s.WriteString(SubtleStyle.Render("[Enter] done"))
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// listWindow returns the range [start, end) of rows visible in a selection list of n rows,
+// keeping the cursor centred when the list is taller than the terminal.
+func listWindow(n, cursor, height int) (start, end int) {
+	// Reserve space for: title(2) + header(2) + footer(4) + messages(2) + status bar(1) = ~11 lines
+	maxVisible := height - 11
+	if maxVisible < 5 {
+		maxVisible = 5
+	}
+	if maxVisible > n {
+		maxVisible = n
+	}
+
+	start, end = 0, n
+	if n > maxVisible {
+		start = cursor - maxVisible/2
+		if start < 0 {
+			start = 0
+		}
+		end = start + maxVisible
+		if end > n {
+			end = n
+			start = end - maxVisible
+		}
+	}
+	return start, end
+}
+
+// listRowAt maps the Y coordinate of a mouse event to the index of the row under it.
+// Selection lists render header, an optional "more above" marker, then one row per line.
+// Returns -1 when y is outside the visible rows.
+func listRowAt(y int, header string, start, end int) int {
+	top := lipgloss.Height(header) - 1
+	if start > 0 {
+		top += 2 // "↑ more above" and the blank line after it
+	}
+	if y < top {
+		return -1
+	}
+	idx := start + y - top
+	if idx >= end {
+		return -1
+	}
+	return idx
+}
+
+// isLeftClick reports whether msg is a left mouse button press.
+func isLeftClick(msg tea.MouseMsg) bool {
+	return msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft
+}
+
+// wheelCursor moves cursor one row for wheel events, like the arrow keys, keeping it in [0, n).
+// ok is false for any other mouse event.
+func wheelCursor(msg tea.MouseMsg, cursor, n int) (next int, ok bool) {
+	if msg.Action != tea.MouseActionPress {
+		return cursor, false
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		if cursor > 0 {
+			cursor--
+		}
+	case tea.MouseButtonWheelDown:
+		if cursor < n-1 {
+			cursor++
+		}
+	default:
+		return cursor, false
+	}
+	return cursor, true
+}
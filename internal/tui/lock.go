@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"errors"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+// lockCheckInterval is how often the idle time is checked when LockAfter is set
+const lockCheckInterval = 10 * time.Second
+
+// lockModel is the lock screen shown after Model.LockAfter without input.
+type lockModel struct {
+	locked    bool
+	input     textinput.Model
+	err       string
+	lastInput time.Time
+}
+
+type idleTickMsg time.Time
+
+func newLockModel() lockModel {
+	input := textinput.New()
+	input.Placeholder = "Master password"
+	input.EchoMode = textinput.EchoPassword
+	input.EchoCharacter = maskGlyph()
+	return lockModel{input: input, lastInput: time.Now()}
+}
+
+// idleTick schedules the next idle check, or nothing when idle locking is off.
+func (m Model) idleTick() tea.Cmd {
+	if m.LockAfter <= 0 {
+		return nil
+	}
+	return tea.Tick(lockCheckInterval, func(t time.Time) tea.Msg { return idleTickMsg(t) })
+}
+
+// checkIdle locks the store once LockAfter has passed without input. A running
+// export, import or backup is let finish; the lock follows on the next check.
+func (m *Model) checkIdle(now time.Time) tea.Cmd {
+	if m.Lock.locked || m.busy() || now.Sub(m.Lock.lastInput) < m.LockAfter {
+		return nil
+	}
+
+	m.Secrets.Lock()
+	m.Lock.locked = true
+	m.Lock.err = ""
+	m.Lock.input.SetValue("")
+	// The input is built before the settings are applied
+	m.Lock.input.EchoCharacter = maskGlyph()
+
+	// The screens hold decrypted profiles and connections, start over after unlocking
+	m.State = StateMainMenu
+	m.Profile = newProfileModel()
+	m.Export = newExportModel()
+	m.Import = newImportModel()
+	m.Backup = newBackupModel()
+	return m.Lock.input.Focus()
+}
+
+// updateLock handles a key on the lock screen: Enter unlocks with the typed password.
+func (m Model) updateLock(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		m.Lock.input, cmd = m.Lock.input.Update(msg)
+		return m, cmd
+	}
+
+	err := m.Secrets.Unlock(m.Lock.input.Value())
+	m.Lock.input.SetValue("")
+	if errors.Is(err, secrets.ErrInvalidPassword) {
+		m.Lock.err = "Wrong master password"
+		return m, nil
+	}
+	if err != nil {
+		// Unlocked but not usable as it is, e.g. a corrupt file; say so on the main menu
+		m.startErr = err.Error()
+	}
+	m.Lock.locked = false
+	m.Lock.input.Blur()
+	return m, nil
+}
+
+func (m Model) viewLock() string {
+	s := TitleStyle.Render(glyph("🔒 Locked")) + "\n\n"
+	s += "The secrets store was locked after " + m.LockAfter.String() + " without input.\n"
+	s += "Enter the master password to continue.\n\n"
+	s += m.Lock.input.View() + "\n\n"
+	if m.Lock.err != "" {
+		s += ErrorStyle.Render(glyph("✗ ")+m.Lock.err) + "\n\n"
+	}
+	s += SubtleStyle.Render("[Enter] unlock  [ctrl+c] quit")
+	return s
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/flevanti/airflow-migrator/internal/core/models"
+	"github.com/flevanti/airflow-migrator/internal/secrets"
 )
 
 // Profile sub-states
@@ -21,6 +23,17 @@ const (
 	profileAdd
 	profileEdit
 	profileDelete
+	profileCleanOrphans
+	profileTesting
+	profileTestResults
+)
+
+const (
+	// profileTestWorkers bounds how many profiles are tested at once by 'T'
+	profileTestWorkers = 4
+
+	// profileTestTimeout bounds each profile test of 'T'
+	profileTestTimeout = 10 * time.Second
 )
 
 // Profile form fields
@@ -44,8 +57,20 @@ type profileModel struct {
 	focusIndex  int
 	editingID   string
 	deleteID    string
+	deleteInput textinput.Model // Typed profile name, with ConfirmDeleteByName
+	orphans     []string
 	message     string
 	messageType string
+
+	// connCounts caches the connection count per profile ID, refreshed on test/refresh
+	connCounts map[string]int
+
+	// testResults holds the outcome of the last 'T' run, in list order
+	testResults []profileTestResult
+
+	// filter narrows the list to profiles matching by name, host or database; '/' edits it
+	filter    textinput.Model
+	filtering bool
 }
 
 func newProfileModel() profileModel {
@@ -69,18 +94,23 @@ func newProfileModel() profileModel {
 		case fieldPassword:
 			t.Placeholder = "password (leave empty to keep existing)"
 			t.EchoMode = textinput.EchoPassword
-			t.EchoCharacter = '•'
+			t.EchoCharacter = maskGlyph()
 		case fieldFernet:
 			t.Placeholder = "Fernet key (leave empty to generate)"
 			t.EchoMode = textinput.EchoPassword
-			t.EchoCharacter = '•'
+			t.EchoCharacter = maskGlyph()
 		}
 		inputs[i] = t
 	}
 
+	filter := textinput.New()
+	filter.Placeholder = "name, host or database"
+	filter.CharLimit = 256
+
 	return profileModel{
 		state:  profileList,
 		inputs: inputs,
+		filter: filter,
 	}
 }
 
@@ -125,24 +155,52 @@ func (m *Model) updateProfiles(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateProfileForm(msg)
 	case profileDelete:
 		return m.updateProfileDelete(msg)
+	case profileCleanOrphans:
+		return m.updateProfileCleanOrphans(msg)
+	case profileTestResults:
+		return m.updateProfileTestResults(msg)
 	}
 	return m, nil
 }
 
 func (m *Model) updateProfileList(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.Profile.filtering {
+		return m.updateProfileFilter(msg)
+	}
+
+	visible := m.visibleProfiles()
 	switch msg := msg.(type) {
+	case tea.MouseMsg:
+		m.Profile.cursor, _ = wheelCursor(msg, m.Profile.cursor, len(visible))
+		return m, nil
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "q", "esc":
+		case "esc":
+			// Esc clears an active filter before leaving the screen
+			if m.Profile.filter.Value() != "" {
+				m.Profile.filter.SetValue("")
+				m.Profile.cursor = 0
+				return m, nil
+			}
+			m.State = StateMainMenu
+			m.Profile.message = ""
+			return m, nil
+		case "q":
 			m.State = StateMainMenu
 			m.Profile.message = ""
+			m.Profile.filter.SetValue("")
+			m.Profile.cursor = 0
 			return m, nil
+		case "/":
+			m.Profile.filtering = true
+			m.Profile.message = ""
+			return m, m.Profile.filter.Focus()
 		case "up", "k":
 			if m.Profile.cursor > 0 {
 				m.Profile.cursor--
 			}
 		case "down", "j":
-			if m.Profile.cursor < len(m.Profile.profiles)-1 {
+			if m.Profile.cursor < len(visible)-1 {
 				m.Profile.cursor++
 			}
 		case "a", "n":
@@ -151,25 +209,56 @@ func (m *Model) updateProfileList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.resetProfileForm()
 			return m, nil
 		case "e", "enter":
-			if len(m.Profile.profiles) > 0 {
+			if len(visible) > 0 {
 				m.Profile.state = profileEdit
-				m.Profile.editingID = m.Profile.profiles[m.Profile.cursor].ID
+				m.Profile.editingID = visible[m.Profile.cursor].ID
 				m.loadProfileIntoForm(m.Profile.editingID)
 				return m, nil
 			}
 		case "d", "backspace":
-			if len(m.Profile.profiles) > 0 {
+			if len(visible) > 0 {
 				m.Profile.state = profileDelete
-				m.Profile.deleteID = m.Profile.profiles[m.Profile.cursor].ID
+				m.Profile.deleteID = visible[m.Profile.cursor].ID
+				m.Profile.deleteInput = textinput.New()
+				m.Profile.deleteInput.Placeholder = "Profile name"
+				m.Profile.deleteInput.CharLimit = 256
+				m.Profile.deleteInput.Focus()
+				m.Profile.message = ""
+				return m, nil
+			}
+		case "c":
+			if len(visible) > 0 {
+				m.duplicateProfile(visible[m.Profile.cursor].ID)
+				return m, nil
+			}
+		case "f":
+			if len(visible) > 0 {
+				m.cloneProfileWithNewKey(visible[m.Profile.cursor].ID)
 				return m, nil
 			}
 		case "t":
-			if len(m.Profile.profiles) > 0 {
-				m.testProfileConnection(m.Profile.profiles[m.Profile.cursor].ID)
+			if len(visible) > 0 {
+				m.testProfileConnection(visible[m.Profile.cursor].ID)
 				return m, nil
 			}
+		case "T":
+			if len(visible) > 0 {
+				m.Profile.state = profileTesting
+				m.Profile.message = ""
+				return m, m.testAllProfiles(visible)
+			}
+		case "o":
+			m.Profile.orphans = m.Migrator.FindOrphanedSecrets(m.Secrets)
+			if len(m.Profile.orphans) == 0 {
+				m.Profile.message = "No orphaned secrets"
+				m.Profile.messageType = "success"
+				return m, nil
+			}
+			m.Profile.state = profileCleanOrphans
+			return m, nil
 		case "r":
 			m.loadProfiles()
+			m.refreshConnCounts()
 			m.Profile.message = "Refreshed"
 			m.Profile.messageType = "success"
 			return m, nil
@@ -178,6 +267,45 @@ func (m *Model) updateProfileList(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateProfileFilter edits the list filter. Enter keeps it, Esc clears it.
+func (m *Model) updateProfileFilter(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			m.Profile.filtering = false
+			m.Profile.filter.Blur()
+			return m, nil
+		case "esc":
+			m.Profile.filtering = false
+			m.Profile.filter.Blur()
+			m.Profile.filter.SetValue("")
+			m.Profile.cursor = 0
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.Profile.filter, cmd = m.Profile.filter.Update(msg)
+	m.Profile.cursor = 0
+	return m, cmd
+}
+
+// visibleProfiles returns the profiles matching the list filter.
+func (m *Model) visibleProfiles() []models.ProfileSummary {
+	query := m.Profile.filter.Value()
+	if query == "" {
+		return m.Profile.profiles
+	}
+
+	var visible []models.ProfileSummary
+	for _, p := range m.Profile.profiles {
+		if p.Matches(query) {
+			visible = append(visible, p)
+		}
+	}
+	return visible
+}
+
 func (m *Model) updateProfileForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -201,6 +329,20 @@ func (m *Model) updateProfileForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+s":
 			m.saveProfile()
 			return m, nil
+		case "ctrl+t":
+			m.testFormConnection()
+			return m, nil
+		case "ctrl+r":
+			// Reveal or hide the focused secret field
+			if m.Profile.focusIndex == fieldPassword || m.Profile.focusIndex == fieldFernet {
+				input := &m.Profile.inputs[m.Profile.focusIndex]
+				if input.EchoMode == textinput.EchoPassword {
+					input.EchoMode = textinput.EchoNormal
+				} else {
+					input.EchoMode = textinput.EchoPassword
+				}
+			}
+			return m, nil
 		case "ctrl+g":
 			if key, err := m.Migrator.GenerateFernetKey(); err == nil {
 				m.Profile.inputs[fieldFernet].SetValue(key)
@@ -217,16 +359,82 @@ func (m *Model) updateProfileForm(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) updateProfileDelete(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.ConfirmDeleteByName {
+		return m.updateProfileDeleteByName(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "y", "Y":
-			m.deleteProfile(m.Profile.deleteID)
+			m.confirmProfileDelete()
+			return m, nil
+		case "n", "N", "esc":
 			m.Profile.state = profileList
-			m.loadProfiles()
-			if m.Profile.cursor >= len(m.Profile.profiles) && m.Profile.cursor > 0 {
-				m.Profile.cursor--
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+// updateProfileDeleteByName deletes only once the typed name matches the profile's.
+func (m *Model) updateProfileDeleteByName(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			m.Profile.state = profileList
+			m.Profile.message = ""
+			return m, nil
+		case "enter":
+			if m.Profile.deleteInput.Value() != m.deleteProfileName() {
+				m.Profile.message = "Name does not match, profile not deleted"
+				m.Profile.messageType = "error"
+				return m, nil
+			}
+			m.confirmProfileDelete()
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.Profile.deleteInput, cmd = m.Profile.deleteInput.Update(msg)
+	return m, cmd
+}
+
+// confirmProfileDelete deletes the profile being confirmed and returns to the list.
+func (m *Model) confirmProfileDelete() {
+	m.deleteProfile(m.Profile.deleteID)
+	m.Profile.state = profileList
+	m.loadProfiles()
+	if m.Profile.cursor >= len(m.visibleProfiles()) && m.Profile.cursor > 0 {
+		m.Profile.cursor--
+	}
+}
+
+// deleteProfileName returns the name of the profile being confirmed for deletion.
+func (m *Model) deleteProfileName() string {
+	for _, p := range m.Profile.profiles {
+		if p.ID == m.Profile.deleteID {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+func (m *Model) updateProfileCleanOrphans(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y":
+			deleted, err := m.Migrator.CleanOrphanedSecrets(m.Secrets)
+			if err != nil {
+				m.Profile.message = "Cleanup failed: " + err.Error()
+				m.Profile.messageType = "error"
+			} else {
+				m.Profile.message = fmt.Sprintf("Deleted %d orphaned secrets", len(deleted))
+				m.Profile.messageType = "success"
 			}
+			m.Profile.state = profileList
 			return m, nil
 		case "n", "N", "esc":
 			m.Profile.state = profileList
@@ -248,10 +456,17 @@ func (m *Model) updateProfileFocus() tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// hideSecretFields masks the password and Fernet key fields again.
+func (m *Model) hideSecretFields() {
+	m.Profile.inputs[fieldPassword].EchoMode = textinput.EchoPassword
+	m.Profile.inputs[fieldFernet].EchoMode = textinput.EchoPassword
+}
+
 func (m *Model) resetProfileForm() {
 	for i := range m.Profile.inputs {
 		m.Profile.inputs[i].SetValue("")
 	}
+	m.hideSecretFields()
 	m.Profile.inputs[fieldPort].SetValue("5432")
 	m.Profile.focusIndex = 0
 	m.Profile.inputs[0].Focus()
@@ -274,6 +489,7 @@ func (m *Model) loadProfileIntoForm(id string) {
 	m.Profile.inputs[fieldUser].SetValue(profile.DBUser)
 	m.Profile.inputs[fieldPassword].SetValue("")
 	m.Profile.inputs[fieldFernet].SetValue("")
+	m.hideSecretFields()
 
 	m.Profile.focusIndex = 0
 	m.Profile.inputs[0].Focus()
@@ -322,7 +538,8 @@ func (m *Model) loadFullProfile(id string) *models.Profile {
 	return profile
 }
 
-func (m *Model) saveProfile() {
+// saveProfile saves the form and returns the profile ID, or "" when the form is invalid.
+func (m *Model) saveProfile() string {
 	name := m.Profile.inputs[fieldName].Value()
 	host := m.Profile.inputs[fieldHost].Value()
 	portStr := m.Profile.inputs[fieldPort].Value()
@@ -335,32 +552,42 @@ func (m *Model) saveProfile() {
 	if name == "" {
 		m.Profile.message = "Name is required"
 		m.Profile.messageType = "error"
-		return
+		return ""
 	}
 	if host == "" {
 		m.Profile.message = "Host is required"
 		m.Profile.messageType = "error"
-		return
+		return ""
+	}
+	if err := models.ValidateHost(host); err != nil {
+		m.Profile.message = err.Error()
+		m.Profile.messageType = "error"
+		return ""
 	}
 	if dbName == "" {
 		m.Profile.message = "Database name is required"
 		m.Profile.messageType = "error"
-		return
+		return ""
 	}
 	if user == "" {
 		m.Profile.message = "User is required"
 		m.Profile.messageType = "error"
-		return
+		return ""
 	}
 
 	port := 5432
 	if portStr != "" {
-		fmt.Sscanf(portStr, "%d", &port)
+		var err error
+		if port, err = models.ParsePort(portStr); err != nil {
+			m.Profile.message = err.Error()
+			m.Profile.messageType = "error"
+			return ""
+		}
 	}
 
 	id := m.Profile.editingID
 	if id == "" {
-		id = fmt.Sprintf("%d", time.Now().UnixNano())
+		id = models.NewProfile(name).ID
 	}
 
 	// If editing, keep existing secrets if not provided
@@ -387,26 +614,107 @@ func (m *Model) saveProfile() {
 	if m.Profile.editingID == "" && password == "" {
 		m.Profile.message = "Password is required for new profiles"
 		m.Profile.messageType = "error"
-		return
+		return ""
 	}
 
-	// Save metadata
-	metaJSON := fmt.Sprintf(`{"id":"%s","name":"%s","db_host":"%s","db_port":%d,"db_name":"%s","db_user":"%s"}`,
-		id, name, host, port, dbName, user)
-	m.Secrets.Set("profile:"+id+":meta", metaJSON)
-	m.Secrets.Set("profile:"+id+":password", password)
-	m.Secrets.Set("profile:"+id+":fernet", fernet)
+	m.storeProfile(&models.Profile{
+		ID:         id,
+		Name:       name,
+		DBHost:     host,
+		DBPort:     port,
+		DBName:     dbName,
+		DBUser:     user,
+		DBPassword: password,
+		FernetKey:  fernet,
+	})
 
 	m.Profile.message = "Profile saved successfully"
 	m.Profile.messageType = "success"
 	m.Profile.state = profileList
 	m.loadProfiles()
+	return id
+}
+
+// storeProfile writes the profile metadata and secrets to the store.
+func (m *Model) storeProfile(p *models.Profile) {
+	metaJSON, _ := json.Marshal(map[string]any{
+		"id":      p.ID,
+		"name":    p.Name,
+		"db_host": p.DBHost,
+		"db_port": p.DBPort,
+		"db_name": p.DBName,
+		"db_user": p.DBUser,
+	})
+	m.Secrets.SetBatch(map[string]string{
+		"profile:" + p.ID + ":meta":     string(metaJSON),
+		"profile:" + p.ID + ":password": p.DBPassword,
+		"profile:" + p.ID + ":fernet":   p.FernetKey,
+	})
+}
+
+// duplicateProfile copies a profile and its secrets under a new ID and opens the copy for editing.
+func (m *Model) duplicateProfile(id string) {
+	profile := m.loadFullProfile(id)
+	if profile == nil {
+		m.Profile.message = "Failed to load profile"
+		m.Profile.messageType = "error"
+		return
+	}
+
+	clone := profile.Clone()
+	clone.Name = profile.Name + " (copy)"
+	clone.ID = models.NewProfile(clone.Name).ID
+	m.storeProfile(clone)
+	m.loadProfiles()
+
+	m.Profile.state = profileEdit
+	m.Profile.editingID = clone.ID
+	m.loadProfileIntoForm(clone.ID)
+	m.Profile.message = "Copied from " + profile.Name
+	m.Profile.messageType = "success"
+}
+
+// cloneProfileWithNewKey copies a profile with a generated Fernet key, shown in the
+// form so it can be replaced with a rotation candidate before saving.
+func (m *Model) cloneProfileWithNewKey(id string) {
+	profile := m.loadFullProfile(id)
+	if profile == nil {
+		m.Profile.message = "Failed to load profile"
+		m.Profile.messageType = "error"
+		return
+	}
+
+	key, err := m.Migrator.GenerateFernetKey()
+	if err != nil {
+		m.Profile.message = "Failed to generate key: " + err.Error()
+		m.Profile.messageType = "error"
+		return
+	}
+
+	clone := profile.Clone()
+	clone.Name = profile.Name + " (new key)"
+	clone.ID = models.NewProfile(clone.Name).ID
+	clone.FernetKey = key
+	m.storeProfile(clone)
+	m.loadProfiles()
+
+	m.Profile.state = profileEdit
+	m.Profile.editingID = clone.ID
+	m.loadProfileIntoForm(clone.ID)
+	m.Profile.inputs[fieldFernet].SetValue(key)
+	m.Profile.inputs[fieldFernet].EchoMode = textinput.EchoNormal
+	m.Profile.message = "Cloned from " + profile.Name + " with a generated key, replace it with your candidate"
+	m.Profile.messageType = "success"
 }
 
 func (m *Model) deleteProfile(id string) {
-	m.Secrets.Delete("profile:" + id + ":meta")
-	m.Secrets.Delete("profile:" + id + ":password")
-	m.Secrets.Delete("profile:" + id + ":fernet")
+	m.Secrets.Transaction(func(txn *secrets.Txn) error {
+		txn.Delete("profile:" + id + ":meta")
+		txn.Delete("profile:" + id + ":password")
+		txn.Delete("profile:" + id + ":fernet")
+		txn.Delete(lastExportSelectionKey(id))
+		return nil
+	})
 	m.Profile.message = "Profile deleted"
 	m.Profile.messageType = "success"
 }
@@ -424,14 +732,163 @@ func (m *Model) testProfileConnection(id string) {
 
 	err := m.Migrator.TestConnection(ctx, profile)
 	if err != nil {
+		delete(m.Profile.connCounts, id)
 		m.Profile.message = "Connection failed: " + err.Error()
 		m.Profile.messageType = "error"
 	} else {
+		m.countProfileConnections(ctx, profile)
 		m.Profile.message = "Connection successful!"
 		m.Profile.messageType = "success"
 	}
 }
 
+// profileTestResult is the outcome of testing one profile with 'T'.
+type profileTestResult struct {
+	name    string
+	err     error
+	latency time.Duration
+}
+
+// Message carrying the results of testing every listed profile
+type profileTestsDoneMsg struct {
+	results []profileTestResult
+}
+
+// testAllProfiles tests the given profiles concurrently, at most profileTestWorkers at
+// a time and each within profileTestTimeout, and reports them in one profileTestsDoneMsg.
+func (m *Model) testAllProfiles(summaries []models.ProfileSummary) tea.Cmd {
+	// Secrets are read up front so the workers only touch the databases
+	profiles := make([]*models.Profile, len(summaries))
+	for i, p := range summaries {
+		profiles[i] = m.loadFullProfile(p.ID)
+	}
+
+	return func() tea.Msg {
+		results := make([]profileTestResult, len(profiles))
+		sem := make(chan struct{}, profileTestWorkers)
+		var wg sync.WaitGroup
+
+		for i, profile := range profiles {
+			results[i].name = summaries[i].Name
+			if profile == nil {
+				results[i].err = fmt.Errorf("failed to load profile")
+				continue
+			}
+
+			wg.Add(1)
+			go func(i int, profile *models.Profile) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				ctx, cancel := context.WithTimeout(context.Background(), profileTestTimeout)
+				defer cancel()
+
+				start := time.Now()
+				results[i].err = m.Migrator.TestConnection(ctx, profile)
+				results[i].latency = time.Since(start)
+			}(i, profile)
+		}
+
+		wg.Wait()
+		return profileTestsDoneMsg{results: results}
+	}
+}
+
+func (m *Model) updateProfileTestResults(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "q", "esc", "enter":
+			m.Profile.state = profileList
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+// countProfileConnections caches the connection count of a profile, dropping it when the
+// database can't be counted.
+func (m *Model) countProfileConnections(ctx context.Context, profile *models.Profile) {
+	if m.Profile.connCounts == nil {
+		m.Profile.connCounts = make(map[string]int)
+	}
+	count, err := m.Migrator.CountConnections(ctx, profile)
+	if err != nil {
+		delete(m.Profile.connCounts, profile.ID)
+		return
+	}
+	m.Profile.connCounts[profile.ID] = count
+}
+
+// refreshConnCounts recounts the connections of every profile.
+func (m *Model) refreshConnCounts() {
+	for _, p := range m.Profile.profiles {
+		profile := m.loadFullProfile(p.ID)
+		if profile == nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		m.countProfileConnections(ctx, profile)
+		cancel()
+	}
+}
+
+// formProfile builds an unsaved profile from the form values. When editing, empty
+// secret fields fall back to the stored secrets like saveProfile does.
+func (m *Model) formProfile() *models.Profile {
+	port := 5432
+	if portStr := m.Profile.inputs[fieldPort].Value(); portStr != "" {
+		fmt.Sscanf(portStr, "%d", &port)
+	}
+
+	profile := &models.Profile{
+		ID:         m.Profile.editingID,
+		Name:       m.Profile.inputs[fieldName].Value(),
+		DBHost:     m.Profile.inputs[fieldHost].Value(),
+		DBPort:     port,
+		DBName:     m.Profile.inputs[fieldDBName].Value(),
+		DBUser:     m.Profile.inputs[fieldUser].Value(),
+		DBPassword: m.Profile.inputs[fieldPassword].Value(),
+		FernetKey:  m.Profile.inputs[fieldFernet].Value(),
+	}
+
+	if m.Profile.editingID != "" {
+		if existing := m.loadFullProfile(m.Profile.editingID); existing != nil {
+			if profile.DBPassword == "" {
+				profile.DBPassword = existing.DBPassword
+			}
+			if profile.FernetKey == "" {
+				profile.FernetKey = existing.FernetKey
+			}
+		}
+	}
+
+	return profile
+}
+
+// testFormConnection tests the form's current values without saving them.
+func (m *Model) testFormConnection() {
+	profile := m.formProfile()
+	if profile.DBHost == "" || profile.DBName == "" || profile.DBUser == "" {
+		m.Profile.message = "Host, database and user are required to test"
+		m.Profile.messageType = "error"
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := m.Migrator.TestConnection(ctx, profile)
+	if err != nil {
+		m.Profile.message = "Connection failed: " + err.Error()
+		m.Profile.messageType = "error"
+	} else {
+		m.Profile.message = fmt.Sprintf("Connection successful (%dms)", time.Since(start).Milliseconds())
+		m.Profile.messageType = "success"
+	}
+}
+
 func (m *Model) viewProfiles() string {
 	switch m.Profile.state {
 	case profileList:
@@ -442,6 +899,12 @@ func (m *Model) viewProfiles() string {
 		return m.viewProfileForm("Edit Profile")
 	case profileDelete:
 		return m.viewProfileDelete()
+	case profileCleanOrphans:
+		return m.viewProfileCleanOrphans()
+	case profileTesting:
+		return TitleStyle.Render(glyph("📋 Connection Profiles")) + "\n\n" + SubtleStyle.Render("Testing profiles...")
+	case profileTestResults:
+		return m.viewProfileTestResults()
 	}
 	return ""
 }
@@ -449,21 +912,33 @@ func (m *Model) viewProfiles() string {
 func (m *Model) viewProfileList() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("📋 Connection Profiles"))
+	s.WriteString(TitleStyle.Render(glyph("📋 Connection Profiles")))
 	s.WriteString("\n\n")
 
+	if m.Profile.filtering || m.Profile.filter.Value() != "" {
+		s.WriteString("Filter: " + m.Profile.filter.View())
+		s.WriteString("\n\n")
+	}
+
+	visible := m.visibleProfiles()
 	if len(m.Profile.profiles) == 0 {
 		s.WriteString(SubtleStyle.Render("No profiles yet. Press 'a' to add one."))
 		s.WriteString("\n\n")
+	} else if len(visible) == 0 {
+		s.WriteString(SubtleStyle.Render("No profiles match the filter. Press Esc to clear it."))
+		s.WriteString("\n\n")
 	} else {
-		for i, p := range m.Profile.profiles {
+		for i, p := range visible {
 			cursor := "  "
 			if i == m.Profile.cursor {
-				cursor = "▸ "
+				cursor = glyph("▸ ")
 			}
 
 			line := fmt.Sprintf("%s%s", cursor, p.Name)
 			detail := fmt.Sprintf(" (%s/%s)", p.DBHost, p.DBName)
+			if count, ok := m.Profile.connCounts[p.ID]; ok {
+				detail = fmt.Sprintf(" (%s/%s, %d conns)", p.DBHost, p.DBName, count)
+			}
 
 			if i == m.Profile.cursor {
 				s.WriteString(SelectedStyle.Render(line))
@@ -479,14 +954,18 @@ func (m *Model) viewProfileList() string {
 
 	if m.Profile.message != "" {
 		if m.Profile.messageType == "error" {
-			s.WriteString(ErrorStyle.Render("✗ " + m.Profile.message))
+			s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Profile.message))
 		} else {
-			s.WriteString(SuccessStyle.Render("✓ " + m.Profile.message))
+			s.WriteString(SuccessStyle.Render(glyph("✓ ") + m.Profile.message))
 		}
 		s.WriteString("\n\n")
 	}
 
-	s.WriteString(SubtleStyle.Render("[a]dd  [e]dit  [d]elete  [t]est  [r]efresh  [q]back"))
+	if m.Profile.filtering {
+		s.WriteString(SubtleStyle.Render("[Enter] apply filter  [Esc] clear"))
+		return s.String()
+	}
+	s.WriteString(SubtleStyle.Render("[a]dd  [e]dit  [c]opy  [f]ernet clone  [d]elete  [t]est  [T]est all  [r]efresh  [o]rphans  [/] filter  [q]back"))
 
 	return s.String()
 }
@@ -507,14 +986,14 @@ func (m *Model) viewProfileForm(title string) string {
 
 	if m.Profile.message != "" {
 		if m.Profile.messageType == "error" {
-			s.WriteString(ErrorStyle.Render("✗ " + m.Profile.message))
+			s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Profile.message))
 		} else {
-			s.WriteString(SuccessStyle.Render("✓ " + m.Profile.message))
+			s.WriteString(SuccessStyle.Render(glyph("✓ ") + m.Profile.message))
 		}
 		s.WriteString("\n\n")
 	}
 
-	s.WriteString(SubtleStyle.Render("[Tab] next  [Ctrl+S] save  [Ctrl+G] gen fernet  [Esc] cancel"))
+	s.WriteString(SubtleStyle.Render("[Tab] next  [Ctrl+S] save  [Ctrl+T] test  [Ctrl+G] gen fernet  [Ctrl+R] reveal  [Esc] cancel"))
 
 	return s.String()
 }
@@ -522,15 +1001,21 @@ func (m *Model) viewProfileForm(title string) string {
 func (m *Model) viewProfileDelete() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("⚠️  Delete Profile"))
+	s.WriteString(TitleStyle.Render(glyph("⚠️  Delete Profile")))
 	s.WriteString("\n\n")
 
-	var profileName string
-	for _, p := range m.Profile.profiles {
-		if p.ID == m.Profile.deleteID {
-			profileName = p.Name
-			break
+	profileName := m.deleteProfileName()
+
+	if m.ConfirmDeleteByName {
+		s.WriteString(fmt.Sprintf("Type '%s' to delete it. This action cannot be undone.\n\n", profileName))
+		s.WriteString(m.Profile.deleteInput.View())
+		s.WriteString("\n\n")
+		if m.Profile.message != "" {
+			s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Profile.message))
+			s.WriteString("\n\n")
 		}
+		s.WriteString(SubtleStyle.Render("[Enter] delete  [Esc] cancel"))
+		return s.String()
 	}
 
 	s.WriteString(fmt.Sprintf("Are you sure you want to delete '%s'?\n\n", profileName))
@@ -539,3 +1024,63 @@ func (m *Model) viewProfileDelete() string {
 
 	return s.String()
 }
+
+func (m *Model) viewProfileCleanOrphans() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("⚠️  Orphaned Secrets")))
+	s.WriteString("\n\n")
+
+	s.WriteString("These secrets belong to no saved profile:\n\n")
+	for _, key := range m.Profile.orphans {
+		s.WriteString(fmt.Sprintf("  %s\n", key))
+	}
+	s.WriteString("\nDelete them? This action cannot be undone.\n\n")
+	s.WriteString(SubtleStyle.Render("[y]es  [n]o"))
+
+	return s.String()
+}
+
+func (m *Model) viewProfileTestResults() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("📋 Profile Test Results")))
+	s.WriteString("\n\n")
+
+	width := len("Profile")
+	failed := 0
+	for _, r := range m.Profile.testResults {
+		width = max(width, len(r.name))
+		if r.err != nil {
+			failed++
+		}
+	}
+
+	s.WriteString(SubtleStyle.Render(fmt.Sprintf("  %-*s  %-6s  %s", width, "Profile", "Status", "Latency")))
+	s.WriteString("\n")
+	for _, r := range m.Profile.testResults {
+		if r.err != nil {
+			s.WriteString(fmt.Sprintf("  %-*s  ", width, r.name))
+			s.WriteString(ErrorStyle.Render(fmt.Sprintf("%-6s", "fail")))
+			s.WriteString(fmt.Sprintf("  %dms  ", r.latency.Milliseconds()))
+			s.WriteString(SubtleStyle.Render(r.err.Error()))
+		} else {
+			s.WriteString(fmt.Sprintf("  %-*s  ", width, r.name))
+			s.WriteString(SuccessStyle.Render(fmt.Sprintf("%-6s", "ok")))
+			s.WriteString(fmt.Sprintf("  %dms", r.latency.Milliseconds()))
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString("\n")
+
+	if failed > 0 {
+		s.WriteString(ErrorStyle.Render(fmt.Sprintf(glyph("✗ %d of %d profiles unreachable"), failed, len(m.Profile.testResults))))
+	} else {
+		s.WriteString(SuccessStyle.Render(fmt.Sprintf(glyph("✓ All %d profiles reachable"), len(m.Profile.testResults))))
+	}
+	s.WriteString("\n\n")
+
+	s.WriteString(SubtleStyle.Render("[Enter/Esc] back"))
+
+	return s.String()
+}
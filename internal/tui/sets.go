@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/flevanti/airflow-migrator/internal/core/models"
+)
+
+// setPrompt asks for the name of a connection set on a selection list: 'l' loads a
+// set into the selection, 's' saves the selection as a set.
+type setPrompt struct {
+	input  textinput.Model
+	active bool
+	saving bool // Save the selection under the name instead of loading a set
+	notice string
+}
+
+func newSetPrompt() setPrompt {
+	input := textinput.New()
+	input.Placeholder = "Set name"
+	input.CharLimit = 100
+	return setPrompt{input: input}
+}
+
+// open shows the prompt, to save the selection or to load a set.
+func (p *setPrompt) open(saving bool) tea.Cmd {
+	p.active = true
+	p.saving = saving
+	p.notice = ""
+	p.input.SetValue("")
+	return p.input.Focus()
+}
+
+// updateSetPrompt handles a key while the prompt is open. On Enter it loads or saves the
+// named set with selected, limited to the available IDs, and returns an error to show.
+func (m *Model) updateSetPrompt(p *setPrompt, msg tea.KeyMsg, selected map[string]bool, available []string) (string, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		p.active = false
+		p.input.Blur()
+		return "", nil
+	case "enter":
+		name := strings.TrimSpace(p.input.Value())
+		if name == "" {
+			return "Type a set name", nil
+		}
+		p.active = false
+		p.input.Blur()
+		if p.saving {
+			return m.saveConnectionSet(p, name, selected), nil
+		}
+		return m.loadConnectionSet(p, name, selected, available), nil
+	}
+
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return "", cmd
+}
+
+// saveConnectionSet saves the selected IDs as the set name.
+func (m *Model) saveConnectionSet(p *setPrompt, name string, selected map[string]bool) string {
+	var ids []string
+	for id, ok := range selected {
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+	if err := m.Migrator.SaveConnectionSet(m.Secrets, name, ids); err != nil {
+		return err.Error()
+	}
+	p.notice = fmt.Sprintf("Saved %d connections as set %q", len(ids), name)
+	return ""
+}
+
+// loadConnectionSet replaces the selection with the available connections of the set name.
+func (m *Model) loadConnectionSet(p *setPrompt, name string, selected map[string]bool, available []string) string {
+	var set *models.ConnectionSet
+	for _, s := range m.Migrator.ListConnectionSets(m.Secrets) {
+		if s.Name == name {
+			set = &s
+			break
+		}
+	}
+	if set == nil {
+		return fmt.Sprintf("No connection set named %q", name)
+	}
+
+	exists := make(map[string]bool, len(available))
+	for _, id := range available {
+		exists[id] = true
+	}
+	found := 0
+	for _, id := range set.ConnectionIDs {
+		if exists[id] {
+			found++
+		}
+	}
+	if found == 0 {
+		return fmt.Sprintf("None of the connections of set %q are here", name)
+	}
+
+	clear(selected)
+	for _, id := range set.ConnectionIDs {
+		if exists[id] {
+			selected[id] = true
+		}
+	}
+	p.notice = fmt.Sprintf("Loaded set %q: %d of %d connections", name, found, len(set.ConnectionIDs))
+	return ""
+}
+
+// viewSetPrompt renders the open prompt with the names of the saved sets, or the
+// notice of the last load or save.
+func (m *Model) viewSetPrompt(p *setPrompt) string {
+	if !p.active {
+		if p.notice == "" {
+			return ""
+		}
+		return SuccessStyle.Render(glyph("✓ ")+p.notice) + "\n\n"
+	}
+
+	var s strings.Builder
+	if p.saving {
+		s.WriteString("Save selection as set: ")
+	} else {
+		s.WriteString("Load set: ")
+	}
+	s.WriteString(p.input.View())
+	s.WriteString("\n")
+
+	var names []string
+	for _, set := range m.Migrator.ListConnectionSets(m.Secrets) {
+		names = append(names, set.Name)
+	}
+	if len(names) > 0 {
+		s.WriteString(SubtleStyle.Render("Saved sets: " + strings.Join(names, ", ")))
+	} else {
+		s.WriteString(SubtleStyle.Render("No saved sets yet"))
+	}
+	s.WriteString("\n\n")
+	return s.String()
+}
@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/flevanti/airflow-migrator/internal/app"
+)
+
+// statusContext describes the current screen and the profile/file it operates on.
+func (m *Model) statusContext() string {
+	parts := []string{}
+	if m.Lock.locked {
+		return "Locked"
+	}
+
+	switch m.State {
+	case StateMainMenu:
+		parts = append(parts, "Main menu")
+	case StateProfiles:
+		parts = append(parts, "Profiles")
+	case StateExport:
+		parts = append(parts, "Export")
+		if m.Export.state > exportSelectProfile && m.Export.selectedProfile != nil {
+			parts = append(parts, "from "+m.Export.selectedProfile.Name)
+		}
+	case StateImport:
+		parts = append(parts, "Import")
+		if m.Import.state > importSelectFile && m.Import.selectedFile != "" {
+			parts = append(parts, "file "+m.Import.selectedFile)
+		}
+		if m.Import.state > importSelectProfile && m.Import.selectedProfile != nil {
+			parts = append(parts, "into "+m.Import.selectedProfile.Name)
+		}
+	case StateAbout:
+		parts = append(parts, "About")
+	case StateBackup:
+		parts = append(parts, "Backup")
+	}
+
+	return strings.Join(parts, glyph(" › "))
+}
+
+// viewStatusBar renders the one-line status bar with a right-aligned hint.
+func (m *Model) viewStatusBar() string {
+	left := " " + app.Name + glyph(" │ ") + m.statusContext()
+	right := "ctrl+c quit "
+	if m.quitPending && m.busy() {
+		right = "ctrl+c again to force quit "
+	}
+
+	gap := m.Width - lipgloss.Width(left) - lipgloss.Width(right)
+	if gap < 2 {
+		gap = 2
+	}
+
+	return StatusBarStyle.Render(left + strings.Repeat(" ", gap) + right)
+}
+
+// withStatusBar appends the status bar to a screen, pinned to the last terminal line.
+// Screens taller than the terminal lose lines at the top, so the bar stays visible.
+func (m *Model) withStatusBar(screen string) string {
+	if pad := m.Height - lipgloss.Height(screen) - 1; pad > 0 {
+		screen += strings.Repeat("\n", pad)
+	}
+	return screen + "\n" + m.viewStatusBar()
+}
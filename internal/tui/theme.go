@@ -0,0 +1,186 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SettingsFile holds the TUI display settings in the config directory
+const SettingsFile = "tui.json"
+
+// Settings are the TUI display preferences kept in SettingsFile
+type Settings struct {
+	Theme string `json:"theme,omitempty"` // "dark" (default) or "light"
+	ASCII bool   `json:"ascii,omitempty"` // Replace emoji and symbols with plain ASCII
+}
+
+// LoadSettings reads SettingsFile from configDir. A missing file gives the defaults.
+func LoadSettings(configDir string) (Settings, error) {
+	var settings Settings
+
+	data, err := os.ReadFile(filepath.Join(configDir, SettingsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, fmt.Errorf("failed to read %s: %w", SettingsFile, err)
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("failed to parse %s: %w", SettingsFile, err)
+	}
+	if _, ok := themes[settings.Theme]; !ok && settings.Theme != "" {
+		return Settings{}, fmt.Errorf("unknown theme %q in %s", settings.Theme, SettingsFile)
+	}
+	return settings, nil
+}
+
+// SaveSettings writes settings to SettingsFile in configDir.
+func SaveSettings(configDir string, settings Settings) error {
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(configDir, SettingsFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", SettingsFile, err)
+	}
+	return nil
+}
+
+// Theme is the set of colors the styles are built from
+type Theme struct {
+	Title       lipgloss.TerminalColor
+	Error       lipgloss.TerminalColor
+	Success     lipgloss.TerminalColor
+	Subtle      lipgloss.TerminalColor
+	Selected    lipgloss.TerminalColor
+	StatusBarFg lipgloss.TerminalColor
+	StatusBarBg lipgloss.TerminalColor
+}
+
+// themeNames lists the themes in the order the main menu cycles through them
+var themeNames = []string{"dark", "light"}
+
+var themes = map[string]Theme{
+	"dark": {
+		Title:       lipgloss.Color("63"),
+		Error:       lipgloss.Color("196"),
+		Success:     lipgloss.Color("42"),
+		Subtle:      lipgloss.Color("241"),
+		Selected:    lipgloss.Color("212"),
+		StatusBarFg: lipgloss.Color("252"),
+		StatusBarBg: lipgloss.Color("237"),
+	},
+	"light": {
+		Title:       lipgloss.Color("55"),
+		Error:       lipgloss.Color("160"),
+		Success:     lipgloss.Color("28"),
+		Subtle:      lipgloss.Color("238"),
+		Selected:    lipgloss.Color("125"),
+		StatusBarFg: lipgloss.Color("235"),
+		StatusBarBg: lipgloss.Color("252"),
+	},
+}
+
+// noColorTheme keeps the bold and layout of the styles but no colors
+var noColorTheme = Theme{
+	Title:       lipgloss.NoColor{},
+	Error:       lipgloss.NoColor{},
+	Success:     lipgloss.NoColor{},
+	Subtle:      lipgloss.NoColor{},
+	Selected:    lipgloss.NoColor{},
+	StatusBarFg: lipgloss.NoColor{},
+	StatusBarBg: lipgloss.NoColor{},
+}
+
+// ApplyTheme rebuilds the package styles from t.
+func ApplyTheme(t Theme) {
+	TitleStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Title).
+		MarginBottom(1)
+
+	ErrorStyle = lipgloss.NewStyle().
+		Foreground(t.Error)
+
+	SuccessStyle = lipgloss.NewStyle().
+		Foreground(t.Success)
+
+	SubtleStyle = lipgloss.NewStyle().
+		Foreground(t.Subtle)
+
+	SelectedStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(t.Selected)
+
+	StatusBarStyle = lipgloss.NewStyle().
+		Foreground(t.StatusBarFg).
+		Background(t.StatusBarBg)
+}
+
+// nextTheme returns the theme after name in themeNames, wrapping around.
+func nextTheme(name string) string {
+	for i, n := range themeNames {
+		if n == name {
+			return themeNames[(i+1)%len(themeNames)]
+		}
+	}
+	// The default theme is the first, so an empty name moves to the second
+	return themeNames[1%len(themeNames)]
+}
+
+// asciiReplacer swaps the emoji and symbols of the views' own text for ASCII, for
+// terminals that cannot render them. The main menu emoji become letter markers ([P], [E], ...),
+// and emoji followed by spaces go first so columns stay aligned.
+var asciiReplacer = strings.NewReplacer(
+	"✈️  ", "", "⚠️  ", "! ", "ℹ️  ", "[i] ",
+	"📋 ", "[P] ", "📤 ", "[E] ", "📥 ", "[I] ", "💾 ", "[B] ",
+	"🔗 ", "", "🔍 ", "", "🔀 ", "", "🐛 ", "", "🔒 ", "", "🔓 ", "",
+	"✈️", "", "⚠️", "!", "ℹ️", "[i]",
+	"📋", "[P]", "📤", "[E]", "📥", "[I]", "💾", "[B]",
+	"🔗", "", "🔍", "", "🔀", "", "🐛", "",
+	"✓", "+", "✗", "x", "⚠", "!", "▸", ">", "•", "*",
+	"↑", "^", "↓", "v", "→", "->", "›", ">", "│", "|", "©", "(c)",
+)
+
+// asciiGlyphs is set by ApplyGlyphs
+var asciiGlyphs bool
+
+// ApplyGlyphs switches the glyphs of the views to ASCII, or back to emoji and symbols.
+func ApplyGlyphs(ascii bool) {
+	asciiGlyphs = ascii
+}
+
+// glyph returns the fixed view text s with its emoji and symbols swapped for ASCII when
+// ApplyGlyphs turned that on. Profile names, messages and other data are never passed
+// through it, so they show as they are.
+func glyph(s string) string {
+	if !asciiGlyphs {
+		return s
+	}
+	return asciiReplacer.Replace(s)
+}
+
+// maskGlyph returns the character password inputs echo.
+func maskGlyph() rune {
+	if asciiGlyphs {
+		return '*'
+	}
+	return '•'
+}
+
+// ASCIITerminal reports whether the terminal is unlikely to render emoji: the Linux
+// console, dumb terminals and the legacy Windows console (outside Windows Terminal).
+func ASCIITerminal() bool {
+	switch os.Getenv("TERM") {
+	case "linux", "dumb":
+		return true
+	}
+	return runtime.GOOS == "windows" && os.Getenv("WT_SESSION") == ""
+}
@@ -1,33 +1,28 @@
 package tui
 
 import (
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/flevanti/airflow-migrator/internal/core"
 	"github.com/flevanti/airflow-migrator/internal/secrets"
 )
 
-// Styles
+// Styles, built from the current theme by ApplyTheme
 var (
-	TitleStyle = lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("63")).
-		MarginBottom(1)
-
-	ErrorStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("196"))
-
-	SuccessStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("42"))
-
-	SubtleStyle = lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241"))
-
-	SelectedStyle = lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("212"))
+	TitleStyle     lipgloss.Style
+	ErrorStyle     lipgloss.Style
+	SuccessStyle   lipgloss.Style
+	SubtleStyle    lipgloss.Style
+	SelectedStyle  lipgloss.Style
+	StatusBarStyle lipgloss.Style
 )
 
+func init() {
+	ApplyTheme(themes["dark"])
+}
+
 // App state
 type AppState int
 
@@ -37,6 +32,8 @@ const (
 	StateExport
 	StateImport
 	StateAbout
+	StateBackup
+	StateWizard
 )
 
 // Model is the main TUI model
@@ -48,15 +45,40 @@ type Model struct {
 	Width     int
 	Height    int
 
+	// ConfirmDeleteByName requires typing the profile name to delete a profile
+	ConfirmDeleteByName bool
+
+	// FilenameTemplate names export files, see models.ExportRequest.FilenameTemplate
+	FilenameTemplate string
+
+	// Settings are the display preferences, saved to SettingsFile when changed
+	Settings Settings
+	// NoColor drops every color whatever the theme, as asked by NO_COLOR
+	NoColor bool
+	// ForceASCII renders plain ASCII symbols whatever Settings.ASCII says
+	ForceASCII  bool
+	settingsErr string
+
+	startErr string  // Why the -profile export could not be opened, shown on the main menu
+	initCmd  tea.Cmd // Returned by Init, set by StartExportWith
+
+	quitPending bool // ctrl+c was pressed once while busy; a second one quits
+
+	// LockAfter locks the secrets store after this long without input; zero never locks
+	LockAfter time.Duration
+	Lock      lockModel
+
 	// Sub-models
 	Profile profileModel
 	Export  exportModel
 	Import  importModel
+	Backup  backupModel
+	Wizard  wizardModel
 }
 
-// NewModel creates a new TUI model
+// NewModel creates a new TUI model. Without any saved profile it opens on the first-run wizard.
 func NewModel(configDir string, secrets *secrets.Store, migrator *core.Migrator) Model {
-	return Model{
+	m := Model{
 		State:     StateMainMenu,
 		ConfigDir: configDir,
 		Secrets:   secrets,
@@ -64,11 +86,40 @@ func NewModel(configDir string, secrets *secrets.Store, migrator *core.Migrator)
 		Profile:   newProfileModel(),
 		Export:    newExportModel(),
 		Import:    newImportModel(),
+		Backup:    newBackupModel(),
+		Lock:      newLockModel(),
+	}
+	if !hasProfiles(secrets) {
+		m.startWizard()
+	}
+	return m
+}
+
+// ApplySettings applies the theme in Settings, or no colors at all with NoColor, and
+// the ASCII glyphs with Settings.ASCII or ForceASCII.
+func (m *Model) ApplySettings() {
+	ApplyGlyphs(m.Settings.ASCII || m.ForceASCII)
+	switch {
+	case m.NoColor:
+		ApplyTheme(noColorTheme)
+	case m.Settings.Theme != "":
+		ApplyTheme(themes[m.Settings.Theme])
+	default:
+		ApplyTheme(themes["dark"])
+	}
+}
+
+// saveSettings applies and stores the settings, keeping the error for the main menu.
+func (m *Model) saveSettings() {
+	m.ApplySettings()
+	m.settingsErr = ""
+	if err := SaveSettings(m.ConfigDir, m.Settings); err != nil {
+		m.settingsErr = err.Error()
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.EnableMouseCellMotion
+	return tea.Batch(tea.EnableMouseCellMotion, m.initCmd, m.idleTick())
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -76,20 +127,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case connectionsLoadedMsg:
 		if msg.err != nil {
-			m.Export.err = "Failed to load connections: " + msg.err.Error()
-			m.Export.state = exportSelectProfile
+			m.Export.err = msg.err.Error()
+			m.Export.state = exportLoadError
 		} else {
-			m.Export.connections = msg.connections
-			m.Export.selected = make(map[string]bool)
-			// Select all by default
-			for _, c := range msg.connections {
-				m.Export.selected[c.ID] = true
+			m.setExportConnections(msg.connections, msg.dbCount)
+			m.Export.lastSelection = m.loadLastExportSelection(m.Export.selectedProfile.ID)
+			// Pre-check the last export of the profile, or select all by default
+			if m.restoreLastExportSelection() == 0 {
+				for _, c := range msg.connections {
+					m.Export.selected[c.ID] = true
+				}
 			}
 			m.Export.connCursor = 0
 			m.Export.state = exportSelectConnections
 		}
 		return m, nil
 
+	case profileTestsDoneMsg:
+		m.Profile.testResults = msg.results
+		m.Profile.state = profileTestResults
+		return m, nil
+
 	case exportCompleteMsg:
 		if msg.err != nil {
 			m.Export.err = msg.err.Error()
@@ -102,7 +160,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case importDecryptedMsg:
 		if msg.err != nil {
 			m.Import.err = msg.err.Error()
-			m.Import.state = importEnterKey
+			m.Import.state = importDecryptError
 		} else {
 			m.Import.records = msg.records
 			m.Import.selected = make(map[string]bool)
@@ -111,6 +169,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case backupCompleteMsg:
+		if msg.err != nil {
+			m.Backup.err = msg.err.Error()
+		} else if msg.result != nil && !msg.result.Success {
+			m.Backup.err = msg.result.Error
+		} else {
+			m.Backup.result = msg.result
+		}
+		m.Backup.state = backupResult
+		return m, nil
+
 	case importCompleteMsg:
 		if msg.err != nil {
 			m.Import.err = msg.err.Error()
@@ -120,11 +189,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.Import.state = importResult
 		return m, nil
 
+	case idleTickMsg:
+		cmd := m.checkIdle(time.Time(msg))
+		return m, tea.Batch(cmd, m.idleTick())
+
+	case tea.MouseMsg:
+		m.Lock.lastInput = time.Now()
+		if m.Lock.locked {
+			return m, nil
+		}
+
 	case tea.KeyMsg:
+		m.Lock.lastInput = time.Now()
 		switch msg.String() {
 		case "ctrl+c":
+			// Quitting mid-operation can leave a partial import or a half-written file
+			if m.busy() && !m.quitPending {
+				m.quitPending = true
+				return m, nil
+			}
 			return m, tea.Quit
 		}
+		m.quitPending = false
+		if m.Lock.locked {
+			return m.updateLock(msg)
+		}
 
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
@@ -142,14 +231,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateImport(msg)
 	case StateAbout:
 		return m.updateAbout(msg)
+	case StateBackup:
+		return m.updateBackup(msg)
+	case StateWizard:
+		return m.updateWizard(msg)
 	}
 
 	return m, nil
 }
 
+// busy reports whether an export, import or backup is running.
+func (m Model) busy() bool {
+	return m.Export.state == exportProcessing ||
+		m.Import.state == importProcessing ||
+		m.Backup.state == backupProcessing
+}
+
 func (m Model) updateMainMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.startErr = ""
 		switch msg.String() {
 		case "q":
 			return m, tea.Quit
@@ -168,12 +269,36 @@ func (m Model) updateMainMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "4", "a":
 			m.State = StateAbout
 			return m, nil
+		case "5", "b":
+			m.State = StateBackup
+			m.resetBackup()
+			return m, nil
+		case "t":
+			m.Settings.Theme = nextTheme(m.Settings.Theme)
+			m.saveSettings()
+			return m, nil
+		case "s":
+			m.Settings.ASCII = !m.Settings.ASCII
+			m.saveSettings()
+			return m, nil
 		}
 	}
 	return m, nil
 }
 
 func (m Model) View() string {
+	screen := m.viewScreen()
+	if m.Lock.locked {
+		screen = m.viewLock()
+	}
+	if m.quitPending && m.busy() {
+		screen += "\n\n" + ErrorStyle.Render(glyph("⚠ Still running, quitting now may leave it half done. Press ctrl+c again to quit anyway"))
+	}
+	return m.withStatusBar(screen)
+}
+
+// viewScreen renders the screen of the current state, without the status bar.
+func (m Model) viewScreen() string {
 	switch m.State {
 	case StateMainMenu:
 		return m.viewMainMenu()
@@ -185,22 +310,34 @@ func (m Model) View() string {
 		return m.viewImport()
 	case StateAbout:
 		return m.viewAbout()
+	case StateBackup:
+		return m.viewBackup()
+	case StateWizard:
+		return m.viewWizard()
 	default:
 		return "Not implemented yet...\n\nPress q to quit"
 	}
 }
 
 func (m Model) viewMainMenu() string {
-	s := TitleStyle.Render("✈️  Airflow Connection Migrator") + "\n\n"
+	s := TitleStyle.Render(glyph("✈️  Airflow Connection Migrator")) + "\n\n"
 
 	s += "What would you like to do?\n\n"
 
-	s += "  [1] 📋 Profiles     - Manage connection profiles\n"
-	s += "  [2] 📤 Export       - Export connections to CSV\n"
-	s += "  [3] 📥 Import       - Import connections from CSV\n"
-	s += "  [4] ℹ️  About        - About this application\n\n"
+	s += glyph("  [1] 📋 Profiles     - Manage connection profiles\n")
+	s += glyph("  [2] 📤 Export       - Export connections to CSV\n")
+	s += glyph("  [3] 📥 Import       - Import connections from CSV\n")
+	s += glyph("  [4] ℹ️  About        - About this application\n")
+	s += glyph("  [5] 💾 Backup       - Backup everything, one file per profile\n\n")
+
+	if m.settingsErr != "" {
+		s += ErrorStyle.Render(glyph("✗ ")+m.settingsErr) + "\n\n"
+	}
+	if m.startErr != "" {
+		s += ErrorStyle.Render(glyph("✗ ")+m.startErr) + "\n\n"
+	}
 
-	s += SubtleStyle.Render("Press number or letter • q to quit")
+	s += SubtleStyle.Render(glyph("Press number or letter • t theme • s plain symbols • q to quit"))
 
 	return s
 }
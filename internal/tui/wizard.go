@@ -0,0 +1,213 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/flevanti/airflow-migrator/internal/secrets"
+)
+
+// Wizard steps, in the order a first run walks through them
+type wizardStep int
+
+const (
+	wizardWelcome wizardStep = iota
+	wizardProfile
+	wizardTest
+	wizardExport
+)
+
+// wizardModel guides a first run through creating, testing and exporting from a profile.
+// It drives the profile and export sub-models rather than duplicating their screens.
+type wizardModel struct {
+	step      wizardStep
+	profileID string // The profile created in wizardProfile
+	testOK    bool
+}
+
+// hasProfiles reports whether the store holds at least one saved profile.
+func hasProfiles(store *secrets.Store) bool {
+	for _, key := range store.List() {
+		if strings.HasPrefix(key, "profile:") && strings.HasSuffix(key, ":meta") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) startWizard() {
+	m.State = StateWizard
+	m.Wizard = wizardModel{}
+}
+
+func (m *Model) updateWizard(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch m.Wizard.step {
+	case wizardWelcome:
+		return m.updateWizardWelcome(msg)
+	case wizardProfile:
+		return m.updateWizardProfile(msg)
+	case wizardTest:
+		return m.updateWizardTest(msg)
+	case wizardExport:
+		return m.updateWizardExport(msg)
+	}
+	return m, nil
+}
+
+func (m *Model) updateWizardWelcome(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			m.Wizard.step = wizardProfile
+			m.Profile.editingID = ""
+			m.resetProfileForm()
+			return m, nil
+		case "s", "esc", "q":
+			m.State = StateMainMenu
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+// updateWizardProfile uses the profile form, moving on to the test once the profile is saved.
+func (m *Model) updateWizardProfile(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			m.Wizard.step = wizardWelcome
+			m.Profile.message = ""
+			return m, nil
+		case "ctrl+s":
+			id := m.saveProfile()
+			if id == "" {
+				return m, nil
+			}
+			m.Wizard.profileID = id
+			m.wizardTestProfile()
+			return m, nil
+		}
+	}
+	return m.updateProfileForm(msg)
+}
+
+// wizardTestProfile tests the wizard's profile, leaving the outcome in the profile message.
+func (m *Model) wizardTestProfile() {
+	m.Wizard.step = wizardTest
+	m.testProfileConnection(m.Wizard.profileID)
+	m.Wizard.testOK = m.Profile.messageType == "success"
+}
+
+func (m *Model) updateWizardTest(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			if m.Wizard.testOK {
+				m.Wizard.step = wizardExport
+			}
+			return m, nil
+		case "r":
+			m.wizardTestProfile()
+			return m, nil
+		case "e":
+			// Saving again keeps the same profile, editingID points at it
+			m.Wizard.step = wizardProfile
+			m.Profile.editingID = m.Wizard.profileID
+			m.loadProfileIntoForm(m.Wizard.profileID)
+			return m, nil
+		case "s", "esc", "q":
+			m.finishWizard()
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) updateWizardExport(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "y", "Y", "enter":
+			return m, m.wizardStartExport()
+		case "n", "N", "esc", "q":
+			m.finishWizard()
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+// wizardStartExport opens the export screen on the wizard's profile.
+func (m *Model) wizardStartExport() tea.Cmd {
+	return m.exportProfile(m.Wizard.profileID)
+}
+
+// finishWizard leaves the wizard for the main menu.
+func (m *Model) finishWizard() {
+	m.State = StateMainMenu
+	m.Profile.message = ""
+}
+
+func (m *Model) viewWizard() string {
+	switch m.Wizard.step {
+	case wizardWelcome:
+		return m.viewWizardWelcome()
+	case wizardProfile:
+		return m.viewProfileForm("Step 1 of 3: Create a profile")
+	case wizardTest:
+		return m.viewWizardTest()
+	case wizardExport:
+		return m.viewWizardExport()
+	}
+	return ""
+}
+
+func (m *Model) viewWizardWelcome() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(glyph("✈️  Welcome to Airflow Connection Migrator")))
+	s.WriteString("\n\n")
+
+	s.WriteString("No profiles are saved yet. This short setup walks you through the usual order:\n\n")
+	s.WriteString("  1. Create a profile for an Airflow metadata database\n")
+	s.WriteString("  2. Test that the database can be reached\n")
+	s.WriteString("  3. Optionally export its connections to an encrypted CSV\n\n")
+
+	s.WriteString(SubtleStyle.Render("[Enter] start  [s]kip to the main menu"))
+
+	return s.String()
+}
+
+func (m *Model) viewWizardTest() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render("Step 2 of 3: Test the profile"))
+	s.WriteString("\n\n")
+
+	if m.Wizard.testOK {
+		s.WriteString(SuccessStyle.Render(glyph("✓ ") + m.Profile.message))
+		s.WriteString("\n\n")
+		s.WriteString(SubtleStyle.Render("[Enter] continue  [r]etest  [e]dit profile  [s]kip to the main menu"))
+		return s.String()
+	}
+
+	s.WriteString(ErrorStyle.Render(glyph("✗ ") + m.Profile.message))
+	s.WriteString("\n\n")
+	s.WriteString("The profile is saved. Fix it and test again, or finish and fix it later from Profiles.\n\n")
+	s.WriteString(SubtleStyle.Render("[e]dit profile  [r]etest  [s]kip to the main menu"))
+
+	return s.String()
+}
+
+func (m *Model) viewWizardExport() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render("Step 3 of 3: First export"))
+	s.WriteString("\n\n")
+
+	s.WriteString("Export connections from this profile now? You will pick the connections and the file key\n")
+	s.WriteString("on the export screen.\n\n")
+
+	s.WriteString(SubtleStyle.Render("[y]es  [n]o, go to the main menu"))
+
+	return s.String()
+}